@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+)
+
+// runImport runs the import subcommand:
+//
+//	app [-config path] import [-mapping field=column,...] [-mode atomic|skip] [-dry-run] file.csv
+//
+// The file is read from stdin if it is "-". The result is written to stdout
+// as JSON. It returns the exit code, 1 if any row is invalid.
+func runImport(ctx context.Context, imports *service.ImportService, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	mappingFlag := fs.String("mapping", "", "columns of fields, as comma separated field=column pairs")
+	mode := fs.String("mode", model.ImportAtomic, "atomic to import nothing if any row is invalid, skip to skip invalid rows")
+	dryRun := fs.Bool("dry-run", false, "validate rows without importing them")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: import [flags] file.csv")
+		fs.PrintDefaults()
+		return 2
+	}
+
+	mapping, err := service.ParseImportMapping(*mappingFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	result, err := imports.ImportCSV(ctx, in, model.ImportOptions{
+		Mapping: mapping,
+		Mode:    *mode,
+		DryRun:  *dryRun,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if result.Valid < result.Rows {
+		return 1
+	}
+	return 0
+}
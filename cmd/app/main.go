@@ -1,17 +1,31 @@
 package main
 
 import (
+	"context"
 	"github.com/Lirohop/App/internal/config"
 	"github.com/Lirohop/App/internal/database"
+	"github.com/Lirohop/App/internal/graph"
+	"github.com/Lirohop/App/internal/grpcserver"
 	"github.com/Lirohop/App/internal/handler"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/notify"
 	"github.com/Lirohop/App/internal/repository"
 	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/sink"
 	 httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/99designs/gqlgen/graphql/playground"
 	 _ "github.com/Lirohop/App/docs"
 	"fmt"
+	"errors"
+	"flag"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 const (
@@ -20,14 +34,26 @@ const (
 	logProd  = "prod"
 )
 
+const shutdownTimeout = 10 * time.Second
+
 
 func main() {
 
 	cfg := config.MustLoad()
-	logger := setupLogger(cfg.App.LogLevel)
+
+	// The import subcommand writes its result to stdout.
+	logOutput := os.Stdout
+	if flag.Arg(0) == "import" {
+		logOutput = os.Stderr
+	}
+
+	logger := setupLogger(cfg.App.LogLevel, logOutput)
 	slog.SetDefault(logger)
 	logger.Info("application starter", "port", cfg.App.Port, "log_level", cfg.App.LogLevel)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	pool, err := database.NewDatabase(cfg, logger)
 	if err != nil {
 		logger.Error("Failed to connect to database, exiting", "error", err)
@@ -40,68 +66,312 @@ func main() {
 	rep := repository.NewSubscriptionRepository(pool, logger)
 	logger.Info("Repository initialized")
 
-	subService := service.NewSubscriptionService(rep, logger)
+	transactor := repository.NewTransactor(pool)
+	outboxRep := repository.NewOutboxRepository(pool, logger)
+
+	categoryRep := repository.NewCategoryRepository(pool, logger)
+	categoryService := service.NewCategoryService(categoryRep, logger)
+	categoryHandler := handler.NewCategoryHandler(categoryService, logger)
+
+	catalogRep := repository.NewCatalogRepository(pool, logger)
+	catalogService := service.NewCatalogService(catalogRep, categoryRep, outboxRep, transactor, logger)
+	catalogHandler := handler.NewCatalogHandler(catalogService, logger)
+
+	switch cfg.Overlaps.Mode {
+	case model.OverlapReject, model.OverlapWarn, model.OverlapAllow:
+	default:
+		logger.Error("Unknown overlap mode, exiting", "mode", cfg.Overlaps.Mode)
+		os.Exit(1)
+	}
+
+	subService := service.NewSubscriptionService(rep, catalogRep, categoryRep, outboxRep, transactor, cfg.Overlaps.Mode, cfg.Trash.Admins, logger)
+
+	importService := service.NewImportService(subService, transactor, logger)
+	importHandler := handler.NewImportHandler(importService, logger)
+
+	if flag.Arg(0) == "import" {
+		code := runImport(ctx, importService, flag.Args()[1:])
+		pool.Close()
+		os.Exit(code)
+	}
+
+	webhookRep := repository.NewWebhookRepository(pool, logger)
+	webhookService := service.NewWebhookService(
+		webhookRep,
+		cfg.Webhooks.Timeout,
+		cfg.Webhooks.BatchSize,
+		cfg.Webhooks.MaxAttempts,
+		logger,
+	)
+	webhookHandler := handler.NewWebhookHandler(webhookService, logger)
+
+	go webhookService.RunDelivery(ctx, cfg.Webhooks.DeliveryInterval)
+
+	notifiers, err := notify.New(cfg.Notifications, logger)
+	if err != nil {
+		logger.Error("Failed to create notifier, exiting", "error", err)
+		panic(err)
+	}
+
+	notificationRep := repository.NewNotificationRepository(pool, logger)
+	notificationService := service.NewNotificationService(
+		notificationRep,
+		rep,
+		notifiers,
+		cfg.Reminders.RenewalLeadDays,
+		cfg.Reminders.EndLeadDays,
+		logger,
+	)
+	notificationHandler := handler.NewNotificationHandler(notificationService, logger)
+
+	go notificationService.RunReminders(ctx, cfg.Reminders.Interval)
+
+	budgetRep := repository.NewBudgetRepository(pool, logger)
+	budgetService := service.NewBudgetService(
+		budgetRep,
+		rep,
+		catalogRep,
+		categoryRep,
+		notificationService,
+		cfg.Budgets.Thresholds,
+		logger,
+	)
+	budgetHandler := handler.NewBudgetHandler(budgetService, logger)
+
+	go budgetService.RunEvaluation(ctx, cfg.Budgets.EvaluationInterval)
+
+	eventSink, err := sink.New(cfg.Events)
+	if err != nil {
+		logger.Error("Failed to create event sink, exiting", "error", err, "sink", cfg.Events.Sink)
+		panic(err)
+	}
+
+	// The relay hands every claimed event to the sinks in order, outside any
+	// transaction: webhook deliveries are queued first, then the event is
+	// published, and budgets are evaluated last. A failure in any sink leaves
+	// the event pending, so it is delivered at least once and sinks before the
+	// failing one may see it again.
+	relaySink := sink.Multi(webhookService, eventSink, budgetService)
+	defer relaySink.Close()
+
+	outboxService := service.NewOutboxService(outboxRep, relaySink, cfg.Events.BatchSize, logger)
+	go outboxService.RunRelay(ctx, cfg.Events.RelayInterval)
 
 	subHandler := handler.NewSubscriptionHandler(subService, logger)
 
-	http.HandleFunc("/subscriptions", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodPost:
-			subHandler.Create(w, r)
-		case http.MethodGet:
-			subHandler.List(w, r)
-		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
-		}
-	})
+	go subService.RunPurge(ctx, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+	go subService.RunTrialConversions(ctx, cfg.Trials.ConversionInterval, cfg.Trials.BatchSize)
 
-	http.HandleFunc("/subscriptions/get", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		subHandler.GetByID(w, r)
-	})
+	priceChangeRep := repository.NewPriceChangeRepository(pool, logger)
+	priceChangeService := service.NewPriceChangeService(priceChangeRep, subService, transactor, logger)
+	priceChangeHandler := handler.NewPriceChangeHandler(priceChangeService, logger)
 
-	http.HandleFunc("/subscriptions/delete", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		subHandler.Delete(w, r)
-	})
+	go priceChangeService.RunPriceChanges(ctx, cfg.PriceChanges.Interval, cfg.PriceChanges.BatchSize)
 
-	http.HandleFunc("/subscriptions/total-cost", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		subHandler.TotalCost(w, r)
-	})
+	idemRep := repository.NewIdempotencyRepository(pool, logger)
+	idemService := service.NewIdempotencyService(idemRep, cfg.Idempotency.TTL, logger)
+	idemHandler := handler.NewIdempotencyHandler(idemService, logger)
+
+	go idemService.RunPurge(ctx, cfg.Idempotency.PurgeInterval)
+
+	reportService := service.NewReportService(
+		rep,
+		catalogRep,
+		categoryRep,
+		priceChangeRep,
+		cfg.Reports.RenewalThreshold,
+		logger,
+	)
+	reportHandler := handler.NewReportHandler(reportService, logger)
+
+	calendarRep := repository.NewCalendarRepository(pool, logger)
+	calendarService := service.NewCalendarService(calendarRep, reportService, cfg.Calendar.HorizonMonths, logger)
+	calendarHandler := handler.NewCalendarHandler(calendarService, logger)
+
+	candidateRep := repository.NewCandidateRepository(pool, logger)
+	statementService := service.NewStatementService(candidateRep, subService, catalogRep, transactor, logger)
+	statementHandler := handler.NewStatementHandler(statementService, logger)
+
+	groupRep := repository.NewGroupRepository(pool, logger)
+	groupService := service.NewGroupService(groupRep, subService, transactor, logger)
+	groupHandler := handler.NewGroupHandler(groupService, logger)
+
+	auditRep := repository.NewAuditRepository(pool, logger)
+	auditService := service.NewAuditService(auditRep, logger)
+	auditHandler := handler.NewAuditHandler(auditService, logger)
+
+	graphHandler := graph.NewHandler(
+		subService,
+		priceChangeService,
+		reportService,
+		cfg.GraphQL.MaxDepth,
+		cfg.GraphQL.MaxComplexity,
+		logger,
+	)
+
+	grpcServer := grpcserver.NewServer(subService, logger)
+
+	// The gateway serves the gRPC API as JSON under /v1/ by calling the
+	// gRPC server over loopback, so it goes through the same interceptors.
+	gateway, err := grpcserver.NewGateway(ctx, fmt.Sprintf("localhost:%d", cfg.GRPC.Port))
+	if err != nil {
+		logger.Error("Failed to create gRPC gateway, exiting", "error", err)
+		panic(err)
+	}
+
+	http.HandleFunc("POST /subscriptions", idemHandler.Wrap(subHandler.Create))
+	http.HandleFunc("GET /subscriptions", subHandler.List)
+	http.HandleFunc("GET /subscriptions/get", subHandler.GetByID)
+	http.HandleFunc("DELETE /subscriptions/delete", subHandler.Delete)
+	http.HandleFunc("GET /subscriptions/total-cost", subHandler.TotalCost)
+	http.HandleFunc("GET /subscriptions/trials-ending", subHandler.TrialsEnding)
+	http.HandleFunc("POST /subscriptions:batch", subHandler.Batch)
+	http.HandleFunc("POST /subscriptions/import", importHandler.Import)
+	http.HandleFunc("GET /subscriptions/export", subHandler.Export)
+	http.HandleFunc("GET /subscriptions/trash", subHandler.Trash)
+	http.HandleFunc("GET /subscriptions/{id}", subHandler.Get)
+	http.HandleFunc("PUT /subscriptions/{id}", subHandler.Update)
+	http.HandleFunc("PATCH /subscriptions/{id}", subHandler.Patch)
+	http.HandleFunc("DELETE /subscriptions/{id}", subHandler.DeleteByPath)
+	http.HandleFunc("POST /subscriptions/{id}/restore", subHandler.Restore)
+	http.HandleFunc("POST /subscriptions/{id}/pause", subHandler.Pause)
+	http.HandleFunc("POST /subscriptions/{id}/resume", subHandler.Resume)
+	http.HandleFunc("POST /subscriptions/{id}/members", subHandler.AddMember)
+	http.HandleFunc("DELETE /subscriptions/{id}/members/{userId}", subHandler.RemoveMember)
+	http.HandleFunc("POST /subscriptions/{id}/price-changes", priceChangeHandler.Create)
+	http.HandleFunc("GET /subscriptions/{id}/price-changes", priceChangeHandler.List)
+	http.HandleFunc("DELETE /subscriptions/{id}/price-changes/{changeId}", priceChangeHandler.Delete)
+	http.HandleFunc("GET /subscriptions/{id}/history", auditHandler.History)
+	http.HandleFunc("GET /audit", auditHandler.List)
+
+	http.HandleFunc("GET /reports/cost-by-category", reportHandler.CostByCategory)
+	http.HandleFunc("GET /reports/spend", reportHandler.Spend)
+	http.HandleFunc("GET /reports/forecast", reportHandler.Forecast)
+
+	http.HandleFunc("POST /budgets", budgetHandler.Create)
+	http.HandleFunc("GET /budgets", budgetHandler.List)
+	http.HandleFunc("GET /budgets/{id}", budgetHandler.Get)
+	http.HandleFunc("GET /budgets/{id}/status", budgetHandler.Status)
+	http.HandleFunc("PUT /budgets/{id}", budgetHandler.Update)
+	http.HandleFunc("DELETE /budgets/{id}", budgetHandler.Delete)
+
+	http.HandleFunc("POST /groups", groupHandler.Create)
+	http.HandleFunc("GET /groups/{id}", groupHandler.Get)
+	http.HandleFunc("DELETE /groups/{id}", groupHandler.Delete)
+	http.HandleFunc("POST /groups/{id}/members", groupHandler.AddMember)
+	http.HandleFunc("PUT /groups/{id}/members/{userId}", groupHandler.UpdateMember)
+	http.HandleFunc("DELETE /groups/{id}/members/{userId}", groupHandler.RemoveMember)
+	http.HandleFunc("GET /groups/{id}/subscriptions", groupHandler.Subscriptions)
+	http.HandleFunc("GET /groups/{id}/total-cost", groupHandler.TotalCost)
+
+	http.HandleFunc("POST /categories", categoryHandler.Create)
+	http.HandleFunc("GET /categories", categoryHandler.List)
+	http.HandleFunc("GET /categories/{id}", categoryHandler.Get)
+	http.HandleFunc("PUT /categories/{id}", categoryHandler.Update)
+	http.HandleFunc("DELETE /categories/{id}", categoryHandler.Delete)
+
+	http.HandleFunc("POST /services", catalogHandler.Create)
+	http.HandleFunc("GET /services", catalogHandler.List)
+	http.HandleFunc("GET /services/search", catalogHandler.Search)
+	http.HandleFunc("GET /services/{id}", catalogHandler.Get)
+	http.HandleFunc("PUT /services/{id}", catalogHandler.Update)
+	http.HandleFunc("DELETE /services/{id}", catalogHandler.Delete)
+
+	http.HandleFunc("GET /users/{id}/notification-settings", notificationHandler.GetSettings)
+	http.HandleFunc("PUT /users/{id}/notification-settings", notificationHandler.SaveSettings)
+	http.HandleFunc("POST /users/{id}/calendar-token", calendarHandler.CreateToken)
+	http.HandleFunc("DELETE /users/{id}/calendar-token", calendarHandler.RevokeToken)
+	http.HandleFunc("GET /users/{id}/renewals.ics", calendarHandler.Renewals)
+	http.HandleFunc("GET /users/{id}/overlaps", subHandler.Overlaps)
+	http.HandleFunc("GET /users/{id}/groups", groupHandler.ListByUser)
+	http.HandleFunc("POST /users/{id}/statements", statementHandler.Import)
+	http.HandleFunc("GET /users/{id}/subscription-candidates", statementHandler.ListCandidates)
+
+	http.HandleFunc("POST /subscription-candidates/{id}/accept", statementHandler.Accept)
+	http.HandleFunc("POST /subscription-candidates/{id}/reject", statementHandler.Reject)
+
+	http.HandleFunc("POST /webhooks", webhookHandler.Create)
+	http.HandleFunc("GET /webhooks", webhookHandler.List)
+	http.HandleFunc("GET /webhooks/{id}", webhookHandler.Get)
+	http.HandleFunc("PUT /webhooks/{id}", webhookHandler.Update)
+	http.HandleFunc("DELETE /webhooks/{id}", webhookHandler.Delete)
+	http.HandleFunc("GET /webhooks/{id}/deliveries", webhookHandler.Deliveries)
+	http.HandleFunc("POST /webhooks/{id}/deliveries/{deliveryId}/redeliver", webhookHandler.Redeliver)
+
+	http.Handle("GET /graphql", graphHandler)
+	http.Handle("POST /graphql", graphHandler)
+
+	http.Handle("/v1/", gateway)
+
+	// GraphiQL is only served while developing.
+	if cfg.App.LogLevel == logDev {
+		http.Handle("GET /graphiql", playground.Handler("GraphQL", "/graphql"))
+	}
 
 	http.Handle("/swagger/", httpSwagger.WrapHandler)
 
 	logger.Debug("Startup complete, ready to handle requests")
 
+	grpcAddr := fmt.Sprintf(":%d", cfg.GRPC.Port)
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		logger.Error("Failed to listen for gRPC, exiting", "error", err)
+		panic(err)
+	}
+
+	go func() {
+		logger.Info("gRPC server listening", "addr", grpcAddr)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Error("gRPC server stopped unexpectedly", "error", err)
+		}
+	}()
+
 	addr := fmt.Sprintf(":%d", cfg.App.Port)
+	server := &http.Server{Addr: addr, Handler: handler.RequestContext(handler.LogRequests(logger, http.DefaultServeMux))}
+
+	go func() {
+		<-ctx.Done()
+		logger.Info("Shutting down HTTP and gRPC servers")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		grpcStopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(grpcStopped)
+		}()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("HTTP server shutdown failed", "error", err)
+		}
+
+		select {
+		case <-grpcStopped:
+		case <-shutdownCtx.Done():
+			logger.Error("gRPC server shutdown timed out, closing connections")
+			grpcServer.Stop()
+		}
+	}()
+
 	logger.Info("HTTP server listening", "addr", addr)
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Error("Server stopped unexpectedly", "error", err)
 	}
 }
 
-func setupLogger(logLevel string) (logger *slog.Logger) {
+func setupLogger(logLevel string, out io.Writer) (logger *slog.Logger) {
 
 	switch logLevel {
 	case logDebug:
-		logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		logger = slog.New(slog.NewTextHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	case logDev:
-		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		logger = slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	case logProd:
-		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		logger = slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelInfo}))
 
 	default:
-		logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		logger = slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	}
 
 	return
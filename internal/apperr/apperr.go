@@ -0,0 +1,97 @@
+// Package apperr sorts the errors returned by the services into kinds, so
+// that the HTTP and gRPC APIs report them alike.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+type Kind int
+
+const (
+	// Unknown errors are not caused by the request, such as a failed query.
+	Unknown Kind = iota
+	// Invalid requests are rejected before anything is read or written.
+	Invalid
+	NotFound
+	Forbidden
+	// VersionConflict means the stored version is not one the client
+	// expected; the client should read it again.
+	VersionConflict
+	// Conflict means the state of the stored data does not allow the
+	// change, such as an overlap or resuming a subscription that is not
+	// paused.
+	Conflict
+	// Exists means the change would create something that already exists.
+	Exists
+	// Aborted means the change was rolled back because of another one, as
+	// in an atomic batch.
+	Aborted
+)
+
+// invalidError marks an error as caused by an invalid request.
+type invalidError struct {
+	err error
+}
+
+func (e *invalidError) Error() string { return e.err.Error() }
+func (e *invalidError) Unwrap() error { return e.err }
+
+// Invalidf returns an error of kind Invalid, formatted like fmt.Errorf.
+func Invalidf(format string, args ...any) error {
+	return &invalidError{err: fmt.Errorf(format, args...)}
+}
+
+// KindOf returns the kind of an error returned by the services.
+func KindOf(err error) Kind {
+	var (
+		invalid  *invalidError
+		conflict *model.VersionConflictError
+	)
+
+	switch {
+	case errors.As(err, &invalid):
+		return Invalid
+	case errors.Is(err, model.ErrNotFound),
+		errors.Is(err, model.ErrServiceNotFound),
+		errors.Is(err, model.ErrCategoryNotFound),
+		errors.Is(err, model.ErrWebhookNotFound),
+		errors.Is(err, model.ErrDeliveryNotFound),
+		errors.Is(err, model.ErrPriceChangeNotFound),
+		errors.Is(err, model.ErrBudgetNotFound),
+		errors.Is(err, model.ErrCalendarTokenNotFound),
+		errors.Is(err, model.ErrCandidateNotFound),
+		errors.Is(err, model.ErrMemberNotFound),
+		errors.Is(err, model.ErrGroupNotFound),
+		errors.Is(err, model.ErrGroupMemberNotFound):
+		return NotFound
+	case errors.Is(err, model.ErrCalendarTokenInvalid),
+		errors.Is(err, model.ErrNotOwner),
+		errors.Is(err, model.ErrTrashForbidden),
+		errors.Is(err, model.ErrGroupForbidden):
+		return Forbidden
+	case errors.As(err, &conflict):
+		return VersionConflict
+	case errors.Is(err, model.ErrNotPaused),
+		errors.Is(err, model.ErrPauseConflict),
+		errors.Is(err, model.ErrServiceInUse),
+		errors.Is(err, model.ErrPriceChangeConflict),
+		errors.Is(err, model.ErrCandidateDecided),
+		errors.Is(err, model.ErrBatchDuplicate),
+		errors.Is(err, model.ErrSubscriptionOverlap),
+		errors.Is(err, model.ErrLastGroupOwner):
+		return Conflict
+	case errors.Is(err, model.ErrServiceNameTaken),
+		errors.Is(err, model.ErrCategoryNameTaken),
+		errors.Is(err, model.ErrMemberExists),
+		errors.Is(err, model.ErrGroupMemberExists):
+		return Exists
+	case errors.Is(err, model.ErrBatchAborted):
+		return Aborted
+	default:
+		return Unknown
+	}
+}
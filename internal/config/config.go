@@ -2,7 +2,9 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -21,9 +23,139 @@ type DBConfig struct {
 	SSLMode  string `yaml:"sslmode"`
 }
 
+type IdempotencyConfig struct {
+	TTL           time.Duration `yaml:"ttl" env-default:"24h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+	// Admins are the actors, as sent in X-Actor, that can read deleted
+	// subscriptions with include_deleted and list the trash.
+	Admins []string `yaml:"admins"`
+}
+
+type WebhookSinkConfig struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+type NATSSinkConfig struct {
+	URL     string `yaml:"url" env-default:"nats://localhost:4222"`
+	Subject string `yaml:"subject" env-default:"subscriptions.events"`
+}
+
+type KafkaSinkConfig struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic" env-default:"subscriptions.events"`
+}
+
+type EventsConfig struct {
+	Sink          string            `yaml:"sink" env-default:"stdout"`
+	FilePath      string            `yaml:"file_path"`
+	Webhook       WebhookSinkConfig `yaml:"webhook"`
+	NATS          NATSSinkConfig    `yaml:"nats"`
+	Kafka         KafkaSinkConfig   `yaml:"kafka"`
+	RelayInterval time.Duration     `yaml:"relay_interval" env-default:"1s"`
+	BatchSize     int               `yaml:"batch_size" env-default:"100"`
+}
+
+type WebhooksConfig struct {
+	DeliveryInterval time.Duration `yaml:"delivery_interval" env-default:"1s"`
+	BatchSize        int           `yaml:"batch_size" env-default:"50"`
+	MaxAttempts      int           `yaml:"max_attempts" env-default:"10"`
+	Timeout          time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"25"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+type NotifyWebhookConfig struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+type NotificationsConfig struct {
+	Notifiers []string            `yaml:"notifiers" env-default:"log"`
+	SMTP      SMTPConfig          `yaml:"smtp"`
+	Webhook   NotifyWebhookConfig `yaml:"webhook"`
+}
+
+type RemindersConfig struct {
+	Interval        time.Duration `yaml:"interval" env-default:"1h"`
+	RenewalLeadDays int           `yaml:"renewal_lead_days" env-default:"3"`
+	EndLeadDays     int           `yaml:"end_lead_days" env-default:"7"`
+}
+
+type TrialsConfig struct {
+	ConversionInterval time.Duration `yaml:"conversion_interval" env-default:"1m"`
+	BatchSize          int           `yaml:"batch_size" env-default:"100"`
+}
+
+type PriceChangesConfig struct {
+	Interval  time.Duration `yaml:"interval" env-default:"1m"`
+	BatchSize int           `yaml:"batch_size" env-default:"100"`
+}
+
+type ReportsConfig struct {
+	// RenewalThreshold flags forecast renewals costing more; 0 flags none.
+	RenewalThreshold int `yaml:"renewal_threshold" env-default:"0"`
+}
+
+type BudgetsConfig struct {
+	// Thresholds are the percentages of a budget at which alerts are sent.
+	Thresholds         []int         `yaml:"thresholds" env-default:"80,100"`
+	EvaluationInterval time.Duration `yaml:"evaluation_interval" env-default:"1h"`
+}
+
+type CalendarConfig struct {
+	// HorizonMonths is how many months, from the current one, feeds cover.
+	HorizonMonths int `yaml:"horizon_months" env-default:"12"`
+}
+
+type OverlapsConfig struct {
+	// Mode is what happens to a subscription overlapping another of the
+	// same user and service: reject, warn or allow.
+	Mode string `yaml:"mode" env-default:"warn"`
+}
+
+type GraphQLConfig struct {
+	// MaxDepth and MaxComplexity bound the queries served at /graphql.
+	// Every field costs 1, lists of subscriptions and price changes are
+	// assumed to hold 10 elements.
+	MaxDepth      int `yaml:"max_depth" env-default:"8"`
+	MaxComplexity int `yaml:"max_complexity" env-default:"1000"`
+}
+
+type GRPCConfig struct {
+	// Port serves the subscriptions.v1 API, next to the HTTP API on
+	// App.Port, which also serves it as JSON under /v1/.
+	Port int `yaml:"port" env-default:"9090"`
+}
+
 type Config struct {
-	App AppConfig `yaml:"app"`
-	DB  DBConfig  `yaml:"db"`
+	App           AppConfig           `yaml:"app"`
+	DB            DBConfig            `yaml:"db"`
+	Idempotency   IdempotencyConfig   `yaml:"idempotency"`
+	Trash         TrashConfig         `yaml:"trash"`
+	Events        EventsConfig        `yaml:"events"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Reminders     RemindersConfig     `yaml:"reminders"`
+	Trials        TrialsConfig        `yaml:"trials"`
+	PriceChanges  PriceChangesConfig  `yaml:"price_changes"`
+	Reports       ReportsConfig       `yaml:"reports"`
+	Budgets       BudgetsConfig       `yaml:"budgets"`
+	Calendar      CalendarConfig      `yaml:"calendar"`
+	Overlaps      OverlapsConfig      `yaml:"overlaps"`
+	GraphQL       GraphQLConfig       `yaml:"graphql"`
+	GRPC          GRPCConfig          `yaml:"grpc"`
 }
 
 func MustLoad() *Config {
@@ -42,9 +174,56 @@ func MustLoad() *Config {
 		panic("failed to read config: " + err.Error())
 	}
 
+	if err := cfg.validate(); err != nil {
+		panic("invalid config: " + err.Error())
+	}
+
 	return &cfg
 }
 
+// validate checks the durations and sizes of the background jobs, which
+// feed time.NewTicker and LIMIT clauses and must be positive.
+func (cfg *Config) validate() error {
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"idempotency.ttl", cfg.Idempotency.TTL},
+		{"idempotency.purge_interval", cfg.Idempotency.PurgeInterval},
+		{"trash.retention", cfg.Trash.Retention},
+		{"trash.purge_interval", cfg.Trash.PurgeInterval},
+		{"events.relay_interval", cfg.Events.RelayInterval},
+		{"webhooks.delivery_interval", cfg.Webhooks.DeliveryInterval},
+		{"reminders.interval", cfg.Reminders.Interval},
+		{"trials.conversion_interval", cfg.Trials.ConversionInterval},
+		{"price_changes.interval", cfg.PriceChanges.Interval},
+		{"budgets.evaluation_interval", cfg.Budgets.EvaluationInterval},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", d.name, d.value)
+		}
+	}
+
+	sizes := []struct {
+		name  string
+		value int
+	}{
+		{"events.batch_size", cfg.Events.BatchSize},
+		{"webhooks.batch_size", cfg.Webhooks.BatchSize},
+		{"webhooks.max_attempts", cfg.Webhooks.MaxAttempts},
+		{"trials.batch_size", cfg.Trials.BatchSize},
+		{"price_changes.batch_size", cfg.PriceChanges.BatchSize},
+	}
+	for _, s := range sizes {
+		if s.value <= 0 {
+			return fmt.Errorf("%s must be positive, got %d", s.name, s.value)
+		}
+	}
+
+	return nil
+}
+
 func fetchConfigPath() (configPath string) {
 
 	flag.StringVar(&configPath, "config", "", "path to config file")
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() *Config {
+		var cfg Config
+		cfg.Idempotency.TTL = 24 * time.Hour
+		cfg.Idempotency.PurgeInterval = time.Hour
+		cfg.Trash.Retention = 720 * time.Hour
+		cfg.Trash.PurgeInterval = time.Hour
+		cfg.Events.RelayInterval = time.Second
+		cfg.Events.BatchSize = 100
+		cfg.Webhooks.DeliveryInterval = time.Second
+		cfg.Webhooks.BatchSize = 50
+		cfg.Webhooks.MaxAttempts = 10
+		cfg.Reminders.Interval = time.Hour
+		cfg.Trials.ConversionInterval = time.Minute
+		cfg.Trials.BatchSize = 100
+		cfg.PriceChanges.Interval = time.Minute
+		cfg.PriceChanges.BatchSize = 100
+		cfg.Budgets.EvaluationInterval = time.Hour
+		return &cfg
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Config)
+	}{
+		{"zero interval", func(cfg *Config) { cfg.Reminders.Interval = 0 }},
+		{"negative interval", func(cfg *Config) { cfg.Budgets.EvaluationInterval = -time.Minute }},
+		{"zero batch size", func(cfg *Config) { cfg.PriceChanges.BatchSize = 0 }},
+		{"negative batch size", func(cfg *Config) { cfg.Events.BatchSize = -1 }},
+	}
+
+	if err := valid().validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.change(cfg)
+			if err := cfg.validate(); err == nil {
+				t.Error("validate = nil, want an error")
+			}
+		})
+	}
+}
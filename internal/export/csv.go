@@ -0,0 +1,29 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (c *csvWriter) Write(sub *model.Subscription) error {
+	return c.w.Write(newRecord(sub).cells())
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
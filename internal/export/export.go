@@ -0,0 +1,250 @@
+// Package export writes subscriptions in file formats for other tools.
+package export
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatNDJSON  = "ndjson"
+	FormatXLSX    = "xlsx"
+	FormatParquet = "parquet"
+)
+
+var contentTypes = map[string]string{
+	FormatCSV:     "text/csv",
+	FormatNDJSON:  "application/x-ndjson",
+	FormatXLSX:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatParquet: "application/vnd.apache.parquet",
+}
+
+// mediaTypes maps the media types accepted for each format, including
+// common aliases, to the format.
+var mediaTypes = map[string]string{
+	"text/csv":             FormatCSV,
+	"application/csv":      FormatCSV,
+	"application/x-ndjson": FormatNDJSON,
+	"application/jsonl":    FormatNDJSON,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FormatXLSX,
+	"application/vnd.apache.parquet":                                    FormatParquet,
+	"application/x-parquet":                                             FormatParquet,
+}
+
+// Writer writes subscriptions one at a time. Close must be called to
+// complete the file.
+type Writer interface {
+	Write(sub *model.Subscription) error
+	Close() error
+}
+
+// New creates a writer of the format to w.
+func New(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	case FormatParquet:
+		return newParquetWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType returns the media type of the format.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// formats are the formats in order of preference when the Accept header
+// rates several the same.
+var formats = []string{FormatCSV, FormatNDJSON, FormatXLSX, FormatParquet}
+
+// mediaRange is a media range of an Accept header.
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// Negotiate returns the format the Accept header rates highest, the one
+// listed first among equals, CSV for an empty header, and false if no
+// format is acceptable. Each format is rated by the most specific media
+// range matching it, so "text/csv;q=0, */*" accepts anything but CSV.
+func Negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return FormatCSV, true
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	best, bestQ, bestIndex := "", 0.0, len(ranges)
+	for _, format := range formats {
+		q, index := quality(format, ranges)
+		if q > bestQ || (q == bestQ && q > 0 && index < bestIndex) {
+			best, bestQ, bestIndex = format, q, index
+		}
+	}
+
+	return best, best != ""
+}
+
+// quality returns the quality of the most specific of ranges that matches
+// any media type of format, and its index, or 0 if none matches.
+func quality(format string, ranges []mediaRange) (float64, int) {
+	q, index, specificity := 0.0, len(ranges), -1
+
+	for mediaType, f := range mediaTypes {
+		if f != format {
+			continue
+		}
+
+		major, _, _ := strings.Cut(mediaType, "/")
+		for i, r := range ranges {
+			var s int
+			switch r.mediaType {
+			case mediaType:
+				s = 2
+			case major + "/*":
+				s = 1
+			case "*/*":
+				s = 0
+			default:
+				continue
+			}
+
+			if s > specificity || s == specificity && (r.q > q || r.q == q && i < index) {
+				q, index, specificity = r.q, i, s
+			}
+		}
+	}
+
+	return q, index
+}
+
+// Record is the row written for a subscription. Months are "MM-YYYY",
+// trial ends "YYYY-MM-DD".
+type Record struct {
+	ID          string     `json:"id" parquet:"id"`
+	ServiceID   string     `json:"service_id" parquet:"service_id"`
+	ServiceName string     `json:"service_name" parquet:"service_name"`
+	Price       int64      `json:"price" parquet:"price"`
+	UserID      string     `json:"user_id" parquet:"user_id"`
+	StartMonth  string     `json:"start_month" parquet:"start_month"`
+	EndMonth    *string    `json:"end_month" parquet:"end_month,optional"`
+	TrialEnd    *string    `json:"trial_end" parquet:"trial_end,optional"`
+	TrialPrice  int64      `json:"trial_price" parquet:"trial_price"`
+	CategoryID  *string    `json:"category_id" parquet:"category_id,optional"`
+	Tags        []string   `json:"tags" parquet:"tags,list"`
+	Status      string     `json:"status" parquet:"status"`
+	Version     int64      `json:"version" parquet:"version"`
+	DeletedAt   *time.Time `json:"deleted_at" parquet:"deleted_at,optional,timestamp(millisecond)"`
+}
+
+// columns are the column names of the tabular formats, in Record order.
+var columns = []string{
+	"id",
+	"service_id",
+	"service_name",
+	"price",
+	"user_id",
+	"start_month",
+	"end_month",
+	"trial_end",
+	"trial_price",
+	"category_id",
+	"tags",
+	"status",
+	"version",
+	"deleted_at",
+}
+
+func newRecord(sub *model.Subscription) Record {
+	r := Record{
+		ID:          sub.ID.String(),
+		ServiceID:   sub.ServiceID.String(),
+		ServiceName: sub.ServiceName,
+		Price:       int64(sub.Price),
+		UserID:      sub.UserId.String(),
+		StartMonth:  utils.ParseMonthYearToString(sub.StartDate),
+		TrialPrice:  int64(sub.TrialPrice),
+		Tags:        sub.Tags,
+		Status:      sub.Status(time.Now().UTC()),
+		Version:     int64(sub.Version),
+		DeletedAt:   sub.DeletedAt,
+	}
+	if sub.EndDate != nil {
+		end := utils.ParseMonthYearToString(*sub.EndDate)
+		r.EndMonth = &end
+	}
+	if sub.TrialEnd != nil {
+		trialEnd := sub.TrialEnd.Format(time.DateOnly)
+		r.TrialEnd = &trialEnd
+	}
+	if sub.CategoryID != nil {
+		categoryID := sub.CategoryID.String()
+		r.CategoryID = &categoryID
+	}
+	if r.Tags == nil {
+		r.Tags = []string{}
+	}
+	return r
+}
+
+// cells returns the values of the record in column order, as text. Tags
+// are separated by ";", like in imports.
+func (r Record) cells() []string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	deletedAt := ""
+	if r.DeletedAt != nil {
+		deletedAt = r.DeletedAt.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		r.ID,
+		r.ServiceID,
+		r.ServiceName,
+		fmt.Sprint(r.Price),
+		r.UserID,
+		r.StartMonth,
+		deref(r.EndMonth),
+		deref(r.TrialEnd),
+		fmt.Sprint(r.TrialPrice),
+		deref(r.CategoryID),
+		strings.Join(r.Tags, ";"),
+		r.Status,
+		fmt.Sprint(r.Version),
+		deletedAt,
+	}
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string // empty if nothing is acceptable
+	}{
+		{"", FormatCSV},
+		{"*/*", FormatCSV},
+		{"application/x-ndjson", FormatNDJSON},
+		{"application/jsonl", FormatNDJSON},
+		{"application/x-parquet, text/csv;q=0.5", FormatParquet},
+		{"text/csv;q=0.5, application/vnd.apache.parquet", FormatParquet},
+		// application/csv is an alias of text/csv.
+		{"application/*", FormatCSV},
+		{"text/csv;q=0, */*", FormatNDJSON},
+		{"application/x-ndjson;q=0.8, application/*;q=0.9, text/csv;q=0", FormatXLSX},
+		{"text/csv;q=0", ""},
+		{"application/json", ""},
+		{"text/csv;q=2, application/x-ndjson", FormatNDJSON},
+		{"not a media type, application/x-ndjson", FormatNDJSON},
+	}
+
+	for _, tt := range tests {
+		got, ok := Negotiate(tt.accept)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Negotiate(%q) = %q, %t, want %q", tt.accept, got, ok, tt.want)
+		}
+	}
+}
+
+func testSubscription() *model.Subscription {
+	end := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	trialEnd := time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)
+
+	return &model.Subscription{
+		ID:          uuid.MustParse("7a1d3c4e-2b5f-4e6a-8c9d-0e1f2a3b4c5d"),
+		ServiceID:   uuid.MustParse("8b2e4d5f-3c6a-4f7b-9d0e-1f2a3b4c5d6e"),
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		UserId:      uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:   time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &end,
+		TrialEnd:    &trialEnd,
+		TrialPrice:  1,
+		Tags:        []string{"family", "music"},
+		Version:     3,
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := New(FormatCSV, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(testSubscription()); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,service_id,service_name,price,user_id,start_month,end_month,trial_end,trial_price,category_id,tags,status,version,deleted_at\n" +
+		"7a1d3c4e-2b5f-4e6a-8c9d-0e1f2a3b4c5d,8b2e4d5f-3c6a-4f7b-9d0e-1f2a3b4c5d6e,Yandex Plus,400," +
+		"60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2024,06-2024,2024-02-15,1,,family;music,ended,3,\n"
+	if buf.String() != want {
+		t.Errorf("csv =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := New(FormatNDJSON, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := testSubscription()
+	sub.Tags = nil
+	for range 2 {
+		if err := w.Write(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("lines = %d, want 2", len(lines))
+	}
+
+	var got Record
+	if err := json.Unmarshal(lines[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := newRecord(sub); !reflect.DeepEqual(got, want) {
+		t.Errorf("record = %+v, want %+v", got, want)
+	}
+	if got.Tags == nil {
+		t.Error("tags = null, want an empty list")
+	}
+}
+
+func TestNewUnknownFormat(t *testing.T) {
+	if _, err := New("pdf", &bytes.Buffer{}); err == nil {
+		t.Error("New = nil error, want an error for an unknown format")
+	}
+}
@@ -0,0 +1,25 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+// ndjsonWriter writes one JSON object per line.
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonWriter) Write(sub *model.Subscription) error {
+	return n.enc.Encode(newRecord(sub))
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,28 @@
+package export
+
+import (
+	"io"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetWriter buffers rows into row groups and writes the footer on
+// Close.
+type parquetWriter struct {
+	w *parquet.GenericWriter[Record]
+}
+
+func newParquetWriter(w io.Writer) *parquetWriter {
+	return &parquetWriter{w: parquet.NewGenericWriter[Record](w)}
+}
+
+func (p *parquetWriter) Write(sub *model.Subscription) error {
+	_, err := p.w.Write([]Record{newRecord(sub)})
+	return err
+}
+
+func (p *parquetWriter) Close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"errors"
+	"io"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const xlsxSheet = "Subscriptions"
+
+// xlsxWriter writes rows to a temporary file as they come; the workbook,
+// a zip archive, is written to w on Close.
+type xlsxWriter struct {
+	w    io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+
+	if err := file.SetSheetName(file.GetSheetName(0), xlsxSheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	sw, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	x := &xlsxWriter{w: w, file: file, sw: sw}
+
+	header := make([]any, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+	if err := x.setRow(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return x, nil
+}
+
+func (x *xlsxWriter) Write(sub *model.Subscription) error {
+	r := newRecord(sub)
+	cells := r.cells()
+
+	values := make([]any, len(cells))
+	for i, c := range cells {
+		values[i] = c
+	}
+	// Numbers are written as numbers so that they can be summed up.
+	values[3], values[8], values[12] = r.Price, r.TrialPrice, r.Version
+
+	return x.setRow(values)
+}
+
+func (x *xlsxWriter) setRow(values []any) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.sw.SetRow(cell, values)
+}
+
+func (x *xlsxWriter) Close() error {
+	err := x.sw.Flush()
+	if err == nil {
+		err = x.file.Write(x.w)
+	}
+	return errors.Join(err, x.file.Close())
+}
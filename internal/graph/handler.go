@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Lirohop/App/internal/service"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// listComplexity is the number of elements assumed for list fields that
+// grow with the data, such as the subscriptions of a user, when the
+// complexity of a query is calculated.
+const listComplexity = 10
+
+// NewHandler serves the schema over GET and POST. Queries nested deeper
+// than maxDepth fields or more complex than maxComplexity are rejected
+// before they run; introspection fields do not count towards the depth.
+func NewHandler(
+	subs *service.SubscriptionService,
+	priceChanges *service.PriceChangeService,
+	reports *service.ReportService,
+	maxDepth, maxComplexity int,
+	logger *slog.Logger,
+) http.Handler {
+	r := &Resolver{
+		subs:         subs,
+		priceChanges: priceChanges,
+		reports:      reports,
+	}
+
+	cfg := Config{Resolvers: r}
+	cfg.Complexity.User.Subscriptions = func(childComplexity int) int {
+		return listComplexity * childComplexity
+	}
+	cfg.Complexity.Subscription.PriceHistory = func(childComplexity int) int {
+		return listComplexity * childComplexity
+	}
+
+	srv := handler.New(NewExecutableSchema(cfg))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(depthLimit{max: maxDepth})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+
+	srv.SetRecoverFunc(func(ctx context.Context, err any) error {
+		logger.Error("graphql resolver panicked", "error", err)
+		return fmt.Errorf("internal server error")
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		srv.ServeHTTP(w, req.WithContext(r.withLoaders(req.Context())))
+	})
+}
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// depthLimit rejects operations nested deeper than max fields.
+type depthLimit struct {
+	max int
+}
+
+func (d depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d depthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d depthLimit) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	op := opCtx.Doc.Operations.ForName(opCtx.OperationName)
+
+	if depth := selectionDepth(op.SelectionSet); depth > d.max {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.max)
+		errcode.Set(err, errDepthLimit)
+		return err
+	}
+
+	return nil
+}
+
+// selectionDepth returns how deep fields are nested in set. Fragments
+// were checked for cycles by validation.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if !strings.HasPrefix(sel.Name, "__") {
+				depth = max(depth, 1+selectionDepth(sel.SelectionSet))
+			}
+		case *ast.InlineFragment:
+			depth = max(depth, selectionDepth(sel.SelectionSet))
+		case *ast.FragmentSpread:
+			depth = max(depth, selectionDepth(sel.Definition.SelectionSet))
+		}
+	}
+
+	return depth
+}
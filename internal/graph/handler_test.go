@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+)
+
+func TestSelectionDepth(t *testing.T) {
+	schema := NewExecutableSchema(Config{Resolvers: &Resolver{}}).Schema()
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{
+			name:  "flat",
+			query: `{ subscription(id: "7a1d3c4e-2b5f-4e6a-8c9d-0e1f2a3b4c5d") { id price } }`,
+			want:  2,
+		},
+		{
+			name: "nested",
+			query: `{ user(id: "60601fee-2bf1-4721-ae6f-7636e79a0cba") {
+				subscriptions { owner { subscriptions { id } } }
+			} }`,
+			want: 5,
+		},
+		{
+			name: "fragment",
+			query: `query { user(id: "60601fee-2bf1-4721-ae6f-7636e79a0cba") { subscriptions { ...sub } } }
+				fragment sub on Subscription { members { user { id } } }`,
+			want: 5,
+		},
+		{
+			name:  "inline fragment",
+			query: `{ user(id: "60601fee-2bf1-4721-ae6f-7636e79a0cba") { ... on User { subscriptions { id } } } }`,
+			want:  3,
+		},
+		{
+			name:  "introspection",
+			query: `{ __schema { types { fields { type { name } } } } subscription(id: "7a1d3c4e-2b5f-4e6a-8c9d-0e1f2a3b4c5d") { __typename id } }`,
+			want:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, tt.query)
+			if errs != nil {
+				t.Fatal(errs)
+			}
+
+			if got := selectionDepth(doc.Operations[0].SelectionSet); got != tt.want {
+				t.Errorf("depth = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLimits checks that queries over the limits are rejected before any
+// resolver runs, which would fail without services.
+func TestLimits(t *testing.T) {
+	h := NewHandler(nil, nil, nil, 4, 100, slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{
+			name: "too deep",
+			query: `{ user(id: "60601fee-2bf1-4721-ae6f-7636e79a0cba") {
+				subscriptions { owner { subscriptions { id } } }
+			} }`,
+			code: errDepthLimit,
+		},
+		{
+			// 10 subscriptions with 10 price changes of 3 fields each.
+			name: "too complex",
+			query: `{ user(id: "60601fee-2bf1-4721-ae6f-7636e79a0cba") {
+				subscriptions { priceHistory { price effectiveMonth applied } }
+			} }`,
+			code: "COMPLEXITY_LIMIT_EXCEEDED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]string{"query": tt.query})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			var resp struct {
+				Errors []struct {
+					Extensions struct {
+						Code string `json:"code"`
+					} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Errors) != 1 || resp.Errors[0].Extensions.Code != tt.code {
+				t.Errorf("response = %s, want a %s error", rec.Body, tt.code)
+			}
+		})
+	}
+}
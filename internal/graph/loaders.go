@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+type loadersKey struct{}
+
+// loaders batch the fields that are resolved once per parent object into
+// one service call per field and request. They cache for the request only,
+// so that every request sees fresh data.
+type loaders struct {
+	subscriptions *dataloader.Loader[uuid.UUID, []*model.Subscription]
+	priceChanges  *dataloader.Loader[uuid.UUID, []*model.ScheduledPriceChange]
+	monthlyCosts  *dataloader.Loader[costKey, []model.MonthlyCost]
+}
+
+// costKey identifies the monthly costs of a user. Keys with the same
+// service and period are loaded together.
+type costKey struct {
+	UserID      uuid.UUID
+	ServiceName string
+	Start       time.Time
+	End         time.Time
+}
+
+type costPeriod struct {
+	ServiceName string
+	Start       time.Time
+	End         time.Time
+}
+
+func (r *Resolver) newLoaders() *loaders {
+	return &loaders{
+		subscriptions: dataloader.NewBatchedLoader(r.loadSubscriptions),
+		priceChanges:  dataloader.NewBatchedLoader(r.loadPriceChanges),
+		monthlyCosts:  dataloader.NewBatchedLoader(r.loadMonthlyCosts),
+	}
+}
+
+// withLoaders returns a context carrying new loaders for one request.
+func (r *Resolver) withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, r.newLoaders())
+}
+
+func loadersFor(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (r *Resolver) loadSubscriptions(ctx context.Context, userIDs []uuid.UUID) []*dataloader.Result[[]*model.Subscription] {
+	byUser, err := r.subs.ListByMembers(ctx, userIDs)
+
+	results := make([]*dataloader.Result[[]*model.Subscription], len(userIDs))
+	for i, id := range userIDs {
+		results[i] = &dataloader.Result[[]*model.Subscription]{Data: byUser[id], Error: err}
+	}
+
+	return results
+}
+
+func (r *Resolver) loadPriceChanges(ctx context.Context, subscriptionIDs []uuid.UUID) []*dataloader.Result[[]*model.ScheduledPriceChange] {
+	bySubscription, err := r.priceChanges.ListPriceChangesBySubscriptions(ctx, subscriptionIDs)
+
+	results := make([]*dataloader.Result[[]*model.ScheduledPriceChange], len(subscriptionIDs))
+	for i, id := range subscriptionIDs {
+		results[i] = &dataloader.Result[[]*model.ScheduledPriceChange]{Data: bySubscription[id], Error: err}
+	}
+
+	return results
+}
+
+func (r *Resolver) loadMonthlyCosts(ctx context.Context, keys []costKey) []*dataloader.Result[[]model.MonthlyCost] {
+	periods := make(map[costPeriod][]uuid.UUID)
+	for _, k := range keys {
+		p := costPeriod{ServiceName: k.ServiceName, Start: k.Start, End: k.End}
+		periods[p] = append(periods[p], k.UserID)
+	}
+
+	type loaded struct {
+		costs map[uuid.UUID][]model.MonthlyCost
+		err   error
+	}
+
+	byPeriod := make(map[costPeriod]loaded, len(periods))
+	for p, userIDs := range periods {
+		costs, err := r.subs.MonthlyCosts(ctx, userIDs, p.ServiceName, p.Start, p.End)
+		byPeriod[p] = loaded{costs: costs, err: err}
+	}
+
+	results := make([]*dataloader.Result[[]model.MonthlyCost], len(keys))
+	for i, k := range keys {
+		l := byPeriod[costPeriod{ServiceName: k.ServiceName, Start: k.Start, End: k.End}]
+		results[i] = &dataloader.Result[[]model.MonthlyCost]{Data: l.costs[k.UserID], Error: l.err}
+	}
+
+	return results
+}
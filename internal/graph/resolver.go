@@ -0,0 +1,73 @@
+package graph
+
+//go:generate go run github.com/99designs/gqlgen@v0.17.81 generate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// Resolver resolves the GraphQL schema with the same services as the REST
+// handlers. Fields that would query once per parent, such as the
+// subscriptions of a user, go through the loaders in the request context.
+type Resolver struct {
+	subs         *service.SubscriptionService
+	priceChanges *service.PriceChangeService
+	reports      *service.ReportService
+}
+
+// User is a user of the subscriptions. Users are not stored; a user is
+// known by the ID on subscriptions.
+type User struct {
+	ID uuid.UUID
+}
+
+// monthlyCosts loads the monthly costs of the user through the request's
+// loader, so that the totals of several users are calculated together.
+func (r *Resolver) monthlyCosts(
+	ctx context.Context,
+	userID uuid.UUID,
+	start, end string,
+	serviceName *string,
+) ([]model.MonthlyCost, error) {
+	startDate, endDate, err := parsePeriod(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	key := costKey{UserID: userID, Start: startDate, End: endDate}
+	if serviceName != nil {
+		key.ServiceName = *serviceName
+	}
+
+	return loadersFor(ctx).monthlyCosts.Load(ctx, key)()
+}
+
+func parsePeriod(start, end string) (time.Time, time.Time, error) {
+	startDate, err := utils.ParseMonthYear(start)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid start month")
+	}
+
+	endDate, err := utils.ParseMonthYear(end)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid end month")
+	}
+
+	return startDate, endDate, nil
+}
+
+func formatOptionalMonth(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	month := utils.ParseMonthYearToString(*t)
+	return &month
+}
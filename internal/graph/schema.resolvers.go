@@ -0,0 +1,207 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/utils"
+	"github.com/google/uuid"
+)
+
+// User is the resolver for the user field.
+func (r *memberResolver) User(ctx context.Context, obj *model.Member) (*User, error) {
+	return &User{ID: obj.UserID}, nil
+}
+
+// Month is the resolver for the month field.
+func (r *monthlyTotalResolver) Month(ctx context.Context, obj *model.MonthlyCost) (string, error) {
+	return utils.ParseMonthYearToString(obj.Month), nil
+}
+
+// StartMonth is the resolver for the startMonth field.
+func (r *pauseResolver) StartMonth(ctx context.Context, obj *model.Pause) (string, error) {
+	return utils.ParseMonthYearToString(obj.StartDate), nil
+}
+
+// ResumeMonth is the resolver for the resumeMonth field.
+func (r *pauseResolver) ResumeMonth(ctx context.Context, obj *model.Pause) (*string, error) {
+	return formatOptionalMonth(obj.ResumeDate), nil
+}
+
+// EffectiveMonth is the resolver for the effectiveMonth field.
+func (r *priceChangeResolver) EffectiveMonth(ctx context.Context, obj *model.ScheduledPriceChange) (string, error) {
+	return utils.ParseMonthYearToString(obj.EffectiveDate), nil
+}
+
+// Applied is the resolver for the applied field.
+func (r *priceChangeResolver) Applied(ctx context.Context, obj *model.ScheduledPriceChange) (bool, error) {
+	return obj.AppliedAt != nil, nil
+}
+
+// Subscription is the resolver for the subscription field.
+func (r *queryResolver) Subscription(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	sub, err := r.subs.GetSubscriptionById(ctx, id, false)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, nil
+	}
+	return sub, err
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id uuid.UUID) (*User, error) {
+	return &User{ID: id}, nil
+}
+
+// CostByCategory is the resolver for the costByCategory field.
+func (r *queryResolver) CostByCategory(ctx context.Context, userID *uuid.UUID, start string, end string) ([]*model.CategoryCost, error) {
+	startDate, endDate, err := parsePeriod(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	costs, err := r.reports.CostByCategory(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.CategoryCost, len(costs))
+	for i := range costs {
+		result[i] = &costs[i]
+	}
+	return result, nil
+}
+
+// Spend is the resolver for the spend field.
+func (r *queryResolver) Spend(ctx context.Context, userID *uuid.UUID, start string, end string, granularity *string) ([]*model.SpendBucket, error) {
+	startDate, endDate, err := parsePeriod(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := model.SpendFilter{UserID: userID, Start: startDate, End: endDate}
+	if granularity != nil {
+		filter.Granularity = *granularity
+	}
+
+	buckets, err := r.reports.Spend(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.SpendBucket, len(buckets))
+	for i := range buckets {
+		result[i] = &buckets[i]
+	}
+	return result, nil
+}
+
+// Period is the resolver for the period field.
+func (r *spendBucketResolver) Period(ctx context.Context, obj *model.SpendBucket) (string, error) {
+	return utils.ParseMonthYearToString(obj.Period), nil
+}
+
+// Owner is the resolver for the owner field.
+func (r *subscriptionResolver) Owner(ctx context.Context, obj *model.Subscription) (*User, error) {
+	return &User{ID: obj.UserId}, nil
+}
+
+// StartMonth is the resolver for the startMonth field.
+func (r *subscriptionResolver) StartMonth(ctx context.Context, obj *model.Subscription) (string, error) {
+	return utils.ParseMonthYearToString(obj.StartDate), nil
+}
+
+// EndMonth is the resolver for the endMonth field.
+func (r *subscriptionResolver) EndMonth(ctx context.Context, obj *model.Subscription) (*string, error) {
+	return formatOptionalMonth(obj.EndDate), nil
+}
+
+// TrialEnd is the resolver for the trialEnd field.
+func (r *subscriptionResolver) TrialEnd(ctx context.Context, obj *model.Subscription) (*string, error) {
+	if obj.TrialEnd == nil {
+		return nil, nil
+	}
+	trialEnd := obj.TrialEnd.Format(time.DateOnly)
+	return &trialEnd, nil
+}
+
+// Status is the resolver for the status field.
+func (r *subscriptionResolver) Status(ctx context.Context, obj *model.Subscription) (string, error) {
+	return obj.Status(time.Now().UTC()), nil
+}
+
+// PriceHistory is the resolver for the priceHistory field.
+func (r *subscriptionResolver) PriceHistory(ctx context.Context, obj *model.Subscription) ([]*model.ScheduledPriceChange, error) {
+	return loadersFor(ctx).priceChanges.Load(ctx, obj.ID)()
+}
+
+// Subscriptions is the resolver for the subscriptions field.
+func (r *userResolver) Subscriptions(ctx context.Context, obj *User) ([]*model.Subscription, error) {
+	return loadersFor(ctx).subscriptions.Load(ctx, obj.ID)()
+}
+
+// TotalCost is the resolver for the totalCost field.
+func (r *userResolver) TotalCost(ctx context.Context, obj *User, start string, end string, serviceName *string) (int, error) {
+	months, err := r.monthlyCosts(ctx, obj.ID, start, end, serviceName)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, m := range months {
+		total += m.Total
+	}
+	return total, nil
+}
+
+// MonthlyTotals is the resolver for the monthlyTotals field.
+func (r *userResolver) MonthlyTotals(ctx context.Context, obj *User, start string, end string, serviceName *string) ([]*model.MonthlyCost, error) {
+	months, err := r.monthlyCosts(ctx, obj.ID, start, end, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.MonthlyCost, len(months))
+	for i := range months {
+		result[i] = &months[i]
+	}
+	return result, nil
+}
+
+// Member returns MemberResolver implementation.
+func (r *Resolver) Member() MemberResolver { return &memberResolver{r} }
+
+// MonthlyTotal returns MonthlyTotalResolver implementation.
+func (r *Resolver) MonthlyTotal() MonthlyTotalResolver { return &monthlyTotalResolver{r} }
+
+// Pause returns PauseResolver implementation.
+func (r *Resolver) Pause() PauseResolver { return &pauseResolver{r} }
+
+// PriceChange returns PriceChangeResolver implementation.
+func (r *Resolver) PriceChange() PriceChangeResolver { return &priceChangeResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// SpendBucket returns SpendBucketResolver implementation.
+func (r *Resolver) SpendBucket() SpendBucketResolver { return &spendBucketResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type memberResolver struct{ *Resolver }
+type monthlyTotalResolver struct{ *Resolver }
+type pauseResolver struct{ *Resolver }
+type priceChangeResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type spendBucketResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
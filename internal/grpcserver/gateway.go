@@ -0,0 +1,40 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	subscriptionsv1 "github.com/Lirohop/App/api/subscriptions/v1"
+	"github.com/Lirohop/App/internal/requestctx"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// NewGateway returns a handler serving SubscriptionService as JSON under
+// /v1/, with the bindings of api/subscriptions/v1/gateway.yaml. Requests
+// are forwarded to the gRPC server at addr until ctx is done, with the
+// request id of handler.RequestContext and the actor header as metadata.
+func NewGateway(ctx context.Context, addr string) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithMetadata(forwardedMetadata))
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if err := subscriptionsv1.RegisterSubscriptionServiceHandlerFromEndpoint(ctx, mux, addr, opts); err != nil {
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+// forwardedMetadata sends the actor as the client sent it, so that a
+// missing actor is not turned into requestctx.SystemActor on the way.
+func forwardedMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	md := metadata.Pairs(strings.ToLower(requestctx.RequestIDHeader), requestctx.RequestID(ctx))
+	if actor := r.Header.Get(requestctx.ActorHeader); actor != "" {
+		md.Set(strings.ToLower(requestctx.ActorHeader), actor)
+	}
+	return md
+}
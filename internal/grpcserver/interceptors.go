@@ -0,0 +1,78 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Lirohop/App/internal/requestctx"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestContext is the gRPC counterpart of handler.RequestContext. It
+// reads the request id and the actor from x-request-id and x-actor
+// metadata and sends the request id back as header metadata.
+func requestContext(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	ctx, requestID := requestctx.FromHeaders(ctx, func(name string) string {
+		if values := md.Get(name); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	})
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(requestctx.RequestIDHeader), requestID)); err != nil {
+		return nil, err
+	}
+
+	return next(ctx, req)
+}
+
+// logRequests is the gRPC counterpart of handler.LogRequests. Health
+// checks are not logged, as they are polled.
+func logRequests(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		if info.FullMethod == healthpb.Health_Check_FullMethodName {
+			return next(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := next(ctx, req)
+		code := status.Code(err)
+
+		level := slog.LevelInfo
+		switch code {
+		case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+			level = slog.LevelError
+		}
+
+		requestctx.Logger(ctx, logger).Log(ctx, level, "request handled",
+			"method", info.FullMethod,
+			"code", code.String(),
+			"duration", time.Since(start))
+
+		return resp, err
+	}
+}
+
+// recoverPanics turns a panic in a handler into an Internal error instead
+// of crashing the server.
+func recoverPanics(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				requestctx.Logger(ctx, logger).Error("grpc handler panicked", "method", info.FullMethod, "error", p)
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return next(ctx, req)
+	}
+}
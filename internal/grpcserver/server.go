@@ -0,0 +1,58 @@
+// Package grpcserver serves the subscriptions.v1 API over gRPC with the
+// same services as the REST handlers.
+package grpcserver
+
+import (
+	"log/slog"
+	"net"
+
+	subscriptionsv1 "github.com/Lirohop/App/api/subscriptions/v1"
+	"github.com/Lirohop/App/internal/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves SubscriptionService together with the health and
+// reflection services.
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+}
+
+func NewServer(subs *service.SubscriptionService, logger *slog.Logger) *Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		requestContext,
+		logRequests(logger),
+		recoverPanics(logger),
+	))
+
+	subscriptionsv1.RegisterSubscriptionServiceServer(srv, &subscriptionServer{subs: subs})
+
+	healthSrv := health.NewServer()
+	healthSrv.SetServingStatus(subscriptionsv1.SubscriptionService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthSrv)
+
+	reflection.Register(srv)
+
+	return &Server{grpc: srv, health: healthSrv}
+}
+
+// Serve accepts connections on lis until GracefulStop is called.
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// GracefulStop reports every service as not serving, then waits for the
+// pending RPCs to finish.
+func (s *Server) GracefulStop() {
+	s.health.Shutdown()
+	s.grpc.GracefulStop()
+}
+
+// Stop closes all connections and cancels the pending RPCs.
+func (s *Server) Stop() {
+	s.grpc.Stop()
+}
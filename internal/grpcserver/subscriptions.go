@@ -0,0 +1,340 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	subscriptionsv1 "github.com/Lirohop/App/api/subscriptions/v1"
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type subscriptionServer struct {
+	subscriptionsv1.UnimplementedSubscriptionServiceServer
+
+	subs *service.SubscriptionService
+}
+
+func (s *subscriptionServer) CreateSubscription(
+	ctx context.Context,
+	req *subscriptionsv1.CreateSubscriptionRequest,
+) (*subscriptionsv1.CreateSubscriptionResponse, error) {
+
+	sub, err := toModel(req.GetSubscription())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := s.subs.CreateSubscription(ctx, sub); err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &subscriptionsv1.CreateSubscriptionResponse{Subscription: toProto(sub)}, nil
+}
+
+func (s *subscriptionServer) GetSubscription(
+	ctx context.Context,
+	req *subscriptionsv1.GetSubscriptionRequest,
+) (*subscriptionsv1.GetSubscriptionResponse, error) {
+
+	id, err := utils.ParseUUIDFromString(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+
+	sub, err := s.subs.GetSubscriptionById(ctx, id, req.GetIncludeDeleted())
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &subscriptionsv1.GetSubscriptionResponse{Subscription: toProto(sub)}, nil
+}
+
+func (s *subscriptionServer) ListSubscriptions(
+	ctx context.Context,
+	req *subscriptionsv1.ListSubscriptionsRequest,
+) (*subscriptionsv1.ListSubscriptionsResponse, error) {
+
+	filter := model.SubscriptionFilter{
+		IncludeDeleted: req.GetIncludeDeleted(),
+		Tags:           req.GetTags(),
+	}
+
+	var err error
+	if filter.UserID, err = parseOptionalUUID(req.UserId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+	if filter.MemberID, err = parseOptionalUUID(req.MemberId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid member_id")
+	}
+	if filter.CategoryID, err = parseOptionalUUID(req.CategoryId); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid category_id")
+	}
+
+	subs, err := s.subs.List(ctx, filter)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	resp := &subscriptionsv1.ListSubscriptionsResponse{
+		Subscriptions: make([]*subscriptionsv1.Subscription, len(subs)),
+	}
+	for i, sub := range subs {
+		resp.Subscriptions[i] = toProto(sub)
+	}
+
+	return resp, nil
+}
+
+func (s *subscriptionServer) UpdateSubscription(
+	ctx context.Context,
+	req *subscriptionsv1.UpdateSubscriptionRequest,
+) (*subscriptionsv1.UpdateSubscriptionResponse, error) {
+
+	id, err := utils.ParseUUIDFromString(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+
+	sub, err := toModel(req.GetSubscription())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sub.ID = id
+
+	if req.Version != nil {
+		err = s.subs.UpdateSubscriptionIfVersion(ctx, sub, []int{int(req.GetVersion())})
+	} else {
+		err = s.subs.UpdateSubscription(ctx, sub)
+	}
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &subscriptionsv1.UpdateSubscriptionResponse{Subscription: toProto(sub)}, nil
+}
+
+func (s *subscriptionServer) DeleteSubscription(
+	ctx context.Context,
+	req *subscriptionsv1.DeleteSubscriptionRequest,
+) (*subscriptionsv1.DeleteSubscriptionResponse, error) {
+
+	id, err := utils.ParseUUIDFromString(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid id")
+	}
+
+	if req.Version != nil {
+		err = s.subs.DeleteSubscriptionIfVersion(ctx, id, []int{int(req.GetVersion())})
+	} else {
+		err = s.subs.DeleteSubscription(ctx, id)
+	}
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &subscriptionsv1.DeleteSubscriptionResponse{}, nil
+}
+
+func (s *subscriptionServer) GetTotalCost(
+	ctx context.Context,
+	req *subscriptionsv1.GetTotalCostRequest,
+) (*subscriptionsv1.GetTotalCostResponse, error) {
+
+	userID, err := utils.ParseUUIDFromString(req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+
+	start, err := utils.ParseMonthYear(req.GetStartMonth())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid start_month")
+	}
+
+	end, err := utils.ParseMonthYear(req.GetEndMonth())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid end_month")
+	}
+
+	total, err := s.subs.CalculateSubscriptionsTotalCost(ctx, userID, req.GetServiceName(), start, end)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &subscriptionsv1.GetTotalCostResponse{Total: int64(total)}, nil
+}
+
+// serviceError returns the gRPC status for an error returned by the
+// services. Errors of unknown kind are internal errors.
+func serviceError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	code := codes.Internal
+
+	switch apperr.KindOf(err) {
+	case apperr.Invalid:
+		code = codes.InvalidArgument
+	case apperr.NotFound:
+		code = codes.NotFound
+	case apperr.Forbidden:
+		code = codes.PermissionDenied
+	case apperr.VersionConflict, apperr.Aborted:
+		code = codes.Aborted
+	case apperr.Conflict:
+		code = codes.FailedPrecondition
+	case apperr.Exists:
+		code = codes.AlreadyExists
+	}
+
+	if code == codes.Internal {
+		return status.Error(code, "internal error")
+	}
+	return status.Error(code, err.Error())
+}
+
+func toModel(in *subscriptionsv1.SubscriptionInput) (*model.Subscription, error) {
+	if in == nil {
+		return nil, errors.New("subscription is required")
+	}
+
+	userID, err := utils.ParseUUIDFromString(in.GetUserId())
+	if err != nil {
+		return nil, errors.New("invalid user_id")
+	}
+
+	startDate, err := utils.ParseMonthYear(in.GetStartMonth())
+	if err != nil {
+		return nil, errors.New("invalid start_month")
+	}
+
+	var endDate *time.Time
+	if in.EndMonth != nil {
+		t, err := utils.ParseMonthYear(in.GetEndMonth())
+		if err != nil {
+			return nil, errors.New("invalid end_month")
+		}
+		endDate = &t
+	}
+
+	var trialEnd *time.Time
+	if in.TrialEnd != nil {
+		t, err := time.Parse(time.DateOnly, in.GetTrialEnd())
+		if err != nil {
+			return nil, errors.New("invalid trial_end")
+		}
+		trialEnd = &t
+	}
+
+	var serviceID uuid.UUID
+	if in.ServiceId != nil {
+		if serviceID, err = utils.ParseUUIDFromString(in.GetServiceId()); err != nil {
+			return nil, errors.New("invalid service_id")
+		}
+	}
+
+	categoryID, err := parseOptionalUUID(in.CategoryId)
+	if err != nil {
+		return nil, errors.New("invalid category_id")
+	}
+
+	return &model.Subscription{
+		ServiceID:   serviceID,
+		ServiceName: in.GetServiceName(),
+		Price:       int(in.GetPrice()),
+		UserId:      userID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		TrialEnd:    trialEnd,
+		TrialPrice:  int(in.GetTrialPrice()),
+		CategoryID:  categoryID,
+		Tags:        in.GetTags(),
+	}, nil
+}
+
+func toProto(s *model.Subscription) *subscriptionsv1.Subscription {
+	out := &subscriptionsv1.Subscription{
+		Id:          s.ID.String(),
+		ServiceId:   s.ServiceID.String(),
+		ServiceName: s.ServiceName,
+		Price:       int64(s.Price),
+		UserId:      s.UserId.String(),
+		StartMonth:  utils.ParseMonthYearToString(s.StartDate),
+		TrialPrice:  int64(s.TrialPrice),
+		Tags:        s.Tags,
+		Status:      s.Status(time.Now().UTC()),
+		Version:     int64(s.Version),
+	}
+
+	if s.EndDate != nil {
+		end := utils.ParseMonthYearToString(*s.EndDate)
+		out.EndMonth = &end
+	}
+
+	if s.TrialEnd != nil {
+		trialEnd := s.TrialEnd.Format(time.DateOnly)
+		out.TrialEnd = &trialEnd
+	}
+
+	for _, p := range s.Pauses {
+		pause := &subscriptionsv1.Pause{Id: p.ID.String(), StartMonth: utils.ParseMonthYearToString(p.StartDate)}
+		if p.ResumeDate != nil {
+			resume := utils.ParseMonthYearToString(*p.ResumeDate)
+			pause.ResumeMonth = &resume
+		}
+		out.Pauses = append(out.Pauses, pause)
+	}
+
+	for _, m := range s.Members {
+		out.Members = append(out.Members, &subscriptionsv1.Member{
+			UserId: m.UserID.String(),
+			Weight: optionalInt64(m.Weight),
+			Amount: optionalInt64(m.Amount),
+		})
+	}
+
+	if s.CategoryID != nil {
+		id := s.CategoryID.String()
+		out.CategoryId = &id
+	}
+
+	if s.DeletedAt != nil {
+		out.DeletedAt = timestamppb.New(*s.DeletedAt)
+	}
+
+	for _, id := range s.Overlaps {
+		out.Warnings = append(out.Warnings, "overlaps subscription "+id.String())
+	}
+
+	return out
+}
+
+func parseOptionalUUID(s *string) (*uuid.UUID, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+
+	id, err := utils.ParseUUIDFromString(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func optionalInt64(n *int) *int64 {
+	if n == nil {
+		return nil
+	}
+
+	v := int64(*n)
+	return &v
+}
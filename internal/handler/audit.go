@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type AuditHandler struct {
+	service *service.AuditService
+	logger  *slog.Logger
+}
+
+func NewAuditHandler(
+	service *service.AuditService,
+	logger *slog.Logger,
+) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// History of subscription changes
+// @Summary Get change history of a subscription
+// @Tags audit
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Success 200 {array} model.AuditEntry
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/history [get]
+func (h *AuditHandler) History(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.service.History(ctx, id)
+	if err != nil {
+		http.Error(w, "failed to get history", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeEntries(w, entries)
+}
+
+// List audit entries
+// @Summary Search the audit log
+// @Tags audit
+// @Produce json
+// @Param subscription_id query string false "Subscription ID" format(uuid)
+// @Param actor query string false "Actor"
+// @Param action query string false "Action" Enums(create, update, delete, restore, purge)
+// @Param from query string false "From time, inclusive (RFC 3339)"
+// @Param to query string false "To time, exclusive (RFC 3339)"
+// @Param limit query int false "Maximum number of entries" default(100)
+// @Success 200 {array} model.AuditEntry
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /audit [get]
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	filter := model.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+	}
+
+	if idStr := query.Get("subscription_id"); idStr != "" {
+		id, err := utils.ParseUUIDFromString(idStr)
+		if err != nil {
+			http.Error(w, "invalid subscription_id", http.StatusBadRequest)
+			return
+		}
+		filter.SubscriptionID = &id
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			http.Error(w, "invalid from", http.StatusBadRequest)
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			http.Error(w, "invalid to", http.StatusBadRequest)
+			return
+		}
+		filter.To = &to
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := h.service.List(ctx, filter)
+	if err != nil {
+		http.Error(w, "failed to list audit entries", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeEntries(w, entries)
+}
+
+func (h *AuditHandler) writeEntries(w http.ResponseWriter, entries []*model.AuditEntry) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		h.logger.Error("failed to encode audit entries", "error", err)
+	}
+}
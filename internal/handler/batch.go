@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// maxBatchBytes bounds the size of a batch request.
+const maxBatchBytes = 10 << 20
+
+type BatchRequest struct {
+	Atomic     bool                    `json:"atomic"` // roll back every operation if one fails
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// BatchOperationRequest is one operation of a batch. Subscription is a
+// CreateSubscriptionRequest for a create and a PatchSubscriptionRequest
+// for an update.
+type BatchOperationRequest struct {
+	Op           string          `json:"op" enums:"create,update,delete"`
+	ID           string          `json:"id,omitempty"`      // update and delete
+	Version      *int            `json:"version,omitempty"` // optional, like If-Match
+	Subscription json.RawMessage `json:"subscription,omitempty" swaggertype:"object"`
+}
+
+type BatchResponse struct {
+	Applied bool                     `json:"applied"`
+	Results []BatchOperationResponse `json:"results"`
+}
+
+// BatchOperationResponse is the result of the operation at Index, with the
+// status code the single request would have returned.
+type BatchOperationResponse struct {
+	Index        int              `json:"index"`
+	Op           string           `json:"op"`
+	Status       int              `json:"status"`
+	ID           string           `json:"id,omitempty"`
+	Subscription *SubscriptionDTO `json:"subscription,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// Batch subscriptions
+// @Summary Create, update and delete subscriptions in one request
+// @Description All operations run in one transaction. A create takes the fields of POST /subscriptions, an update the fields to change like PATCH /subscriptions/{id}. With atomic set nothing is applied if any operation fails, and the others report status 424; otherwise the failed operations are skipped.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body BatchRequest true "Operations"
+// @Success 200 {object} BatchResponse "Applied"
+// @Failure 400 {string} string "Bad request"
+// @Failure 413 {string} string "Request too large"
+// @Failure 422 {object} BatchResponse "Atomic batch with failed operations"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions:batch [post]
+func (h *SubscriptionHandler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	ops := make([]model.BatchOperation, len(req.Operations))
+	for i, opReq := range req.Operations {
+		op, err := opReq.toModel()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("operation %d: %s", i, err), http.StatusBadRequest)
+			return
+		}
+		ops[i] = op
+	}
+
+	results, applied, err := h.service.Batch(r.Context(), ops, req.Atomic)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := BatchResponse{Applied: applied, Results: make([]BatchOperationResponse, len(results))}
+	for i, result := range results {
+		resp.Results[i] = toBatchOperationResponse(i, result)
+	}
+
+	status := http.StatusOK
+	if !applied {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode batch response", "error", err)
+	}
+}
+
+func (req BatchOperationRequest) toModel() (model.BatchOperation, error) {
+	op := model.BatchOperation{Op: req.Op}
+
+	if req.Op == model.BatchUpdate || req.Op == model.BatchDelete {
+		id, err := utils.ParseUUIDFromString(req.ID)
+		if err != nil {
+			return op, errors.New("invalid id")
+		}
+		op.ID = id
+
+		if req.Version != nil {
+			op.Versions = []int{*req.Version}
+		}
+	}
+
+	switch req.Op {
+	case model.BatchCreate:
+		var create CreateSubscriptionRequest
+		if err := json.Unmarshal(req.Subscription, &create); err != nil {
+			return op, errors.New("invalid subscription")
+		}
+		sub, err := create.toModel()
+		if err != nil {
+			return op, err
+		}
+		op.Subscription = sub
+	case model.BatchUpdate:
+		var patch PatchSubscriptionRequest
+		if err := json.Unmarshal(req.Subscription, &patch); err != nil {
+			return op, errors.New("invalid subscription")
+		}
+		op.Patch = patch.apply
+	case model.BatchDelete:
+	default:
+		return op, fmt.Errorf("unknown op %q, want create, update or delete", req.Op)
+	}
+
+	return op, nil
+}
+
+func toBatchOperationResponse(index int, result model.BatchOperationResult) BatchOperationResponse {
+	resp := BatchOperationResponse{Index: index, Op: result.Op}
+
+	if result.ID != uuid.Nil {
+		resp.ID = result.ID.String()
+	}
+
+	if result.Err != nil {
+		resp.Status = serviceErrorStatus(result.Err)
+		resp.Error = serviceErrorText(result.Err, resp.Status)
+		return resp
+	}
+
+	switch result.Op {
+	case model.BatchCreate:
+		resp.Status = http.StatusCreated
+	case model.BatchUpdate:
+		resp.Status = http.StatusOK
+	default:
+		resp.Status = http.StatusNoContent
+	}
+
+	if result.Subscription != nil {
+		dto := toSubscriptionDTO(result.Subscription)
+		resp.Subscription = &dto
+	}
+
+	return resp
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type BudgetHandler struct {
+	service *service.BudgetService
+	logger  *slog.Logger
+}
+
+type BudgetRequest struct {
+	UserID     string  `json:"user_id"`
+	Scope      string  `json:"scope"` // user (default), category or service
+	CategoryID *string `json:"category_id"`
+	ServiceID  *string `json:"service_id"`
+	Amount     int     `json:"amount"`
+	Period     string  `json:"period"` // month (default), quarter or year
+}
+
+type BudgetStatusDTO struct {
+	Budget      *model.Budget `json:"budget"`
+	PeriodStart string        `json:"period_start"` // "07-2025"
+	PeriodEnd   string        `json:"period_end"`
+	Actual      int           `json:"actual"`
+	Projected   int           `json:"projected"`
+	Percent     int           `json:"percent"`
+}
+
+func NewBudgetHandler(
+	service *service.BudgetService,
+	logger *slog.Logger,
+) *BudgetHandler {
+	return &BudgetHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create budget
+// @Summary Create budget
+// @Description Limits what the subscriptions of a user, or only those of a category or service, cost per calendar month, quarter or year. Alerts are sent when the projected spend of a period reaches the configured thresholds.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param budget body BudgetRequest true "Budget data"
+// @Success 201 {object} model.Budget
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Category or service not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets [post]
+func (h *BudgetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req BudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	budget := &model.Budget{}
+	if err := req.apply(budget); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CreateBudget(r.Context(), budget); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/budgets/"+budget.ID.String())
+	h.writeJSON(w, http.StatusCreated, budget)
+}
+
+// List budgets
+// @Summary List budgets
+// @Tags budgets
+// @Produce json
+// @Param userId query string false "Only budgets of this user" format(uuid)
+// @Success 200 {array} model.Budget
+// @Failure 400 {string} string "Bad request"
+// @Router /budgets [get]
+func (h *BudgetHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseOptionalUUID(r.URL.Query().Get("userId"))
+	if err != nil {
+		http.Error(w, "invalid userId", http.StatusBadRequest)
+		return
+	}
+
+	budgets, err := h.service.ListBudgets(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "failed to list budgets", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, budgets)
+}
+
+// Get budget
+// @Summary Get budget
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID" format(uuid)
+// @Success 200 {object} model.Budget
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id} [get]
+func (h *BudgetHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	budget, err := h.service.GetBudget(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, budget)
+}
+
+// Budget status
+// @Summary Spend of the current budget period
+// @Description Actual counts the charges up to and including the current month, projected those of the whole period.
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID" format(uuid)
+// @Success 200 {object} BudgetStatusDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id}/status [get]
+func (h *BudgetHandler) Status(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.service.BudgetStatus(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, BudgetStatusDTO{
+		Budget:      status.Budget,
+		PeriodStart: utils.ParseMonthYearToString(status.PeriodStart),
+		PeriodEnd:   utils.ParseMonthYearToString(status.PeriodEnd),
+		Actual:      status.Actual,
+		Projected:   status.Projected,
+		Percent:     status.Percent,
+	})
+}
+
+// Update budget
+// @Summary Update budget
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID" format(uuid)
+// @Param budget body BudgetRequest true "Budget data"
+// @Success 200 {object} model.Budget
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id} [put]
+func (h *BudgetHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req BudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	budget := &model.Budget{ID: id}
+	if err := req.apply(budget); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateBudget(r.Context(), budget); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, budget)
+}
+
+// Delete budget
+// @Summary Delete budget
+// @Tags budgets
+// @Param id path string true "Budget ID" format(uuid)
+// @Success 204
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /budgets/{id} [delete]
+func (h *BudgetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteBudget(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (req BudgetRequest) apply(b *model.Budget) error {
+	userID, err := utils.ParseUUIDFromString(req.UserID)
+	if err != nil {
+		return errors.New("invalid user_id")
+	}
+	b.UserID = userID
+
+	b.Scope = req.Scope
+	b.Amount = req.Amount
+	b.Period = req.Period
+
+	b.CategoryID, err = parseOptionalUUID(derefString(req.CategoryID))
+	if err != nil {
+		return errors.New("invalid category_id")
+	}
+
+	b.ServiceID, err = parseOptionalUUID(derefString(req.ServiceID))
+	if err != nil {
+		return errors.New("invalid service_id")
+	}
+
+	return nil
+}
+
+func (h *BudgetHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Lirohop/App/internal/ical"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+const calendarProdID = "-//Lirohop//Subscriptions//EN"
+
+type CalendarHandler struct {
+	service *service.CalendarService
+	logger  *slog.Logger
+}
+
+type CalendarTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"` // path of the feed, including the token
+}
+
+func NewCalendarHandler(
+	service *service.CalendarService,
+	logger *slog.Logger,
+) *CalendarHandler {
+	return &CalendarHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create calendar token
+// @Summary Create a secret token for the renewal feed
+// @Description Returns a new token; a previous token of the user stops working. The token is shown only once. Only the user, sent as X-Actor, can create it.
+// @Tags calendar
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param X-Actor header string true "ID of the user"
+// @Success 201 {object} CalendarTokenResponse
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not the user"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/calendar-token [post]
+func (h *CalendarHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.service.CreateToken(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := CalendarTokenResponse{
+		Token: token,
+		URL:   "/users/" + userID.String() + "/renewals.ics?token=" + url.QueryEscape(token),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// Revoke calendar token
+// @Summary Revoke the token of the renewal feed
+// @Description Only the user, sent as X-Actor, can revoke it.
+// @Tags calendar
+// @Param id path string true "User ID" format(uuid)
+// @Param X-Actor header string true "ID of the user"
+// @Success 204
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not the user"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/calendar-token [delete]
+func (h *CalendarHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeToken(r.Context(), userID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Renewal feed
+// @Summary iCalendar feed of upcoming charges
+// @Description An all-day event for every charge from the current month on, following billing periods, trials, pauses, scheduled price changes and end months. UIDs are built from the subscription id and the date, so they stay the same between fetches.
+// @Tags calendar
+// @Produce text/calendar
+// @Param id path string true "User ID" format(uuid)
+// @Param token query string true "Secret token of the feed"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Token is not valid"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/renewals.ics [get]
+func (h *CalendarHandler) Renewals(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	charges, err := h.service.Renewals(r.Context(), userID, r.URL.Query().Get("token"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	calendar := ical.Calendar{
+		ProdID: calendarProdID,
+		Name:   "Subscription renewals",
+		Events: make([]ical.Event, len(charges)),
+	}
+	for i, c := range charges {
+		calendar.Events[i] = renewalEvent(c)
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("Cache-Control", "private, no-store")
+	if err := calendar.Write(w, time.Now()); err != nil {
+		h.logger.Error("failed to write calendar", "error", err)
+	}
+}
+
+func renewalEvent(c model.ChargeEvent) ical.Event {
+	summary := c.ServiceName + " renewal"
+	if !c.Renewal {
+		summary = c.ServiceName + " first charge"
+	}
+
+	return ical.Event{
+		UID:         fmt.Sprintf("%s-%s@subscriptions", c.SubscriptionID, c.Date.Format("20060102")),
+		Date:        c.Date,
+		Summary:     summary,
+		Description: fmt.Sprintf("%s is charged %d (billed %s).", c.ServiceName, c.Amount, c.BillingPeriod),
+	}
+}
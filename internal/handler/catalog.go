@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type CatalogHandler struct {
+	service *service.CatalogService
+	logger  *slog.Logger
+}
+
+type ServiceRequest struct {
+	Name          string   `json:"name"`
+	Aliases       []string `json:"aliases"`
+	CategoryID    *string  `json:"category_id"`
+	DefaultPrice  *int     `json:"default_price"`
+	Currency      *string  `json:"currency"`       // ISO 4217, e.g. "RUB"
+	BillingPeriod string   `json:"billing_period"` // monthly (default), quarterly or yearly
+	Website       *string  `json:"website"`
+}
+
+func NewCatalogHandler(
+	service *service.CatalogService,
+	logger *slog.Logger,
+) *CatalogHandler {
+	return &CatalogHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create service
+// @Summary Add service to the catalog
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body ServiceRequest true "Service data"
+// @Success 201 {object} model.Service
+// @Failure 400 {string} string "Bad request"
+// @Failure 409 {string} string "Name or alias is used by another service"
+// @Failure 500 {string} string "Internal server error"
+// @Router /services [post]
+func (h *CatalogHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req ServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	svc := &model.Service{}
+	if err := req.apply(svc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CreateService(r.Context(), svc); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/services/"+svc.ID.String())
+	h.writeJSON(w, http.StatusCreated, svc)
+}
+
+// List services
+// @Summary Get the service catalog
+// @Tags services
+// @Produce json
+// @Param category_id query string false "Only services of this category" format(uuid)
+// @Success 200 {array} model.Service
+// @Failure 500 {string} string "Internal server error"
+// @Router /services [get]
+func (h *CatalogHandler) List(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := parseOptionalUUID(r.URL.Query().Get("category_id"))
+	if err != nil {
+		http.Error(w, "invalid category_id", http.StatusBadRequest)
+		return
+	}
+
+	services, err := h.service.ListServices(r.Context(), categoryID)
+	if err != nil {
+		http.Error(w, "failed to list services", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, services)
+}
+
+// Search services
+// @Summary Find services by part of their name or an alias
+// @Tags services
+// @Produce json
+// @Param q query string true "Search text"
+// @Param limit query int false "Maximum number of results" default(20)
+// @Success 200 {array} model.Service
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /services/search [get]
+func (h *CatalogHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	services, err := h.service.SearchServices(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, services)
+}
+
+// Get service
+// @Summary Get catalog service
+// @Tags services
+// @Produce json
+// @Param id path string true "Service ID" format(uuid)
+// @Success 200 {object} model.Service
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /services/{id} [get]
+func (h *CatalogHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	svc, err := h.service.GetService(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, svc)
+}
+
+// Update service
+// @Summary Replace catalog service
+// @Description Subscriptions of the service take its new name.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "Service ID" format(uuid)
+// @Param service body ServiceRequest true "Service data"
+// @Success 200 {object} model.Service
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Name or alias is used by another service"
+// @Failure 500 {string} string "Internal server error"
+// @Router /services/{id} [put]
+func (h *CatalogHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req ServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	svc := &model.Service{ID: id}
+	if err := req.apply(svc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateService(r.Context(), svc); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, svc)
+}
+
+// Delete service
+// @Summary Delete catalog service
+// @Tags services
+// @Param id path string true "Service ID" format(uuid)
+// @Success 204 "Deleted"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Service is referenced by subscriptions"
+// @Failure 500 {string} string "Internal server error"
+// @Router /services/{id} [delete]
+func (h *CatalogHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteService(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (req ServiceRequest) apply(svc *model.Service) error {
+	svc.Name = req.Name
+	svc.Aliases = req.Aliases
+	if svc.Aliases == nil {
+		svc.Aliases = []string{}
+	}
+	svc.DefaultPrice = req.DefaultPrice
+	svc.Currency = req.Currency
+	svc.BillingPeriod = req.BillingPeriod
+	svc.Website = req.Website
+
+	svc.CategoryID = nil
+	if req.CategoryID != nil {
+		categoryID, err := utils.ParseUUIDFromString(*req.CategoryID)
+		if err != nil {
+			return errors.New("invalid category_id")
+		}
+		svc.CategoryID = &categoryID
+	}
+
+	return nil
+}
+
+func (h *CatalogHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type CategoryHandler struct {
+	service *service.CategoryService
+	logger  *slog.Logger
+}
+
+type CategoryRequest struct {
+	Name string `json:"name"`
+}
+
+func NewCategoryHandler(
+	service *service.CategoryService,
+	logger *slog.Logger,
+) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create category
+// @Summary Create category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body CategoryRequest true "Category data"
+// @Success 201 {object} model.Category
+// @Failure 400 {string} string "Bad request"
+// @Failure 409 {string} string "Name is already used"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories [post]
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	category := &model.Category{Name: req.Name}
+
+	if err := h.service.CreateCategory(r.Context(), category); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/categories/"+category.ID.String())
+	h.writeJSON(w, http.StatusCreated, category)
+}
+
+// List categories
+// @Summary Get all categories
+// @Tags categories
+// @Produce json
+// @Success 200 {array} model.Category
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories [get]
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.ListCategories(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list categories", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, categories)
+}
+
+// Get category
+// @Summary Get category
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID" format(uuid)
+// @Success 200 {object} model.Category
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories/{id} [get]
+func (h *CategoryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.service.GetCategory(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, category)
+}
+
+// Rename category
+// @Summary Rename category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID" format(uuid)
+// @Param category body CategoryRequest true "Category data"
+// @Success 200 {object} model.Category
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Name is already used"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories/{id} [put]
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	category := &model.Category{ID: id, Name: req.Name}
+
+	if err := h.service.UpdateCategory(r.Context(), category); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, category)
+}
+
+// Delete category
+// @Summary Delete category
+// @Description Its services and subscriptions become uncategorized.
+// @Tags categories
+// @Param id path string true "Category ID" format(uuid)
+// @Success 204 "Deleted"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /categories/{id} [delete]
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteCategory(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
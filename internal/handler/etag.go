@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag renders a subscription version as a strong entity tag.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch returns the versions listed in the If-Match header. ok is false
+// when the header is absent or "*", i.e. the write is unconditional.
+// Tags that are weak or not versions are ignored, so they never match.
+func ifMatch(r *http.Request) (versions []int, ok bool) {
+	tags := entityTags(r, "If-Match")
+	if len(tags) == 0 {
+		return nil, false
+	}
+
+	versions = make([]int, 0, len(tags))
+	for _, tag := range tags {
+		if tag == "*" {
+			return nil, false
+		}
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if version, err := parseETag(tag); err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, true
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches version,
+// using the weak comparison RFC 9110 requires for GET.
+func ifNoneMatch(r *http.Request, version int) bool {
+	for _, tag := range entityTags(r, "If-None-Match") {
+		if tag == "*" {
+			return true
+		}
+		if v, err := parseETag(strings.TrimPrefix(tag, "W/")); err == nil && v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func entityTags(r *http.Request, header string) []string {
+	var tags []string
+	for _, value := range r.Header.Values(header) {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func parseETag(tag string) (int, error) {
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(unquoted)
+}
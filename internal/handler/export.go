@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Lirohop/App/internal/export"
+)
+
+// Export subscriptions
+// @Summary Export subscriptions
+// @Description Takes the filters of the subscription list. The format is given by format, or else negotiated from the Accept header, CSV by default. Subscriptions are streamed as they are read.
+// @Tags subscriptions
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/vnd.apache.parquet
+// @Param format query string false "File format, overrides Accept" Enums(csv, ndjson, xlsx, parquet)
+// @Param X-Actor header string false "ID of an admin, to read the trash"
+// @Param include_deleted query bool false "Also export subscriptions from the trash; admins only"
+// @Param category_id query string false "Only subscriptions of this category" format(uuid)
+// @Param tag query []string false "Only subscriptions with all of these tags" collectionFormat(multi)
+// @Success 200 {file} file
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not an admin"
+// @Failure 406 {string} string "No acceptable format"
+// @Router /subscriptions/export [get]
+func (h *SubscriptionHandler) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := parseSubscriptionFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The filter is checked before the file headers are set, as the export
+	// itself can only fail by cutting the file short.
+	if err := h.service.CheckFilter(r.Context(), filter); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		var ok bool
+		if format, ok = export.Negotiate(r.Header.Get("Accept")); !ok {
+			http.Error(w, "no acceptable format, use csv, ndjson, xlsx or parquet", http.StatusNotAcceptable)
+			return
+		}
+	}
+
+	if export.ContentType(format) == "" {
+		http.Error(w, "invalid format", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.`+format+`"`)
+
+	writer, err := export.New(format, w)
+	if err != nil {
+		h.logger.Error("failed to create export writer", "error", err, "format", format)
+		http.Error(w, "failed to export subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	// The writer is closed even when the export fails, to release what it
+	// holds, such as the temporary file of an xlsx workbook.
+	defer func() {
+		if err := writer.Close(); err != nil {
+			h.logger.Error("failed to complete export", "error", err, "format", format)
+		}
+	}()
+
+	// Once rows are written the status is sent, so a failure can only cut
+	// the file short.
+	if err := h.service.ExportSubscriptions(r.Context(), filter, writer.Write); err != nil {
+		h.logger.Error("export aborted", "error", err, "format", format)
+	}
+}
@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type GroupHandler struct {
+	service *service.GroupService
+	logger  *slog.Logger
+}
+
+type CreateGroupRequest struct {
+	Name string `json:"name"`
+}
+
+type GroupMemberRequest struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role" enums:"owner,admin,member"` // member by default
+}
+
+type GroupRoleRequest struct {
+	Role string `json:"role" enums:"owner,admin,member"`
+}
+
+func NewGroupHandler(
+	service *service.GroupService,
+	logger *slog.Logger,
+) *GroupHandler {
+	return &GroupHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create group
+// @Summary Create group
+// @Description Groups, such as a household or a team, combine the spending of their members. The actor, sent as X-Actor, becomes the owner.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param X-Actor header string true "ID of the owner"
+// @Param group body CreateGroupRequest true "Group data"
+// @Success 201 {object} model.UserGroup
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Actor is not a user ID"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups [post]
+func (h *GroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	group := &model.UserGroup{Name: req.Name}
+	if err := h.service.CreateGroup(r.Context(), group); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/groups/"+group.ID.String())
+	h.writeJSON(w, http.StatusCreated, group)
+}
+
+// Get group
+// @Summary Get group with its members
+// @Description Any member, sent as X-Actor, can get the group.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID" format(uuid)
+// @Param X-Actor header string true "ID of a member"
+// @Success 200 {object} model.UserGroup
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not a member"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups/{id} [get]
+func (h *GroupHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.GetGroup(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, group)
+}
+
+// List groups of a user
+// @Summary List the groups a user is a member of
+// @Description Only the user, sent as X-Actor, can list the groups.
+// @Tags groups
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param X-Actor header string true "ID of the user"
+// @Success 200 {array} model.UserGroup
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not the user"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/groups [get]
+func (h *GroupHandler) ListByUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.service.ListUserGroups(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, groups)
+}
+
+// Delete group
+// @Summary Delete group
+// @Description Only owners, sent as X-Actor, can delete the group. Subscriptions of its members are kept.
+// @Tags groups
+// @Param id path string true "Group ID" format(uuid)
+// @Param X-Actor header string true "ID of an owner"
+// @Success 204
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not an owner"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups/{id} [delete]
+func (h *GroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteGroup(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Add group member
+// @Summary Add a user to a group
+// @Description Owners can add users with any role, admins only with the member role.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID" format(uuid)
+// @Param X-Actor header string true "ID of an owner or admin"
+// @Param member body GroupMemberRequest true "Member"
+// @Success 200 {object} model.UserGroup
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Role does not allow this"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Already a member"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups/{id}/members [post]
+func (h *GroupHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req GroupMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := utils.ParseUUIDFromString(req.UserID)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.AddMember(r.Context(), id, model.GroupMember{UserID: userID, Role: req.Role})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, group)
+}
+
+// Update group member
+// @Summary Change the role of a group member
+// @Description Only owners can change roles. The last owner cannot give up the role.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "Group ID" format(uuid)
+// @Param userId path string true "Member user ID" format(uuid)
+// @Param X-Actor header string true "ID of an owner"
+// @Param role body GroupRoleRequest true "Role"
+// @Success 200 {object} model.UserGroup
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not an owner"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Last owner"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups/{id}/members/{userId} [put]
+func (h *GroupHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := utils.ParseUUIDFromString(r.PathValue("userId"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	var req GroupRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.UpdateMemberRole(r.Context(), id, userID, req.Role)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, group)
+}
+
+// Remove group member
+// @Summary Remove a user from a group
+// @Description Owners can remove anyone, admins only members, and everyone can leave. The last owner cannot leave.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID" format(uuid)
+// @Param userId path string true "Member user ID" format(uuid)
+// @Param X-Actor header string true "ID of an owner, an admin or the member"
+// @Success 200 {object} model.UserGroup
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Role does not allow this"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Last owner"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups/{id}/members/{userId} [delete]
+func (h *GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := utils.ParseUUIDFromString(r.PathValue("userId"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.service.RemoveMember(r.Context(), id, userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, group)
+}
+
+// List group subscriptions
+// @Summary List the subscriptions of the members of a group
+// @Description Subscriptions the members own or are members of, each once. Only owners and admins can list them.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID" format(uuid)
+// @Param X-Actor header string true "ID of an owner or admin"
+// @Success 200 {array} SubscriptionDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Role does not allow this"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups/{id}/subscriptions [get]
+func (h *GroupHandler) Subscriptions(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	subs, err := h.service.ListSubscriptions(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := make([]SubscriptionDTO, len(subs))
+	for i, s := range subs {
+		resp[i] = toSubscriptionDTO(s)
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// Group total cost
+// @Summary Calculate the total cost of a group
+// @Description Sums the total cost of every member, calculated like /subscriptions/total-cost, so that a subscription shared by several members counts once. Any member can get the total; the cost of each member is only returned to owners and admins.
+// @Tags groups
+// @Produce json
+// @Param id path string true "Group ID" format(uuid)
+// @Param X-Actor header string true "ID of a member"
+// @Param serviceName query string false "Service name"
+// @Param start query string true "Start month (MM-YYYY)" example(01-2025)
+// @Param end query string true "End month (MM-YYYY)" example(12-2025)
+// @Success 200 {object} model.GroupCost
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not a member"
+// @Failure 404 {string} string "Group or service not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /groups/{id}/total-cost [get]
+func (h *GroupHandler) TotalCost(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	start, err := utils.ParseMonthYear(query.Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start date", http.StatusBadRequest)
+		return
+	}
+
+	end, err := utils.ParseMonthYear(query.Get("end"))
+	if err != nil {
+		http.Error(w, "invalid end date", http.StatusBadRequest)
+		return
+	}
+
+	cost, err := h.service.TotalCost(r.Context(), id, query.Get("serviceName"), start, end)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, cost)
+}
+
+func (h *GroupHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -2,13 +2,21 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Lirohop/App/internal/apperr"
 	"github.com/Lirohop/App/internal/model"
 	"github.com/Lirohop/App/internal/service"
 	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
 )
 
 type SubscriptionHandler struct {
@@ -17,20 +25,67 @@ type SubscriptionHandler struct {
 }
 
 type CreateSubscriptionRequest struct {
-	ServiceName string  `json:"service_name"`
-	Price       int     `json:"price"`
-	UserID      string  `json:"user_id"`
-	StartMonth  string  `json:"start_month"`
-	EndMonth    *string `json:"end_month"`
+	ServiceID   *string  `json:"service_id"`   // optional, catalog service
+	ServiceName string   `json:"service_name"` // resolved through the catalog when service_id is empty
+	Price       int      `json:"price"`        // catalog default price when empty
+	UserID      string   `json:"user_id"`
+	StartMonth  string   `json:"start_month"`
+	EndMonth    *string  `json:"end_month"`
+	TrialEnd    *string  `json:"trial_end"`   // optional, "2025-07-15"
+	TrialPrice  int      `json:"trial_price"` // charged before trial_end
+	CategoryID  *string  `json:"category_id"` // category of the service when empty
+	Tags        []string `json:"tags"`
 }
 
 type SubscriptionDTO struct {
+	ID          string      `json:"id"`
+	ServiceID   string      `json:"service_id"`
+	ServiceName string      `json:"service_name"`
+	Price       int         `json:"price"`
+	UserID      string      `json:"user_id"`
+	StartMonth  string      `json:"start_month"`
+	EndMonth    *string     `json:"end_month,omitempty"`
+	TrialEnd    *string     `json:"trial_end,omitempty"`
+	TrialPrice  int         `json:"trial_price"`
+	Pauses      []PauseDTO  `json:"pauses,omitempty"`
+	Members     []MemberDTO `json:"members,omitempty"`
+	CategoryID  *string     `json:"category_id,omitempty"`
+	Tags        []string    `json:"tags"`
+	Status      string      `json:"status" enums:"scheduled,active,paused,ended"`
+	Version     int         `json:"version"`
+	DeletedAt   *string     `json:"deleted_at,omitempty"`
+	Warnings    []string    `json:"warnings,omitempty"` // overlapping subscriptions, in warn mode
+}
+
+type PauseDTO struct {
 	ID          string  `json:"id"`
-	ServiceName string  `json:"service_name"`
-	Price       int     `json:"price"`
-	UserID      string  `json:"user_id"`
 	StartMonth  string  `json:"start_month"`
-	EndMonth    *string `json:"end_month,omitempty"`
+	ResumeMonth *string `json:"resume_month,omitempty"`
+}
+
+type PauseRequest struct {
+	StartMonth  *string `json:"start_month"`  // optional, defaults to next month
+	ResumeMonth *string `json:"resume_month"` // optional, paused until resumed
+}
+
+type ResumeRequest struct {
+	ResumeMonth *string `json:"resume_month"` // optional, defaults to next month
+}
+
+// PatchSubscriptionRequest holds the fields to change; omitted fields keep
+// their value, an empty end_month or trial_end clears the date and an empty
+// category_id the category. Clearing trial_end also resets trial_price.
+type PatchSubscriptionRequest struct {
+	ServiceID   *string   `json:"service_id"`
+	ServiceName *string   `json:"service_name"`
+	Price       *int      `json:"price"`
+	UserID      *string   `json:"user_id"`
+	StartMonth  *string   `json:"start_month"`
+	EndMonth    *string   `json:"end_month"`
+	TrialEnd    *string   `json:"trial_end"`
+	TrialPrice  *int      `json:"trial_price"`
+	CategoryID  *string   `json:"category_id"`
+	Tags        *[]string `json:"tags"`
 }
 
 type TotalCostResponse struct {
@@ -49,13 +104,19 @@ func NewSubscriptionHandler(
 
 // Create subscription
 // @Summary Create subscription
-// @Description Create new subscription for user
+// @Description Create new subscription for user. Send "Prefer: return=minimal" to get only the Location header. A subscription sharing a month with another of the same user and service is rejected or returned with warnings, depending on the overlap mode.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body CreateSubscriptionRequest true "Subscription data"
-// @Success 201 "Created"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
+// @Param Prefer header string false "return=minimal to omit the body"
+// @Success 201 {object} SubscriptionDTO
+// @Header 201 {string} Location "/subscriptions/{id}"
 // @Failure 400 {string} string "Bad request"
+// @Failure 409 {string} string "Request with this key is in progress, or the subscription overlaps another (reject mode)"
+// @Failure 422 {string} string "Key was used with a different request"
+// @Failure 500 {string} string "Internal server error"
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -66,54 +127,45 @@ func (h *SubscriptionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, err := utils.ParseUUIDFromString(req.UserID)
+	sub, err := req.toModel()
 	if err != nil {
-		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	startDate, err := utils.ParseMonthYear(req.StartMonth)
-	if err != nil {
-		http.Error(w, "invalid start_month", http.StatusBadRequest)
+	if err := h.service.CreateSubscription(ctx, sub); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
-	var endDate *time.Time
-	if req.EndMonth != nil {
-		t, err := utils.ParseMonthYear(*req.EndMonth)
-		if err != nil {
-			http.Error(w, "invalid end_month", http.StatusBadRequest)
-			return
-		}
-		endDate = &t
-	}
+	w.Header().Set("Location", "/subscriptions/"+sub.ID.String())
+	w.Header().Set("ETag", etag(sub.Version))
 
-	sub := &model.Subscription{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserId:      userID,
-		StartDate:   startDate,
-		EndDate:     endDate,
-	}
-
-	if err := h.service.CreateSubscription(ctx, sub); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if preferReturnMinimal(r) {
+		w.Header().Set("Preference-Applied", "return=minimal")
+		w.WriteHeader(http.StatusCreated)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toSubscriptionDTO(sub)); err != nil {
+		h.logger.Error("failed to encode created subscription", "error", err, "id", sub.ID)
+	}
 }
 
 // Delete subscription
 // @Summary Delete subscription by ID
 // @Tags subscriptions
 // @Param id query string true "Subscription ID" format(uuid)
+// @Param If-Match header string false "ETag the deletion is based on"
 // @Success 201 "Deleted"
 // @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 412 {string} string "Subscription was changed since ETag"
+// @Failure 500 {string} string "Internal server error"
 // @Router /subscriptions/delete [delete]
 func (h *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
 	idStr := r.URL.Query().Get("id")
 	if idStr == "" {
 		http.Error(w, "missing id", http.StatusBadRequest)
@@ -126,8 +178,8 @@ func (h *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteSubscription(ctx, id); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := h.deleteSubscription(r, id); err != nil {
+		writeServiceError(w, err)
 		return
 	}
 
@@ -139,8 +191,16 @@ func (h *SubscriptionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 // @Tags subscriptions
 // @Produce json
 // @Param id query string true "Subscription ID" format(uuid)
+// @Param X-Actor header string false "ID of an admin, to read the trash"
+// @Param include_deleted query bool false "Also return a subscription from the trash; admins only"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} model.Subscription
+// @Header 200 {string} ETag "Subscription version"
+// @Success 304 "Not modified"
 // @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not an admin"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
 // @Router /subscriptions/get [get]
 func (h *SubscriptionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
@@ -158,10 +218,16 @@ func (h *SubscriptionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sub, err := h.service.GetSubscriptionById(ctx, id)
+	sub, err := h.service.GetSubscriptionById(ctx, id, r.URL.Query().Get("include_deleted") == "true")
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(sub.Version))
+	if ifNoneMatch(r, sub.Version) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -173,40 +239,378 @@ func (h *SubscriptionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// Get subscription
+// @Summary Get subscription
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param X-Actor header string false "ID of an admin, to read the trash"
+// @Param include_deleted query bool false "Also return a subscription from the trash; admins only"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Success 304 "Not modified"
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not an admin"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id} [get]
+func (h *SubscriptionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.GetSubscriptionById(ctx, id, r.URL.Query().Get("include_deleted") == "true")
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(sub.Version))
+	if ifNoneMatch(r, sub.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toSubscriptionDTO(sub)); err != nil {
+		http.Error(w, "failed to encode subscription", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Replace subscription
+// @Summary Replace subscription
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param subscription body CreateSubscriptionRequest true "Subscription data"
+// @Param If-Match header string false "ETag the change is based on"
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Subscription overlaps another (reject mode)"
+// @Failure 412 {string} string "Subscription was changed since ETag"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id} [put]
+func (h *SubscriptionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := req.toModel()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub.ID = id
+
+	if versions, ok := ifMatch(r); ok {
+		err = h.service.UpdateSubscriptionIfVersion(ctx, sub, versions)
+	} else {
+		err = h.service.UpdateSubscription(ctx, sub)
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSubscription(w, sub)
+}
+
+// Patch subscription
+// @Summary Update subscription fields
+// @Description Omitted fields are kept, an empty end_month or trial_end clears the date and an empty category_id the category. Clearing trial_end also resets trial_price.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param subscription body PatchSubscriptionRequest true "Fields to change"
+// @Param If-Match header string false "ETag the change is based on"
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Subscription overlaps another (reject mode)"
+// @Failure 412 {string} string "Subscription was changed since ETag"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req PatchSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.GetSubscriptionById(ctx, id, false)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	// Without If-Match the patch is still applied against the version
+	// it was computed from, so concurrent writes are never lost.
+	versions, ok := ifMatch(r)
+	if !ok {
+		versions = []int{sub.Version}
+	}
+
+	if err := req.apply(sub); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateSubscriptionIfVersion(ctx, sub, versions); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSubscription(w, sub)
+}
+
+// Delete subscription by path
+// @Summary Delete subscription
+// @Tags subscriptions
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param If-Match header string false "ETag the deletion is based on"
+// @Success 204 "Deleted"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 412 {string} string "Subscription was changed since ETag"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id} [delete]
+func (h *SubscriptionHandler) DeleteByPath(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.deleteSubscription(r, id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteSubscription deletes the subscription, only if it still has the
+// version of the If-Match header when one is sent.
+func (h *SubscriptionHandler) deleteSubscription(r *http.Request, id uuid.UUID) error {
+	if versions, ok := ifMatch(r); ok {
+		return h.service.DeleteSubscriptionIfVersion(r.Context(), id, versions)
+	}
+	return h.service.DeleteSubscription(r.Context(), id)
+}
+
+// Restore subscription
+// @Summary Restore subscription from the trash
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found in the trash"
+// @Failure 409 {string} string "Subscription overlaps another"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/restore [post]
+func (h *SubscriptionHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.RestoreSubscription(ctx, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSubscription(w, sub)
+}
+
+// Pause subscription
+// @Summary Pause billing of a subscription
+// @Description Months from start_month up to, but not including, resume_month are not charged. The body may be omitted.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param pause body PauseRequest false "Pause period"
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Pause overlaps another pause"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req PauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	start, err := parseOptionalMonth(req.StartMonth)
+	if err != nil {
+		http.Error(w, "invalid start_month", http.StatusBadRequest)
+		return
+	}
+
+	resume, err := parseOptionalMonth(req.ResumeMonth)
+	if err != nil {
+		http.Error(w, "invalid resume_month", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.PauseSubscription(ctx, id, start, resume)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSubscription(w, sub)
+}
+
+// Resume subscription
+// @Summary Resume billing of a paused subscription
+// @Description Ends the current or next pause. A pause starting at or after resume_month is removed. The body may be omitted.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param resume body ResumeRequest false "Resume month"
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Subscription is not paused"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req ResumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	resume, err := parseOptionalMonth(req.ResumeMonth)
+	if err != nil {
+		http.Error(w, "invalid resume_month", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.ResumeSubscription(ctx, id, resume)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSubscription(w, sub)
+}
+
 // List subscriptions
 // @Summary Get all subscriptions
 // @Tags subscriptions
 // @Produce json
-// @Success 200 {array} model.Subscription
+// @Param X-Actor header string false "ID of an admin, to read the trash"
+// @Param include_deleted query bool false "Also list subscriptions from the trash; admins only"
+// @Param category_id query string false "Only subscriptions of this category" format(uuid)
+// @Param tag query []string false "Only subscriptions with all of these tags" collectionFormat(multi)
+// @Success 200 {array} SubscriptionDTO
 // @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not an admin"
+// @Failure 500 {string} string "Internal server error"
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) List(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	filter, err := parseSubscriptionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.writeList(w, r, filter)
+}
+
+// List the trash
+// @Summary List deleted subscriptions
+// @Description Lists the subscriptions in the trash, which are purged after the retention. Only admins, sent as X-Actor, can list it.
+// @Tags subscriptions
+// @Produce json
+// @Param X-Actor header string true "ID of an admin"
+// @Param category_id query string false "Only subscriptions of this category" format(uuid)
+// @Param tag query []string false "Only subscriptions with all of these tags" collectionFormat(multi)
+// @Success 200 {array} SubscriptionDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not an admin"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/trash [get]
+func (h *SubscriptionHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseSubscriptionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.IncludeDeleted = false
+	filter.OnlyDeleted = true
+
+	h.writeList(w, r, filter)
+}
 
-	subs, err := h.service.List(ctx)
+func (h *SubscriptionHandler) writeList(w http.ResponseWriter, r *http.Request, filter model.SubscriptionFilter) {
+	subs, err := h.service.List(r.Context(), filter)
 	if err != nil {
-		http.Error(w, "invalid list", http.StatusBadRequest)
+		writeServiceError(w, err)
 		return
 	}
 
 	resp := make([]SubscriptionDTO, len(subs))
 	for i, s := range subs {
-		start := utils.ParseMonthYearToString(s.StartDate)
-
-		var end *string
-		if s.EndDate != nil {
-			e := utils.ParseMonthYearToString(*s.EndDate)
-			end = &e
-		}
-
-		resp[i] = SubscriptionDTO{
-			ID:          s.ID.String(),
-			ServiceName: s.ServiceName,
-			Price:       s.Price,
-			UserID:      s.UserId.String(),
-			StartMonth:  start,
-			EndMonth:    end,
-		}
+		resp[i] = toSubscriptionDTO(s)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -219,7 +623,7 @@ func (h *SubscriptionHandler) List(w http.ResponseWriter, r *http.Request) {
 
 // Calculate total subscriptions cost
 // @Summary Calculate total subscriptions cost
-// @Description Calculate total cost for subscriptions in period
+// @Description Calculate total cost for subscriptions in period. Subscriptions shared with the user count with the user's share.
 // @Tags subscriptions
 // @Produce json
 // @Param userId query string true "User ID" format(uuid)
@@ -228,6 +632,7 @@ func (h *SubscriptionHandler) List(w http.ResponseWriter, r *http.Request) {
 // @Param end query string true "End month (MM-YYYY)" example(12-2025)
 // @Success 200 {object} TotalCostResponse
 // @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Service not found"
 // @Failure 500 {string} string "Internal server error"
 // @Router /subscriptions/total-cost [get]
 func (h *SubscriptionHandler) TotalCost(w http.ResponseWriter, r *http.Request) {
@@ -267,7 +672,7 @@ func (h *SubscriptionHandler) TotalCost(w http.ResponseWriter, r *http.Request)
 
 	total, err := h.service.CalculateSubscriptionsTotalCost(ctx, userID, serviceName, startDate, endDate)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, err)
 		return
 	}
 
@@ -277,3 +682,355 @@ func (h *SubscriptionHandler) TotalCost(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+// Trials ending
+// @Summary Get subscriptions whose trial ends soon
+// @Tags subscriptions
+// @Produce json
+// @Param within query string false "Period from now, in days (7d) or as a duration (36h)" default(7d)
+// @Success 200 {array} SubscriptionDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/trials-ending [get]
+func (h *SubscriptionHandler) TrialsEnding(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	within := 7 * 24 * time.Hour
+	if s := r.URL.Query().Get("within"); s != "" {
+		d, err := parseWithin(s)
+		if err != nil {
+			http.Error(w, "invalid within", http.StatusBadRequest)
+			return
+		}
+		within = d
+	}
+
+	subs, err := h.service.TrialsEnding(ctx, within)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := make([]SubscriptionDTO, len(subs))
+	for i, s := range subs {
+		resp[i] = toSubscriptionDTO(s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode subscriptions", "error", err)
+	}
+}
+
+// parseSubscriptionFilter reads the filters of the subscription list.
+func parseSubscriptionFilter(query url.Values) (model.SubscriptionFilter, error) {
+	categoryID, err := parseOptionalUUID(query.Get("category_id"))
+	if err != nil {
+		return model.SubscriptionFilter{}, errors.New("invalid category_id")
+	}
+
+	return model.SubscriptionFilter{
+		IncludeDeleted: query.Get("include_deleted") == "true",
+		CategoryID:     categoryID,
+		Tags:           query["tag"],
+	}, nil
+}
+
+// parseOptionalUUID parses s unless it is empty.
+func parseOptionalUUID(s string) (*uuid.UUID, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	id, err := utils.ParseUUIDFromString(s)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func parseOptionalMonth(s *string) (*time.Time, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	t, err := utils.ParseMonthYear(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseWithin parses a number of days such as "7d", or a time.Duration.
+func parseWithin(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+func toSubscriptionDTO(s *model.Subscription) SubscriptionDTO {
+	var end *string
+	if s.EndDate != nil {
+		e := utils.ParseMonthYearToString(*s.EndDate)
+		end = &e
+	}
+
+	var trialEnd *string
+	if s.TrialEnd != nil {
+		t := s.TrialEnd.Format(time.DateOnly)
+		trialEnd = &t
+	}
+
+	var pauses []PauseDTO
+	for _, p := range s.Pauses {
+		dto := PauseDTO{ID: p.ID.String(), StartMonth: utils.ParseMonthYearToString(p.StartDate)}
+		if p.ResumeDate != nil {
+			resume := utils.ParseMonthYearToString(*p.ResumeDate)
+			dto.ResumeMonth = &resume
+		}
+		pauses = append(pauses, dto)
+	}
+
+	var categoryID *string
+	if s.CategoryID != nil {
+		id := s.CategoryID.String()
+		categoryID = &id
+	}
+
+	var deletedAt *string
+	if s.DeletedAt != nil {
+		d := s.DeletedAt.UTC().Format(time.RFC3339)
+		deletedAt = &d
+	}
+
+	var warnings []string
+	for _, id := range s.Overlaps {
+		warnings = append(warnings, "overlaps subscription "+id.String())
+	}
+
+	return SubscriptionDTO{
+		ID:          s.ID.String(),
+		ServiceID:   s.ServiceID.String(),
+		ServiceName: s.ServiceName,
+		Price:       s.Price,
+		UserID:      s.UserId.String(),
+		StartMonth:  utils.ParseMonthYearToString(s.StartDate),
+		EndMonth:    end,
+		TrialEnd:    trialEnd,
+		TrialPrice:  s.TrialPrice,
+		Pauses:      pauses,
+		Members:     toMemberDTOs(s.Members),
+		CategoryID:  categoryID,
+		Tags:        s.Tags,
+		Status:      s.Status(time.Now().UTC()),
+		Version:     s.Version,
+		DeletedAt:   deletedAt,
+		Warnings:    warnings,
+	}
+}
+
+// preferReturnMinimal reports whether the client asked for
+// "Prefer: return=minimal" (RFC 7240).
+func preferReturnMinimal(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, pref := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(pref), "return=minimal") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (req CreateSubscriptionRequest) toModel() (*model.Subscription, error) {
+	userID, err := utils.ParseUUIDFromString(req.UserID)
+	if err != nil {
+		return nil, errors.New("invalid user_id")
+	}
+
+	startDate, err := utils.ParseMonthYear(req.StartMonth)
+	if err != nil {
+		return nil, errors.New("invalid start_month")
+	}
+
+	var endDate *time.Time
+	if req.EndMonth != nil {
+		t, err := utils.ParseMonthYear(*req.EndMonth)
+		if err != nil {
+			return nil, errors.New("invalid end_month")
+		}
+		endDate = &t
+	}
+
+	var trialEnd *time.Time
+	if req.TrialEnd != nil {
+		t, err := time.Parse(time.DateOnly, *req.TrialEnd)
+		if err != nil {
+			return nil, errors.New("invalid trial_end")
+		}
+		trialEnd = &t
+	}
+
+	var serviceID uuid.UUID
+	if req.ServiceID != nil {
+		if serviceID, err = utils.ParseUUIDFromString(*req.ServiceID); err != nil {
+			return nil, errors.New("invalid service_id")
+		}
+	}
+
+	categoryID, err := parseOptionalUUID(derefString(req.CategoryID))
+	if err != nil {
+		return nil, errors.New("invalid category_id")
+	}
+
+	return &model.Subscription{
+		ServiceID:   serviceID,
+		ServiceName: req.ServiceName,
+		Price:       req.Price,
+		UserId:      userID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		TrialEnd:    trialEnd,
+		TrialPrice:  req.TrialPrice,
+		CategoryID:  categoryID,
+		Tags:        req.Tags,
+	}, nil
+}
+
+func (req PatchSubscriptionRequest) apply(sub *model.Subscription) error {
+	if req.ServiceName != nil {
+		sub.ServiceID = uuid.Nil
+		sub.ServiceName = *req.ServiceName
+	}
+
+	if req.ServiceID != nil {
+		serviceID, err := utils.ParseUUIDFromString(*req.ServiceID)
+		if err != nil {
+			return errors.New("invalid service_id")
+		}
+		sub.ServiceID = serviceID
+	}
+
+	if req.Price != nil {
+		sub.Price = *req.Price
+	}
+
+	if req.UserID != nil {
+		userID, err := utils.ParseUUIDFromString(*req.UserID)
+		if err != nil {
+			return errors.New("invalid user_id")
+		}
+		sub.UserId = userID
+	}
+
+	if req.StartMonth != nil {
+		startDate, err := utils.ParseMonthYear(*req.StartMonth)
+		if err != nil {
+			return errors.New("invalid start_month")
+		}
+		sub.StartDate = startDate
+	}
+
+	if req.EndMonth != nil {
+		if *req.EndMonth == "" {
+			sub.EndDate = nil
+		} else {
+			endDate, err := utils.ParseMonthYear(*req.EndMonth)
+			if err != nil {
+				return errors.New("invalid end_month")
+			}
+			sub.EndDate = &endDate
+		}
+	}
+
+	if req.TrialEnd != nil {
+		if *req.TrialEnd == "" {
+			// Without a trial there is nothing to charge the trial price for.
+			sub.TrialEnd = nil
+			sub.TrialPrice = 0
+		} else {
+			trialEnd, err := time.Parse(time.DateOnly, *req.TrialEnd)
+			if err != nil {
+				return errors.New("invalid trial_end")
+			}
+			sub.TrialEnd = &trialEnd
+		}
+	}
+
+	if req.TrialPrice != nil {
+		sub.TrialPrice = *req.TrialPrice
+	}
+
+	if req.CategoryID != nil {
+		categoryID, err := parseOptionalUUID(*req.CategoryID)
+		if err != nil {
+			return errors.New("invalid category_id")
+		}
+		sub.CategoryID = categoryID
+	}
+
+	if req.Tags != nil {
+		sub.Tags = *req.Tags
+	}
+
+	return nil
+}
+
+func (h *SubscriptionHandler) writeSubscription(w http.ResponseWriter, sub *model.Subscription) {
+	w.Header().Set("ETag", etag(sub.Version))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toSubscriptionDTO(sub)); err != nil {
+		h.logger.Error("failed to encode subscription", "error", err, "id", sub.ID)
+	}
+}
+
+// writeServiceError maps errors returned by the service to status codes.
+// Errors of unknown kind are not caused by the request, so their text is
+// not sent to the client.
+func writeServiceError(w http.ResponseWriter, err error) {
+	status := serviceErrorStatus(err)
+	http.Error(w, serviceErrorText(err, status), status)
+}
+
+// serviceErrorStatus returns the status code for an error returned by the
+// services.
+func serviceErrorStatus(err error) int {
+	switch apperr.KindOf(err) {
+	case apperr.Invalid:
+		return http.StatusBadRequest
+	case apperr.NotFound:
+		return http.StatusNotFound
+	case apperr.Forbidden:
+		return http.StatusForbidden
+	case apperr.VersionConflict:
+		return http.StatusPreconditionFailed
+	case apperr.Conflict, apperr.Exists:
+		return http.StatusConflict
+	case apperr.Aborted:
+		return http.StatusFailedDependency
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// serviceErrorText returns the text sent to the client for an error with the
+// given status.
+func serviceErrorText(err error, status int) string {
+	if status == http.StatusInternalServerError {
+		return http.StatusText(status)
+	}
+	return err.Error()
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/requestctx"
+	"github.com/Lirohop/App/internal/service"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+type IdempotencyHandler struct {
+	service *service.IdempotencyService
+	logger  *slog.Logger
+}
+
+func NewIdempotencyHandler(
+	service *service.IdempotencyService,
+	logger *slog.Logger,
+) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Wrap makes next safe to retry: a request carrying an Idempotency-Key
+// header is executed once and later requests of the same actor with the
+// same key get the stored response back.
+func (h *IdempotencyHandler) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "invalid Idempotency-Key", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key = scopedKey(requestctx.Actor(ctx), key)
+
+		rec, err := h.service.Begin(ctx, key, fingerprint(r, body))
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyMismatch):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, "failed to check Idempotency-Key", http.StatusInternalServerError)
+			return
+		}
+
+		if rec != nil {
+			for name, values := range rec.Headers {
+				w.Header()[name] = values
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(*rec.StatusCode)
+			w.Write(rec.Body)
+			return
+		}
+
+		// The key must be completed or released even if the client has
+		// gone away, or it stays in progress until it expires.
+		ctx = context.WithoutCancel(ctx)
+
+		defer func() {
+			if p := recover(); p != nil {
+				h.release(ctx, key)
+				panic(p)
+			}
+		}()
+
+		rw := &recordingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next(rw, r)
+
+		// Server errors are not stored so the client can retry them.
+		if rw.status >= http.StatusInternalServerError {
+			h.release(ctx, key)
+			return
+		}
+
+		if err := h.service.Complete(ctx, key, rw.status, w.Header().Clone(), rw.body.Bytes()); err != nil {
+			h.logger.Error("failed to store idempotent response", "error", err, "key", key)
+		}
+	}
+}
+
+func (h *IdempotencyHandler) release(ctx context.Context, key string) {
+	if err := h.service.Release(ctx, key); err != nil {
+		h.logger.Error("failed to release idempotency key", "error", err, "key", key)
+	}
+}
+
+// scopedKey is the key stored for an Idempotency-Key sent by actor, so that
+// callers neither replay nor block each other's requests.
+func scopedKey(actor, key string) string {
+	return fmt.Sprintf("%d:%s:%s", len(actor), actor, key)
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
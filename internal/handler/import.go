@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+)
+
+// maxImportBytes bounds the size of an uploaded CSV file.
+const maxImportBytes = 10 << 20
+
+type ImportHandler struct {
+	service *service.ImportService
+	logger  *slog.Logger
+}
+
+func NewImportHandler(
+	service *service.ImportService,
+	logger *slog.Logger,
+) *ImportHandler {
+	return &ImportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Import subscriptions
+// @Summary Import subscriptions from CSV
+// @Description The first row names the columns: service_id, service_name, price, user_id, start_month, end_month, trial_end, trial_price, category_id and tags (separated by ";"), or other names given by mapping. Every row is validated like a created subscription. In atomic mode nothing is imported if any row is invalid; in skip mode invalid rows are skipped. A dry run only validates.
+// @Tags subscriptions
+// @Accept text/csv
+// @Produce json
+// @Param file body string true "CSV file"
+// @Param mapping query string false "Columns of fields, as field=column pairs" example(price=Cost,user_id=Owner)
+// @Param mode query string false "What to do with invalid rows" Enums(atomic, skip) default(atomic)
+// @Param dry_run query bool false "Validate without importing" default(false)
+// @Success 200 {object} model.ImportResult "Dry run"
+// @Success 201 {object} model.ImportResult "Imported"
+// @Failure 400 {string} string "Bad request"
+// @Failure 413 {string} string "File too large"
+// @Failure 422 {object} model.ImportResult "Atomic import with invalid rows"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/import [post]
+func (h *ImportHandler) Import(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	mapping, err := service.ParseImportMapping(query.Get("mapping"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dryRun := false
+	if v := query.Get("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	result, err := h.service.ImportCSV(r.Context(), body, model.ImportOptions{
+		Mapping: mapping,
+		Mode:    query.Get("mode"),
+		DryRun:  dryRun,
+	})
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		writeServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	switch {
+	case result.Imported > 0:
+		status = http.StatusCreated
+	case !dryRun && result.Valid < result.Rows:
+		status = http.StatusUnprocessableEntity
+	}
+
+	h.writeJSON(w, status, result)
+}
+
+func (h *ImportHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
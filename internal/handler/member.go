@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type MemberDTO struct {
+	UserID string `json:"user_id"`
+	Weight *int   `json:"weight,omitempty"`
+	Amount *int   `json:"amount,omitempty"`
+}
+
+type AddMemberRequest struct {
+	UserID string `json:"user_id"`
+	Weight *int   `json:"weight"` // share of what is left after fixed amounts; the owner has 1 unless listed
+	Amount *int   `json:"amount"` // fixed monthly amount out of the full price
+}
+
+// Add member
+// @Summary Share a subscription with a user
+// @Description Exactly one of weight and amount must be given. Only the owner of the subscription, sent as X-Actor, can add members. The owner may be listed with a weight to pay more or less than one share.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param X-Actor header string true "ID of the owner"
+// @Param member body AddMemberRequest true "Member"
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not the owner"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Already a member"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/members [post]
+func (h *SubscriptionHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req AddMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := utils.ParseUUIDFromString(req.UserID)
+	if err != nil {
+		http.Error(w, "invalid user_id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.AddMember(r.Context(), id, model.Member{UserID: userID, Weight: req.Weight, Amount: req.Amount})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSubscription(w, sub)
+}
+
+// Remove member
+// @Summary Stop sharing a subscription with a user
+// @Description The owner of the subscription can remove any member and members can remove themselves, either sent as X-Actor.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param userId path string true "Member user ID" format(uuid)
+// @Param X-Actor header string true "ID of the owner or the member"
+// @Success 200 {object} SubscriptionDTO
+// @Header 200 {string} ETag "Subscription version"
+// @Failure 400 {string} string "Bad request"
+// @Failure 403 {string} string "Not the owner or the member"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/members/{userId} [delete]
+func (h *SubscriptionHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := utils.ParseUUIDFromString(r.PathValue("userId"))
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.service.RemoveMember(r.Context(), id, userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSubscription(w, sub)
+}
+
+func toMemberDTOs(members []model.Member) []MemberDTO {
+	var dtos []MemberDTO
+	for _, m := range members {
+		dtos = append(dtos, MemberDTO{UserID: m.UserID.String(), Weight: m.Weight, Amount: m.Amount})
+	}
+	return dtos
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type NotificationHandler struct {
+	service *service.NotificationService
+	logger  *slog.Logger
+}
+
+type NotificationSettingsRequest struct {
+	Email           *string `json:"email"`
+	RenewalLeadDays *int    `json:"renewal_lead_days"` // default from config when empty
+	EndLeadDays     *int    `json:"end_lead_days"`     // default from config when empty
+}
+
+func NewNotificationHandler(
+	service *service.NotificationService,
+	logger *slog.Logger,
+) *NotificationHandler {
+	return &NotificationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Get notification settings
+// @Summary Get reminder settings of a user
+// @Tags notifications
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {object} model.NotificationSettings
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/notification-settings [get]
+func (h *NotificationHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.service.GetSettings(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "failed to get notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeSettings(w, settings)
+}
+
+// Save notification settings
+// @Summary Replace reminder settings of a user
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param settings body NotificationSettingsRequest true "Settings"
+// @Success 200 {object} model.NotificationSettings
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/notification-settings [put]
+func (h *NotificationHandler) SaveSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req NotificationSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	settings := &model.NotificationSettings{
+		UserID:          userID,
+		Email:           req.Email,
+		RenewalLeadDays: req.RenewalLeadDays,
+		EndLeadDays:     req.EndLeadDays,
+	}
+
+	if err := h.service.SaveSettings(r.Context(), settings); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeSettings(w, settings)
+}
+
+func (h *NotificationHandler) writeSettings(w http.ResponseWriter, settings *model.NotificationSettings) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(settings); err != nil {
+		h.logger.Error("failed to encode notification settings", "error", err)
+	}
+}
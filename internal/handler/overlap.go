@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type OverlapDTO struct {
+	ServiceID       string    `json:"service_id"`
+	ServiceName     string    `json:"service_name"`
+	SubscriptionIDs [2]string `json:"subscription_ids"`
+	StartMonth      string    `json:"start_month"`         // first shared month
+	EndMonth        *string   `json:"end_month,omitempty"` // last shared month, open-ended if empty
+}
+
+// List overlaps
+// @Summary List overlapping subscriptions of a user
+// @Description Every pair of live subscriptions to the same service that share a month, with the months they share. Pairs are reported whatever the overlap mode was when they were written.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Success 200 {array} OverlapDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/overlaps [get]
+func (h *SubscriptionHandler) Overlaps(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	overlaps, err := h.service.ListOverlaps(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := make([]OverlapDTO, len(overlaps))
+	for i, o := range overlaps {
+		resp[i] = toOverlapDTO(o)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("failed to encode overlaps", "error", err)
+	}
+}
+
+func toOverlapDTO(o *model.Overlap) OverlapDTO {
+	dto := OverlapDTO{
+		ServiceID:       o.ServiceID.String(),
+		ServiceName:     o.ServiceName,
+		SubscriptionIDs: [2]string{o.SubscriptionIDs[0].String(), o.SubscriptionIDs[1].String()},
+		StartMonth:      utils.ParseMonthYearToString(o.StartDate),
+	}
+	if o.EndDate != nil {
+		end := utils.ParseMonthYearToString(*o.EndDate)
+		dto.EndMonth = &end
+	}
+	return dto
+}
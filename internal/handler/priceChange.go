@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type PriceChangeHandler struct {
+	service *service.PriceChangeService
+	logger  *slog.Logger
+}
+
+type PriceChangeRequest struct {
+	EffectiveMonth string `json:"effective_month"` // "08-2025"
+	Price          int    `json:"price"`
+}
+
+type PriceChangeDTO struct {
+	ID             string     `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EffectiveMonth string     `json:"effective_month"`
+	Price          int        `json:"price"`
+	CreatedAt      time.Time  `json:"created_at"`
+	AppliedAt      *time.Time `json:"applied_at,omitempty"`
+	// The price replaced by the change, once applied.
+	PreviousPrice *int `json:"previous_price,omitempty"`
+}
+
+func NewPriceChangeHandler(
+	service *service.PriceChangeService,
+	logger *slog.Logger,
+) *PriceChangeHandler {
+	return &PriceChangeHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Schedule price change
+// @Summary Schedule a price change
+// @Description The subscription is charged price from effective_month on. The change is applied to the subscription when that month starts.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param change body PriceChangeRequest true "Price change"
+// @Success 201 {object} PriceChangeDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "A price change is already scheduled for the month"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/price-changes [post]
+func (h *PriceChangeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req PriceChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	effective, err := utils.ParseMonthYear(req.EffectiveMonth)
+	if err != nil {
+		http.Error(w, "invalid effective_month", http.StatusBadRequest)
+		return
+	}
+
+	change := &model.ScheduledPriceChange{
+		SubscriptionID: id,
+		EffectiveDate:  effective,
+		Price:          req.Price,
+	}
+
+	if err := h.service.SchedulePriceChange(r.Context(), change); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/subscriptions/"+id.String()+"/price-changes/"+change.ID.String())
+	h.writeJSON(w, http.StatusCreated, toPriceChangeDTO(change))
+}
+
+// List price changes
+// @Summary List price changes of a subscription
+// @Description Applied and pending changes, by effective month.
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID" format(uuid)
+// @Success 200 {array} PriceChangeDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/price-changes [get]
+func (h *PriceChangeHandler) List(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.service.ListPriceChanges(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := make([]PriceChangeDTO, len(changes))
+	for i, c := range changes {
+		resp[i] = toPriceChangeDTO(c)
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// Cancel price change
+// @Summary Cancel a pending price change
+// @Tags subscriptions
+// @Param id path string true "Subscription ID" format(uuid)
+// @Param changeId path string true "Price change ID" format(uuid)
+// @Success 204
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found or already applied"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscriptions/{id}/price-changes/{changeId} [delete]
+func (h *PriceChangeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	changeID, err := utils.ParseUUIDFromString(r.PathValue("changeId"))
+	if err != nil {
+		http.Error(w, "invalid changeId", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CancelPriceChange(r.Context(), id, changeID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toPriceChangeDTO(c *model.ScheduledPriceChange) PriceChangeDTO {
+	return PriceChangeDTO{
+		ID:             c.ID.String(),
+		SubscriptionID: c.SubscriptionID.String(),
+		EffectiveMonth: utils.ParseMonthYearToString(c.EffectiveDate),
+		Price:          c.Price,
+		CreatedAt:      c.CreatedAt,
+		AppliedAt:      c.AppliedAt,
+		PreviousPrice:  c.PreviousPrice,
+	}
+}
+
+func (h *PriceChangeHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type SpendBucketDTO struct {
+	Period        string `json:"period"` // first month of the bucket, "01-2025"
+	Total         int    `json:"total"`
+	Subscriptions int    `json:"subscriptions"`
+	New           int    `json:"new"`
+	Ended         int    `json:"ended"`
+}
+
+type ChargeEventDTO struct {
+	SubscriptionID string `json:"subscription_id"`
+	ServiceName    string `json:"service_name"`
+	Date           string `json:"date"` // "2025-08-01"
+	Amount         int    `json:"amount"`
+	BillingPeriod  string `json:"billing_period"`
+	Renewal        bool   `json:"renewal"`
+	OverThreshold  bool   `json:"over_threshold"`
+}
+
+type ForecastMonthDTO struct {
+	Month   string `json:"month"` // "08-2025"
+	Total   int    `json:"total"`
+	Charges int    `json:"charges"`
+}
+
+type ForecastDTO struct {
+	Total   int                `json:"total"`
+	Months  []ForecastMonthDTO `json:"months"`
+	Charges []ChargeEventDTO   `json:"charges"`
+}
+
+type ReportHandler struct {
+	service *service.ReportService
+	logger  *slog.Logger
+}
+
+func NewReportHandler(
+	service *service.ReportService,
+	logger *slog.Logger,
+) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Cost by category
+// @Summary Cost of subscriptions by category
+// @Description Costs are calculated like total-cost, including trials and pauses, for the months from start through end.
+// @Tags reports
+// @Produce json
+// @Param userId query string false "Only the share of this user of the subscriptions the user owns or is a member of" format(uuid)
+// @Param start query string true "Start month (MM-YYYY)" example(01-2025)
+// @Param end query string true "End month (MM-YYYY)" example(12-2025)
+// @Success 200 {array} model.CategoryCost
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /reports/cost-by-category [get]
+func (h *ReportHandler) CostByCategory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := parseOptionalUUID(query.Get("userId"))
+	if err != nil {
+		http.Error(w, "invalid userId", http.StatusBadRequest)
+		return
+	}
+
+	start, err := utils.ParseMonthYear(query.Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start date", http.StatusBadRequest)
+		return
+	}
+
+	end, err := utils.ParseMonthYear(query.Get("end"))
+	if err != nil {
+		http.Error(w, "invalid end date", http.StatusBadRequest)
+		return
+	}
+
+	costs, err := h.service.CostByCategory(r.Context(), userID, start, end)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, costs)
+}
+
+// Spend over time
+// @Summary Spending per month, quarter or year
+// @Description Each bucket holds the charges of its months in the window, the number of charged subscriptions, and the number of subscriptions whose first or last billed month is in it.
+// @Tags reports
+// @Produce json
+// @Param userId query string false "Only the share of this user of the subscriptions the user owns or is a member of" format(uuid)
+// @Param start query string true "Start month (MM-YYYY)" example(01-2025)
+// @Param end query string true "End month (MM-YYYY)" example(12-2025)
+// @Param granularity query string false "Bucket size" Enums(month, quarter, year) default(month)
+// @Success 200 {array} SpendBucketDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /reports/spend [get]
+func (h *ReportHandler) Spend(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := parseOptionalUUID(query.Get("userId"))
+	if err != nil {
+		http.Error(w, "invalid userId", http.StatusBadRequest)
+		return
+	}
+
+	start, err := utils.ParseMonthYear(query.Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start date", http.StatusBadRequest)
+		return
+	}
+
+	end, err := utils.ParseMonthYear(query.Get("end"))
+	if err != nil {
+		http.Error(w, "invalid end date", http.StatusBadRequest)
+		return
+	}
+
+	buckets, err := h.service.Spend(r.Context(), model.SpendFilter{
+		UserID:      userID,
+		Start:       start,
+		End:         end,
+		Granularity: query.Get("granularity"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := make([]SpendBucketDTO, len(buckets))
+	for i, b := range buckets {
+		resp[i] = SpendBucketDTO{
+			Period:        utils.ParseMonthYearToString(b.Period),
+			Total:         b.Total,
+			Subscriptions: b.Subscriptions,
+			New:           b.New,
+			Ended:         b.Ended,
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// Forecast
+// @Summary Forecast upcoming charges of a user
+// @Description Projects the charges from next month on. Quarterly and yearly services are charged for their whole billing period at its start; scheduled price changes, trials, pauses and end months are taken into account. Shared subscriptions count with the user's share. Renewals costing more than threshold are flagged, by default above the configured threshold.
+// @Tags reports
+// @Produce json
+// @Param userId query string true "User ID" format(uuid)
+// @Param months query int false "Number of months" default(12) minimum(1) maximum(60)
+// @Param threshold query int false "Flag renewals costing more; 0 flags none"
+// @Success 200 {object} ForecastDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /reports/forecast [get]
+func (h *ReportHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userID, err := utils.ParseUUIDFromString(query.Get("userId"))
+	if err != nil {
+		http.Error(w, "invalid userId", http.StatusBadRequest)
+		return
+	}
+
+	months := 12
+	if v := query.Get("months"); v != "" {
+		months, err = strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid months", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var threshold *int
+	if v := query.Get("threshold"); v != "" {
+		t, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid threshold", http.StatusBadRequest)
+			return
+		}
+		threshold = &t
+	}
+
+	forecast, err := h.service.Forecast(r.Context(), userID, months, threshold)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	resp := ForecastDTO{
+		Total:   forecast.Total,
+		Months:  make([]ForecastMonthDTO, len(forecast.Months)),
+		Charges: make([]ChargeEventDTO, len(forecast.Charges)),
+	}
+	for i, m := range forecast.Months {
+		resp.Months[i] = ForecastMonthDTO{
+			Month:   utils.ParseMonthYearToString(m.Month),
+			Total:   m.Total,
+			Charges: m.Charges,
+		}
+	}
+	for i, c := range forecast.Charges {
+		resp.Charges[i] = ChargeEventDTO{
+			SubscriptionID: c.SubscriptionID.String(),
+			ServiceName:    c.ServiceName,
+			Date:           c.Date.Format(time.DateOnly),
+			Amount:         c.Amount,
+			BillingPeriod:  c.BillingPeriod,
+			Renewal:        c.Renewal,
+			OverThreshold:  c.OverThreshold,
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *ReportHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/statement"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+// maxStatementBytes bounds the size of an uploaded bank statement.
+const maxStatementBytes = 10 << 20
+
+type StatementHandler struct {
+	service *service.StatementService
+	logger  *slog.Logger
+}
+
+// AcceptCandidateRequest overrides what the subscription of an accepted
+// candidate is created with; omitted fields keep the candidate's values.
+type AcceptCandidateRequest struct {
+	ServiceName *string `json:"service_name,omitempty"`
+	Price       *int    `json:"price,omitempty"`
+	StartMonth  *string `json:"start_month,omitempty"` // "07-2025"
+	CategoryID  *string `json:"category_id,omitempty"`
+}
+
+type AcceptCandidateResponse struct {
+	Candidate    *model.SubscriptionCandidate `json:"candidate"`
+	Subscription SubscriptionDTO              `json:"subscription"`
+}
+
+func NewStatementHandler(
+	service *service.StatementService,
+	logger *slog.Logger,
+) *StatementHandler {
+	return &StatementHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Import bank statement
+// @Summary Find subscriptions in a bank statement
+// @Description Reads an OFX/QFX, CAMT.053 or CSV statement and stores the debits charged every month, quarter or year by the same merchant as subscription candidates, matched against the service catalog. Candidates for services the user already subscribes to get the status existing. Statements are not stored; uploading overlapping statements updates the same candidates.
+// @Tags statements
+// @Accept octet-stream
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param file body string true "Bank statement"
+// @Param format query string false "Statement format, detected from the content if omitted" Enums(ofx, qfx, camt053, csv)
+// @Param mapping query string false "CSV columns of the fields date, amount, description and currency, as field=column pairs" example(description=Payee,amount=Debit)
+// @Success 201 {array} model.SubscriptionCandidate
+// @Failure 400 {string} string "Bad request"
+// @Failure 413 {string} string "File too large"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/statements [post]
+func (h *StatementHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	mapping, err := service.ParseImportMapping(query.Get("mapping"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxStatementBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "failed to read statement", http.StatusBadRequest)
+		return
+	}
+
+	candidates, err := h.service.ImportStatement(r.Context(), userID, data, query.Get("format"), statement.Options{
+		Mapping: mapping,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, candidates)
+}
+
+// List subscription candidates
+// @Summary List subscription candidates of a user
+// @Tags statements
+// @Produce json
+// @Param id path string true "User ID" format(uuid)
+// @Param status query string false "Only candidates with this status" Enums(pending, existing, accepted, rejected)
+// @Success 200 {array} model.SubscriptionCandidate
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /users/{id}/subscription-candidates [get]
+func (h *StatementHandler) ListCandidates(w http.ResponseWriter, r *http.Request) {
+	userID, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	candidates, err := h.service.ListCandidates(r.Context(), userID, r.URL.Query().Get("status"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, candidates)
+}
+
+// Accept subscription candidate
+// @Summary Create a subscription from a candidate
+// @Description The subscription starts in the month of the first charge found and is priced per month. The body is optional and overrides the candidate's values.
+// @Tags statements
+// @Accept json
+// @Produce json
+// @Param id path string true "Candidate ID" format(uuid)
+// @Param overrides body AcceptCandidateRequest false "Overrides"
+// @Success 201 {object} AcceptCandidateResponse
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Candidate is not pending"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscription-candidates/{id}/accept [post]
+func (h *StatementHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req AcceptCandidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	acceptance := model.CandidateAcceptance{
+		ServiceName: req.ServiceName,
+		Price:       req.Price,
+	}
+	if req.StartMonth != nil {
+		start, err := utils.ParseMonthYear(*req.StartMonth)
+		if err != nil {
+			http.Error(w, "invalid start_month", http.StatusBadRequest)
+			return
+		}
+		acceptance.StartDate = &start
+	}
+	if req.CategoryID != nil {
+		categoryID, err := utils.ParseUUIDFromString(*req.CategoryID)
+		if err != nil {
+			http.Error(w, "invalid category_id", http.StatusBadRequest)
+			return
+		}
+		acceptance.CategoryID = &categoryID
+	}
+
+	candidate, sub, err := h.service.AcceptCandidate(r.Context(), id, acceptance)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, AcceptCandidateResponse{
+		Candidate:    candidate,
+		Subscription: toSubscriptionDTO(sub),
+	})
+}
+
+// Reject subscription candidate
+// @Summary Reject a candidate
+// @Description A rejected candidate is kept so that importing the same charges again does not propose it again.
+// @Tags statements
+// @Produce json
+// @Param id path string true "Candidate ID" format(uuid)
+// @Success 200 {object} model.SubscriptionCandidate
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 409 {string} string "Candidate was already accepted or rejected"
+// @Failure 500 {string} string "Internal server error"
+// @Router /subscription-candidates/{id}/reject [post]
+func (h *StatementHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	candidate, err := h.service.RejectCandidate(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, candidate)
+}
+
+func (h *StatementHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
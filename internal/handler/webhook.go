@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/service"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+	logger  *slog.Logger
+}
+
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`      // generated when empty
+	EventTypes []string `json:"event_types"` // empty means all events
+	UserID     *string  `json:"user_id"`     // optional, only events of this user
+}
+
+type WebhookDTO struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	UserID     *string  `json:"user_id,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
+
+func NewWebhookHandler(
+	service *service.WebhookService,
+	logger *slog.Logger,
+) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Create webhook
+// @Summary Register webhook
+// @Description Payloads are signed in the X-Webhook-Signature header as "t=<unix>,v1=<hex HMAC-SHA256 of "<unix>.<body>">". The secret is returned only here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "Webhook data"
+// @Success 201 {object} WebhookDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	hook := &model.Webhook{}
+	if err := req.apply(hook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.CreateWebhook(ctx, hook); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	dto := toWebhookDTO(hook)
+	dto.Secret = hook.Secret
+
+	w.Header().Set("Location", "/webhooks/"+hook.ID.String())
+	h.writeJSON(w, http.StatusCreated, dto)
+}
+
+// List webhooks
+// @Summary Get all webhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} WebhookDTO
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks [get]
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		http.Error(w, "failed to list webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]WebhookDTO, len(hooks))
+	for i, hook := range hooks {
+		resp[i] = toWebhookDTO(hook)
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// Get webhook
+// @Summary Get webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Success 200 {object} WebhookDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.service.GetWebhook(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toWebhookDTO(hook))
+}
+
+// Update webhook
+// @Summary Replace webhook URL, secret and filter
+// @Description An empty secret keeps the current one.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Param webhook body WebhookRequest true "Webhook data"
+// @Success 200 {object} WebhookDTO
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json body", http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.service.GetWebhook(ctx, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if err := req.apply(hook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.UpdateWebhook(ctx, hook); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, toWebhookDTO(hook))
+}
+
+// Delete webhook
+// @Summary Delete webhook and its delivery log
+// @Tags webhooks
+// @Param id path string true "Webhook ID" format(uuid)
+// @Success 204 "Deleted"
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// List webhook deliveries
+// @Summary Get latest deliveries of a webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Param status query string false "Delivery status" Enums(pending, succeeded, dead)
+// @Success 200 {array} model.WebhookDelivery
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), id, r.URL.Query().Get("status"))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, deliveries)
+}
+
+// Redeliver webhook delivery
+// @Summary Queue a delivery to be sent again
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID" format(uuid)
+// @Param deliveryId path string true "Delivery ID" format(uuid)
+// @Success 202 {object} model.WebhookDelivery
+// @Failure 400 {string} string "Bad request"
+// @Failure 404 {string} string "Not found"
+// @Failure 500 {string} string "Internal server error"
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.ParseUUIDFromString(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	deliveryID, err := utils.ParseUUIDFromString(r.PathValue("deliveryId"))
+	if err != nil {
+		http.Error(w, "invalid deliveryId", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.service.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, delivery)
+}
+
+func (req WebhookRequest) apply(hook *model.Webhook) error {
+	hook.URL = req.URL
+	hook.EventTypes = req.EventTypes
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+
+	hook.UserID = nil
+	if req.UserID != nil {
+		userID, err := utils.ParseUUIDFromString(*req.UserID)
+		if err != nil {
+			return errors.New("invalid user_id")
+		}
+		hook.UserID = &userID
+	}
+
+	return nil
+}
+
+func toWebhookDTO(hook *model.Webhook) WebhookDTO {
+	var userID *string
+	if hook.UserID != nil {
+		id := hook.UserID.String()
+		userID = &id
+	}
+
+	return WebhookDTO{
+		ID:         hook.ID.String(),
+		URL:        hook.URL,
+		EventTypes: hook.EventTypes,
+		UserID:     userID,
+		CreatedAt:  hook.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  hook.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func (h *WebhookHandler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
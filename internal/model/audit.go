@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+	AuditActionPause   = "pause"
+	AuditActionResume  = "resume"
+)
+
+// AuditEntry records one change of a subscription. Before is empty for
+// creations and After is empty for purges.
+type AuditEntry struct {
+	ID             int64           `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	Action         string          `json:"action"`
+	Actor          string          `json:"actor"`
+	RequestID      string          `json:"request_id,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Before         json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After          json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+}
+
+// AuditFilter narrows down audit queries; zero fields match everything.
+type AuditFilter struct {
+	SubscriptionID *uuid.UUID
+	Actor          string
+	Action         string
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+var (
+	// ErrBatchAborted is the result of the valid operations of an atomic
+	// batch that was rolled back because another operation failed.
+	ErrBatchAborted = errors.New("not applied, another operation of the atomic batch failed")
+	// ErrBatchDuplicate is the result of an operation on a subscription
+	// that an earlier operation of the batch already changes.
+	ErrBatchDuplicate = errors.New("subscription is changed by another operation of the batch")
+)
+
+// BatchOperation is one write of a batch. A create stores Subscription; an
+// update applies Patch to the stored subscription ID; a delete removes it.
+// Updates and deletes with Versions only apply if the stored version is
+// one of them.
+type BatchOperation struct {
+	Op           string
+	ID           uuid.UUID
+	Subscription *Subscription
+	Patch        func(*Subscription) error
+	Versions     []int
+}
+
+// BatchOperationResult is the outcome of the operation at the same index.
+// Subscription is the created or updated subscription; Err is nil if the
+// operation was applied.
+type BatchOperationResult struct {
+	Op           string
+	ID           uuid.UUID
+	Subscription *Subscription
+	Err          error
+}
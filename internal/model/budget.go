@@ -0,0 +1,43 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BudgetScopeUser     = "user"
+	BudgetScopeCategory = "category"
+	BudgetScopeService  = "service"
+)
+
+var ErrBudgetNotFound = errors.New("budget not found")
+
+// Budget limits what the subscriptions of a user cost per calendar month,
+// quarter or year (Period is one of the Granularity values). A category or
+// service scope counts only the subscriptions of CategoryID or ServiceID.
+type Budget struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Scope      string     `json:"scope"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	ServiceID  *uuid.UUID `json:"service_id,omitempty"`
+	Amount     int        `json:"amount"`
+	Period     string     `json:"period"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// BudgetStatus compares a budget with the spend of its current period.
+// Actual counts the charges up to and including the current month,
+// Projected those of the whole period.
+type BudgetStatus struct {
+	Budget      *Budget   `json:"budget"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Actual      int       `json:"actual"`
+	Projected   int       `json:"projected"`
+	Percent     int       `json:"percent"` // Projected as a percentage of Amount
+}
@@ -0,0 +1,8 @@
+package model
+
+import "errors"
+
+var (
+	ErrCalendarTokenNotFound = errors.New("calendar token not found")
+	ErrCalendarTokenInvalid  = errors.New("calendar token is not valid")
+)
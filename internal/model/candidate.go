@@ -0,0 +1,55 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CandidatePending  = "pending"
+	CandidateExisting = "existing" // matches a subscription the user has
+	CandidateAccepted = "accepted"
+	CandidateRejected = "rejected"
+)
+
+var (
+	ErrCandidateNotFound = errors.New("subscription candidate not found")
+	ErrCandidateDecided  = errors.New("subscription candidate was already accepted, rejected or matched")
+)
+
+// SubscriptionCandidate is a recurring charge found in a bank statement.
+// Amount is the latest charge in minor units of Currency; Price is what it
+// comes to per month, in whole units, as subscriptions are priced.
+// SubscriptionID is the matching subscription of an existing candidate or
+// the one created by accepting it.
+type SubscriptionCandidate struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	MerchantKey    string     `json:"-"`
+	Merchant       string     `json:"merchant"`
+	ServiceID      *uuid.UUID `json:"service_id,omitempty"`
+	ServiceName    string     `json:"service_name"`
+	Price          int        `json:"price"`
+	Amount         int64      `json:"amount"`
+	Currency       string     `json:"currency"`
+	BillingPeriod  string     `json:"billing_period"`
+	FirstCharge    time.Time  `json:"first_charge"`
+	LastCharge     time.Time  `json:"last_charge"`
+	Occurrences    int        `json:"occurrences"`
+	Status         string     `json:"status"`
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+}
+
+// CandidateAcceptance overrides what an accepted candidate's subscription
+// is created with; nil fields keep the candidate's values.
+type CandidateAcceptance struct {
+	ServiceName *string
+	Price       *int
+	StartDate   *time.Time
+	CategoryID  *uuid.UUID
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+)
+
+var (
+	ErrServiceNotFound  = errors.New("service not found")
+	ErrServiceNameTaken = errors.New("service name or alias is already used by another service")
+	ErrServiceInUse     = errors.New("service is referenced by subscriptions")
+)
+
+// Service is a catalog entry. Subscriptions reference it, and their service
+// names resolve to it by its canonical Name or any of its Aliases, ignoring
+// case and extra whitespace.
+type Service struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Aliases       []string   `json:"aliases"`
+	CategoryID    *uuid.UUID `json:"category_id,omitempty"`
+	DefaultPrice  *int       `json:"default_price,omitempty"`
+	Currency      *string    `json:"currency,omitempty"`
+	BillingPeriod string     `json:"billing_period" enums:"monthly,quarterly,yearly"`
+	Website       *string    `json:"website,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
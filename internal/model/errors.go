@@ -0,0 +1,27 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound = errors.New("subscription not found")
+	// ErrTrashForbidden is returned when an actor that is not an admin
+	// asks for deleted subscriptions.
+	ErrTrashForbidden = errors.New("only admins can see deleted subscriptions")
+)
+
+// VersionConflictError is returned by conditional writes when the stored
+// version of the subscription is not one of the expected versions.
+type VersionConflictError struct {
+	ID       uuid.UUID
+	Expected []int
+	Actual   int
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("subscription %s has version %d, expected %v", e.ID, e.Actual, e.Expected)
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventPriceChanged        = "subscription.price_changed"
+	EventSubscriptionEnded   = "subscription.ended"
+	EventSubscriptionDeleted = "subscription.deleted"
+	EventTrialConverted      = "subscription.trial_converted"
+	EventServiceRenamed      = "subscription.service_renamed"
+)
+
+// Event is a domain event about a subscription. Payload depends on Type:
+// the Subscription for created and deleted events, PriceChange,
+// SubscriptionEnd, TrialConversion and ServiceRename for the others.
+type Event struct {
+	ID             uuid.UUID       `json:"id"`
+	Type           string          `json:"type"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	UserID         uuid.UUID       `json:"user_id"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+type PriceChange struct {
+	OldPrice int `json:"old_price"`
+	NewPrice int `json:"new_price"`
+}
+
+// ServiceRename is raised when the service name of a subscription changes,
+// including when its catalog service is renamed.
+type ServiceRename struct {
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+}
+
+type SubscriptionEnd struct {
+	EndDate time.Time `json:"end_date"`
+}
+
+// TrialConversion is raised when the trial of a subscription that is still
+// running ends and it starts being charged the full price.
+type TrialConversion struct {
+	TrialEnd   time.Time `json:"trial_end"`
+	TrialPrice int       `json:"trial_price"`
+	Price      int       `json:"price"`
+}
+
+// OutboxMessage is an Event waiting in the outbox to be published.
+type OutboxMessage struct {
+	Seq      int64
+	Event    Event
+	Attempts int
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPriceChangeNotFound = errors.New("scheduled price change not found")
+	ErrPriceChangeConflict = errors.New("a price change is already scheduled for this month")
+)
+
+// ScheduledPriceChange sets the price of a subscription from the month of
+// EffectiveDate on. It is applied to the subscription when that month
+// starts, recording the price it replaced as PreviousPrice.
+type ScheduledPriceChange struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	EffectiveDate  time.Time  `json:"effective_date"`
+	Price          int        `json:"price"`
+	CreatedAt      time.Time  `json:"created_at"`
+	AppliedAt      *time.Time `json:"applied_at,omitempty"`
+	PreviousPrice  *int       `json:"previous_price,omitempty"`
+}
+
+// PricePeriod is the price a subscription was charged for the months
+// before Until, when an applied price change replaced it.
+type PricePeriod struct {
+	Price int       `json:"price"`
+	Until time.Time `json:"until"`
+}
+
+// PriceAt returns the monthly price of the subscription in the given month,
+// before any trial price.
+func (s *Subscription) PriceAt(month time.Time) int {
+	for _, p := range s.PricePeriods {
+		if month.Before(p.Until) {
+			return p.Price
+		}
+	}
+	return s.Price
+}
+
+// ChargeEvent is a charge expected on Date. A charge of a quarterly or
+// yearly subscription covers the months of its billing period.
+type ChargeEvent struct {
+	SubscriptionID uuid.UUID
+	ServiceName    string
+	Date           time.Time
+	Amount         int
+	BillingPeriod  string
+	Renewal        bool
+	OverThreshold  bool
+}
+
+type ForecastMonth struct {
+	Month   time.Time
+	Total   int
+	Charges int
+}
+
+type Forecast struct {
+	Total   int
+	Months  []ForecastMonth
+	Charges []ChargeEvent
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group roles, from most to least privileged. Owners manage the group and
+// the roles of its members, admins add and remove members and see what
+// each member spends, members see only the combined totals.
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleAdmin  = "admin"
+	GroupRoleMember = "member"
+)
+
+var (
+	ErrGroupNotFound       = errors.New("group not found")
+	ErrGroupMemberNotFound = errors.New("group member not found")
+	ErrGroupMemberExists   = errors.New("user is already a member of the group")
+	ErrGroupForbidden      = errors.New("group role does not allow this")
+	ErrLastGroupOwner      = errors.New("group must keep an owner")
+)
+
+// UserGroup combines the spending of its members, such as a household or
+// a team.
+type UserGroup struct {
+	ID        uuid.UUID     `json:"id"`
+	Name      string        `json:"name"`
+	Members   []GroupMember `json:"members"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type GroupMember struct {
+	UserID uuid.UUID `json:"user_id"`
+	Role   string    `json:"role"`
+}
+
+// Role returns the role of the user in the group, or "" if the user is
+// not a member.
+func (g *UserGroup) Role(userID uuid.UUID) string {
+	for _, m := range g.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// Owners counts the owners of the group.
+func (g *UserGroup) Owners() int {
+	n := 0
+	for _, m := range g.Members {
+		if m.Role == GroupRoleOwner {
+			n++
+		}
+	}
+	return n
+}
+
+// GroupCost is what the subscriptions of the members of a group cost in a
+// period. Members holds the share of each member, in group order, and is
+// left out for callers with the member role.
+type GroupCost struct {
+	Total   int          `json:"total"`
+	Members []MemberCost `json:"members,omitempty"`
+}
+
+type MemberCost struct {
+	UserID uuid.UUID `json:"user_id"`
+	Total  int       `json:"total"`
+}
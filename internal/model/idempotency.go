@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// IdempotencyRecord is a stored response for an Idempotency-Key.
+// StatusCode is nil while the original request is still in progress.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string
+	StatusCode  *int
+	Headers     map[string][]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,36 @@
+package model
+
+import "github.com/google/uuid"
+
+const (
+	// ImportAtomic imports no row if any row is invalid.
+	ImportAtomic = "atomic"
+	// ImportSkipInvalid imports the valid rows and skips the others.
+	ImportSkipInvalid = "skip"
+)
+
+// ImportOptions control a CSV import. Mapping maps subscription fields to
+// the CSV columns holding them; fields not in Mapping are read from the
+// column of the same name. A dry run validates every row and writes
+// nothing.
+type ImportOptions struct {
+	Mapping map[string]string
+	Mode    string
+	DryRun  bool
+}
+
+// ImportRowResult is the outcome of one CSV row, numbered by its line in
+// the file. SubscriptionID is set for imported rows.
+type ImportRowResult struct {
+	Row            int        `json:"row"`
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+type ImportResult struct {
+	Rows     int               `json:"rows"`
+	Valid    int               `json:"valid"`
+	Imported int               `json:"imported"`
+	DryRun   bool              `json:"dry_run"`
+	Results  []ImportRowResult `json:"results"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditActionAddMember    = "add_member"
+	AuditActionRemoveMember = "remove_member"
+)
+
+var (
+	ErrMemberNotFound = errors.New("subscription member not found")
+	ErrMemberExists   = errors.New("user is already a member of the subscription")
+	ErrNotOwner       = errors.New("only the owner of the subscription can change its members")
+)
+
+// Member shares the cost of a subscription with its owner, either by
+// Weight or for a fixed monthly Amount out of the full price. The owner
+// may be listed with a weight to pay more or less than one share.
+type Member struct {
+	UserID uuid.UUID `json:"user_id"`
+	Weight *int      `json:"weight,omitempty"`
+	Amount *int      `json:"amount,omitempty"`
+}
@@ -6,11 +6,43 @@ import (
 	. "github.com/google/uuid"
 )
 
+// Subscription is charged Price on the first day of every month from
+// StartDate through EndDate, except that charges before TrialEnd are
+// TrialPrice. Months covered by Pauses are not charged.
 type Subscription struct {
 	ID          UUID       `json:"id"`
+	ServiceID   UUID       `json:"service_id"`
 	ServiceName string     `json:"service_name"`
 	Price       int        `json:"price"`
 	UserId      UUID       `json:"user_id"`
 	StartDate   time.Time  `json:"start_date"`
 	EndDate     *time.Time `json:"end_date"`
+	TrialEnd    *time.Time `json:"trial_end,omitempty"`
+	TrialPrice  int        `json:"trial_price"`
+	Pauses      []Pause    `json:"pauses"`
+	Members     []Member   `json:"members"`
+	CategoryID  *UUID      `json:"category_id"`
+	Tags        []string   `json:"tags"`
+	Version     int        `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	// OverlapAllowed leaves the subscription out of the check that
+	// subscriptions of a user to one service do not overlap.
+	OverlapAllowed bool `json:"-"`
+	// Overlaps are the subscriptions found to overlap this one when it
+	// was written in warn mode.
+	Overlaps []UUID `json:"-"`
+	// PricePeriods holds the prices replaced by applied price changes, by
+	// date; see PriceAt.
+	PricePeriods []PricePeriod `json:"-"`
+}
+
+// SubscriptionFilter narrows down List results; zero fields match
+// everything.
+type SubscriptionFilter struct {
+	IncludeDeleted bool
+	OnlyDeleted    bool // the trash; only admins can set either
+	UserID         *UUID
+	MemberID       *UUID // subscriptions the user owns or is a member of
+	CategoryID     *UUID
+	Tags           []string // subscriptions with all of these tags
 }
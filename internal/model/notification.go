@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	NotificationRenewal = "renewal"
+	NotificationEnd     = "end"
+	NotificationBudget  = "budget"
+)
+
+// NotificationSettings are per-user reminder preferences. Nil lead times
+// fall back to the configured defaults.
+type NotificationSettings struct {
+	UserID          uuid.UUID `json:"user_id"`
+	Email           *string   `json:"email,omitempty"`
+	RenewalLeadDays *int      `json:"renewal_lead_days,omitempty"`
+	EndLeadDays     *int      `json:"end_lead_days,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Notification is a message for a user, delivered by a notifier.
+type Notification struct {
+	Kind           string     `json:"kind"`
+	UserID         uuid.UUID  `json:"user_id"`
+	Email          string     `json:"email,omitempty"`
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"`
+	Date           time.Time  `json:"date"`
+	Subject        string     `json:"subject"`
+	Body           string     `json:"body"`
+}
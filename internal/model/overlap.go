@@ -0,0 +1,29 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Overlap modes decide what happens when a subscription is written that
+// shares a month with another live subscription of the same user to the
+// same service.
+const (
+	OverlapReject = "reject"
+	OverlapWarn   = "warn"
+	OverlapAllow  = "allow"
+)
+
+var ErrSubscriptionOverlap = errors.New("subscription overlaps another subscription of the user to the same service")
+
+// Overlap is a pair of live subscriptions of one user to the same service
+// that share the months from StartDate to EndDate, open-ended if nil.
+type Overlap struct {
+	ServiceID       uuid.UUID
+	ServiceName     string
+	SubscriptionIDs [2]uuid.UUID
+	StartDate       time.Time
+	EndDate         *time.Time
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusScheduled = "scheduled"
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusEnded     = "ended"
+)
+
+var (
+	ErrNotPaused     = errors.New("subscription is not paused")
+	ErrPauseConflict = errors.New("pause overlaps another pause")
+)
+
+// Pause is a non-billable gap: the months from StartDate up to, but not
+// including, ResumeDate are not charged. An open pause has no ResumeDate.
+type Pause struct {
+	ID         uuid.UUID  `json:"id"`
+	StartDate  time.Time  `json:"start_date"`
+	ResumeDate *time.Time `json:"resume_date,omitempty"`
+}
+
+// Covers reports whether the pause covers the given month.
+func (p Pause) Covers(month time.Time) bool {
+	return !month.Before(p.StartDate) && (p.ResumeDate == nil || month.Before(*p.ResumeDate))
+}
+
+// Overlaps reports whether the two pauses share a month.
+func (p Pause) Overlaps(o Pause) bool {
+	return (o.ResumeDate == nil || p.StartDate.Before(*o.ResumeDate)) &&
+		(p.ResumeDate == nil || o.StartDate.Before(*p.ResumeDate))
+}
+
+// PausedAt reports whether the subscription is paused in the given month.
+func (s *Subscription) PausedAt(month time.Time) bool {
+	for _, p := range s.Pauses {
+		if p.Covers(month) {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the state of the subscription at now.
+func (s *Subscription) Status(now time.Time) string {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	switch {
+	case month.Before(s.StartDate):
+		return StatusScheduled
+	case s.EndDate != nil && month.After(*s.EndDate):
+		return StatusEnded
+	case s.PausedAt(month):
+		return StatusPaused
+	default:
+		return StatusActive
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GranularityMonth   = "month"
+	GranularityQuarter = "quarter"
+	GranularityYear    = "year"
+)
+
+// SpendFilter selects the months from Start through End, grouped into
+// buckets of Granularity, and the subscriptions of UserID unless it is nil.
+type SpendFilter struct {
+	UserID      *uuid.UUID
+	Start       time.Time
+	End         time.Time
+	Granularity string
+}
+
+// SpendBucket sums up the charges of the months of one bucket. New and
+// Ended count the subscriptions whose first or last billed month is in the
+// bucket.
+type SpendBucket struct {
+	Period        time.Time
+	Total         int
+	Subscriptions int
+	New           int
+	Ended         int
+}
+
+// MonthlyCost is what the subscriptions of a user cost in Month.
+type MonthlyCost struct {
+	Month time.Time `json:"month"`
+	Total int       `json:"total"`
+}
+
+// CategoryCost is the cost of the subscriptions of one category over a
+// period. CategoryID is nil for subscriptions without a category.
+type CategoryCost struct {
+	CategoryID    *uuid.UUID `json:"category_id"`
+	CategoryName  string     `json:"category_name"`
+	Total         int        `json:"total"`
+	Subscriptions int        `json:"subscriptions"`
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+// LogNotifier writes notifications to the application log.
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (l *LogNotifier) Notify(ctx context.Context, n model.Notification) error {
+	l.logger.InfoContext(ctx, "notification",
+		"kind", n.Kind,
+		"user_id", n.UserID,
+		"subject", n.Subject,
+		"body", n.Body,
+	)
+	return nil
+}
@@ -0,0 +1,51 @@
+// Package notify delivers notifications to users.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/config"
+	"github.com/Lirohop/App/internal/model"
+)
+
+const (
+	TypeLog     = "log"
+	TypeSMTP    = "smtp"
+	TypeWebhook = "webhook"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, n model.Notification) error
+}
+
+// New creates every notifier listed in cfg, by type. Callers track what
+// each of them delivered, so that a failing notifier does not make the
+// others send again.
+func New(cfg config.NotificationsConfig, logger *slog.Logger) (map[string]Notifier, error) {
+	notifiers := make(map[string]Notifier, len(cfg.Notifiers))
+
+	for _, t := range cfg.Notifiers {
+		switch t {
+		case TypeLog:
+			notifiers[t] = NewLogNotifier(logger)
+		case TypeSMTP:
+			n, err := NewSMTPNotifier(cfg.SMTP, logger)
+			if err != nil {
+				return nil, err
+			}
+			notifiers[t] = n
+		case TypeWebhook:
+			n, err := NewWebhookNotifier(cfg.Webhook.URL, cfg.Webhook.Timeout)
+			if err != nil {
+				return nil, err
+			}
+			notifiers[t] = n
+		default:
+			return nil, fmt.Errorf("unknown notifier %q", t)
+		}
+	}
+
+	return notifiers, nil
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lirohop/App/internal/config"
+	"github.com/Lirohop/App/internal/model"
+)
+
+// SMTPNotifier emails notifications to the user's address. Users without
+// an address are skipped. Authentication is used only when a username is
+// configured, so it works against local fake servers such as MailHog.
+type SMTPNotifier struct {
+	addr   string
+	from   string
+	auth   smtp.Auth
+	logger *slog.Logger
+}
+
+func NewSMTPNotifier(cfg config.SMTPConfig, logger *slog.Logger) (*SMTPNotifier, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, errors.New("smtp notifier requires smtp.host and smtp.from")
+	}
+
+	n := &SMTPNotifier{
+		addr:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:   cfg.From,
+		logger: logger,
+	}
+	if cfg.Username != "" {
+		n.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	return n, nil
+}
+
+func (s *SMTPNotifier) Notify(_ context.Context, n model.Notification) error {
+	if n.Email == "" {
+		s.logger.Debug("no email address, skipping notification", "user_id", n.UserID, "kind", n.Kind)
+		return nil
+	}
+
+	if err := smtp.SendMail(s.addr, s.auth, s.from, []string{n.Email}, s.message(n)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SMTPNotifier) message(n model.Notification) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + headerValue(s.from) + "\r\n")
+	b.WriteString("To: " + headerValue(n.Email) + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", headerValue(n.Subject)) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(n.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return []byte(b.String())
+}
+
+// headerValue strips line breaks from v, which contains user input such
+// as service names, so that it cannot add headers or recipients.
+func headerValue(v string) string {
+	return strings.Join(strings.FieldsFunc(v, func(r rune) bool {
+		return r == '\r' || r == '\n'
+	}), " ")
+}
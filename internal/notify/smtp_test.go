@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Lirohop/App/internal/config"
+	"github.com/Lirohop/App/internal/model"
+)
+
+// fakeSMTP accepts one message on a local port and records its envelope
+// recipients and data.
+type fakeSMTP struct {
+	lis        net.Listener
+	recipients []string
+	data       chan string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	f := &fakeSMTP{lis: lis, data: make(chan string, 1)}
+	go f.serve()
+
+	return f
+}
+
+func (f *fakeSMTP) serve() {
+	conn, err := f.lis.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			f.recipients = append(f.recipients, strings.TrimSpace(line[len("RCPT TO:"):]))
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(strings.TrimPrefix(l, "."))
+			}
+			f.data <- b.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("500 unknown command")
+		}
+	}
+}
+
+func (f *fakeSMTP) config() config.SMTPConfig {
+	addr := f.lis.Addr().(*net.TCPAddr)
+	return config.SMTPConfig{Host: addr.IP.String(), Port: addr.Port, From: "reminders@example.com"}
+}
+
+func TestSMTPNotifierHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{
+			name:    "ascii",
+			subject: "Netflix renews on 2025-08-01",
+			want:    "Netflix renews on 2025-08-01",
+		},
+		{
+			name:    "line breaks",
+			subject: "Netflix\r\nBcc: victim@example.com\r\n renews",
+			want:    "Netflix Bcc: victim@example.com  renews",
+		},
+		{
+			name:    "non-ascii",
+			subject: "Кинопоиск renews on 2025-08-01",
+			want:    "Кинопоиск renews on 2025-08-01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t)
+
+			n, err := NewSMTPNotifier(server.config(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = n.Notify(context.Background(), model.Notification{
+				Email:   "user@example.com",
+				Subject: tt.subject,
+				Body:    "body",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			msg, err := mail.ReadMessage(strings.NewReader(<-server.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Join(server.recipients, ","); got != "<user@example.com>" {
+				t.Errorf("recipients = %s, want <user@example.com>", got)
+			}
+			if bcc := msg.Header["Bcc"]; bcc != nil {
+				t.Errorf("Bcc header = %q, want none", bcc)
+			}
+
+			raw := msg.Header.Get("Subject")
+			for _, r := range raw {
+				if r > 127 {
+					t.Fatalf("Subject %q is not ASCII", raw)
+				}
+			}
+
+			got, err := new(mime.WordDecoder).DecodeHeader(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Subject = %s, want %s", strconv.Quote(got), strconv.Quote(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+// WebhookNotifier POSTs notifications as JSON to a fixed URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) (*WebhookNotifier, error) {
+	if url == "" {
+		return nil, errors.New("webhook notifier requires webhook.url")
+	}
+
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, n model.Notification) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
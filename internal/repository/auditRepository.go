@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/requestctx"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const defaultAuditLimit = 100
+
+type AuditRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewAuditRepository(db *pgxpool.Pool, logger *slog.Logger) *AuditRepository {
+	return &AuditRepository{db: db, logger: logger}
+}
+
+// List returns audit entries matching filter, oldest first.
+func (r *AuditRepository) List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	if filter.SubscriptionID != nil {
+		args = append(args, *filter.SubscriptionID)
+		conds = append(conds, fmt.Sprintf("subscription_id = $%d", len(args)))
+	}
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		conds = append(conds, fmt.Sprintf("actor = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		conds = append(conds, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conds = append(conds, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conds = append(conds, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	query := `SELECT id, subscription_id, action, actor, coalesce(request_id, ''), created_at, before, after
+	 FROM subscription_audit`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("failed to select audit entries", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*model.AuditEntry, 0)
+
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.Scan(
+			&e.ID,
+			&e.SubscriptionID,
+			&e.Action,
+			&e.Actor,
+			&e.RequestID,
+			&e.CreatedAt,
+			&e.Before,
+			&e.After,
+		); err != nil {
+			r.logger.Error("failed to scan audit row", "error", err)
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during audit rows iteration", "error", err)
+		return nil, err
+	}
+
+	r.logger.Info("audit entries successfully fetched", "count", len(entries))
+
+	return entries, nil
+}
+
+// insertAudit appends an audit entry using the actor and request id from
+// ctx. It must run in the same transaction as the change it records.
+func insertAudit(ctx context.Context, q querier, action string, before, after *model.Subscription) error {
+	args, err := auditArgs(ctx, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(ctx, insertAuditSQL, args...)
+	return err
+}
+
+// queueAudit is insertAudit for a statement sent with b.
+func queueAudit(ctx context.Context, b *pgx.Batch, action string, before, after *model.Subscription) error {
+	args, err := auditArgs(ctx, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	b.Queue(insertAuditSQL, args...)
+	return nil
+}
+
+const insertAuditSQL = `INSERT INTO subscription_audit(subscription_id, action, actor, request_id, before, after)
+         VALUES($1, $2, $3, nullif($4, ''), $5, $6)`
+
+func auditArgs(ctx context.Context, action string, before, after *model.Subscription) ([]any, error) {
+	var beforeJSON, afterJSON []byte
+
+	if before != nil {
+		var err error
+		if beforeJSON, err = json.Marshal(before); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		var err error
+		if afterJSON, err = json.Marshal(after); err != nil {
+			return nil, err
+		}
+	}
+
+	return []any{
+		subscriptionID(before, after),
+		action,
+		requestctx.Actor(ctx),
+		requestctx.RequestID(ctx),
+		beforeJSON,
+		afterJSON,
+	}, nil
+}
+
+func subscriptionID(before, after *model.Subscription) uuid.UUID {
+	if after != nil {
+		return after.ID
+	}
+	return before.ID
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const budgetColumns = `id, user_id, scope, category_id, service_id, amount, period, created_at, updated_at`
+
+type BudgetRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewBudgetRepository(db *pgxpool.Pool, logger *slog.Logger) *BudgetRepository {
+	return &BudgetRepository{db: db, logger: logger}
+}
+
+func (r *BudgetRepository) Create(ctx context.Context, b *model.Budget) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO budgets(user_id, scope, category_id, service_id, amount, period)
+         VALUES($1, $2, $3, $4, $5, $6)
+         RETURNING id, created_at, updated_at`,
+		b.UserID, b.Scope, b.CategoryID, b.ServiceID, b.Amount, b.Period,
+	).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+
+	if err != nil {
+		r.logger.Error("failed to create budget", "error", err, "user_id", b.UserID)
+		return err
+	}
+
+	r.logger.Info("budget created in repository", "id", b.ID)
+
+	return nil
+}
+
+func (r *BudgetRepository) Update(ctx context.Context, b *model.Budget) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE budgets
+         SET user_id = $1, scope = $2, category_id = $3, service_id = $4,
+             amount = $5, period = $6, updated_at = now()
+         WHERE id = $7
+         RETURNING created_at, updated_at`,
+		b.UserID, b.Scope, b.CategoryID, b.ServiceID, b.Amount, b.Period, b.ID,
+	).Scan(&b.CreatedAt, &b.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrBudgetNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to update budget", "error", err, "id", b.ID)
+		return err
+	}
+
+	r.logger.Info("budget updated in repository", "id", b.ID)
+
+	return nil
+}
+
+func (r *BudgetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete budget", "error", err, "id", id)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrBudgetNotFound
+	}
+
+	r.logger.Info("budget deleted in repository", "id", id)
+
+	return nil
+}
+
+func (r *BudgetRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Budget, error) {
+	b, err := scanBudget(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+budgetColumns+` FROM budgets WHERE id = $1`, id))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrBudgetNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get budget", "error", err, "id", id)
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// List returns the budgets of the user, or of every user if userID is nil.
+func (r *BudgetRepository) List(ctx context.Context, userID *uuid.UUID) ([]*model.Budget, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+budgetColumns+`
+	 FROM budgets
+	 WHERE $1::uuid IS NULL OR user_id = $1
+	 ORDER BY user_id, created_at, id`, userID)
+	if err != nil {
+		r.logger.Error("failed to select budgets", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := make([]*model.Budget, 0)
+
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			r.logger.Error("failed to scan budget row", "error", err)
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during budget rows iteration", "error", err)
+		return nil, err
+	}
+
+	return budgets, nil
+}
+
+func scanBudget(row pgx.Row) (*model.Budget, error) {
+	var b model.Budget
+
+	if err := row.Scan(
+		&b.ID,
+		&b.UserID,
+		&b.Scope,
+		&b.CategoryID,
+		&b.ServiceID,
+		&b.Amount,
+		&b.Period,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CalendarRepository stores the hashes of the users' calendar feed tokens.
+type CalendarRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewCalendarRepository(db *pgxpool.Pool, logger *slog.Logger) *CalendarRepository {
+	return &CalendarRepository{db: db, logger: logger}
+}
+
+// SaveToken sets the token hash of the user, replacing any previous one.
+func (r *CalendarRepository) SaveToken(ctx context.Context, userID uuid.UUID, hash []byte) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO calendar_tokens(user_id, token_hash)
+         VALUES($1, $2)
+         ON CONFLICT (user_id) DO UPDATE
+         SET token_hash = EXCLUDED.token_hash, created_at = now()`, userID, hash)
+	if err != nil {
+		r.logger.Error("failed to save calendar token", "error", err, "user_id", userID)
+		return err
+	}
+
+	r.logger.Info("calendar token saved in repository", "user_id", userID)
+
+	return nil
+}
+
+func (r *CalendarRepository) GetTokenHash(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	var hash []byte
+
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT token_hash FROM calendar_tokens WHERE user_id = $1`, userID).Scan(&hash)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrCalendarTokenNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get calendar token", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+func (r *CalendarRepository) DeleteToken(ctx context.Context, userID uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM calendar_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		r.logger.Error("failed to delete calendar token", "error", err, "user_id", userID)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrCalendarTokenNotFound
+	}
+
+	r.logger.Info("calendar token deleted in repository", "user_id", userID)
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const candidateColumns = `id, user_id, merchant_key, merchant, service_id, service_name, price, amount, currency,
+	 billing_period, first_charge, last_charge, occurrences, status, subscription_id,
+	 created_at, updated_at, decided_at`
+
+type CandidateRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewCandidateRepository(db *pgxpool.Pool, logger *slog.Logger) *CandidateRepository {
+	return &CandidateRepository{db: db, logger: logger}
+}
+
+// Upsert stores c, or merges it into the candidate already found for the
+// same merchant, currency and billing period, taking merchant, service and
+// price from the later charge. Accepted and rejected candidates are left
+// alone, in which case Upsert returns false.
+func (r *CandidateRepository) Upsert(ctx context.Context, c *model.SubscriptionCandidate) (bool, error) {
+	stored, err := scanCandidate(conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO subscription_candidates AS c(
+	     user_id, merchant_key, merchant, service_id, service_name, price, amount, currency,
+	     billing_period, first_charge, last_charge, occurrences, status, subscription_id)
+	 VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	 ON CONFLICT (user_id, merchant_key, currency, billing_period) DO UPDATE
+	 SET merchant = CASE WHEN EXCLUDED.last_charge >= c.last_charge THEN EXCLUDED.merchant ELSE c.merchant END,
+	     service_id = EXCLUDED.service_id,
+	     service_name = EXCLUDED.service_name,
+	     price = CASE WHEN EXCLUDED.last_charge >= c.last_charge THEN EXCLUDED.price ELSE c.price END,
+	     amount = CASE WHEN EXCLUDED.last_charge >= c.last_charge THEN EXCLUDED.amount ELSE c.amount END,
+	     first_charge = least(c.first_charge, EXCLUDED.first_charge),
+	     last_charge = greatest(c.last_charge, EXCLUDED.last_charge),
+	     occurrences = greatest(c.occurrences, EXCLUDED.occurrences),
+	     status = EXCLUDED.status,
+	     subscription_id = EXCLUDED.subscription_id,
+	     updated_at = now()
+	 WHERE c.status IN ('pending', 'existing')
+	 RETURNING `+candidateColumns,
+		c.UserID, c.MerchantKey, c.Merchant, c.ServiceID, c.ServiceName, c.Price, c.Amount, c.Currency,
+		c.BillingPeriod, c.FirstCharge, c.LastCharge, c.Occurrences, c.Status, c.SubscriptionID))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error("failed to store subscription candidate", "error", err, "user_id", c.UserID)
+		return false, err
+	}
+
+	*c = *stored
+
+	return true, nil
+}
+
+func (r *CandidateRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.SubscriptionCandidate, error) {
+	return r.get(ctx, `SELECT `+candidateColumns+` FROM subscription_candidates WHERE id = $1`, id)
+}
+
+// GetForUpdate locks the candidate until the end of the transaction in ctx.
+func (r *CandidateRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.SubscriptionCandidate, error) {
+	return r.get(ctx, `SELECT `+candidateColumns+` FROM subscription_candidates WHERE id = $1 FOR UPDATE`, id)
+}
+
+func (r *CandidateRepository) get(ctx context.Context, query string, id uuid.UUID) (*model.SubscriptionCandidate, error) {
+	c, err := scanCandidate(conn(ctx, r.db).QueryRow(ctx, query, id))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrCandidateNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get subscription candidate", "error", err, "id", id)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// List returns the candidates of the user, only those with the given
+// status unless it is empty, most recently charged first.
+func (r *CandidateRepository) List(ctx context.Context, userID uuid.UUID, status string) ([]*model.SubscriptionCandidate, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+candidateColumns+`
+	 FROM subscription_candidates
+	 WHERE user_id = $1 AND ($2 = '' OR status = $2)
+	 ORDER BY last_charge DESC, merchant`, userID, status)
+	if err != nil {
+		r.logger.Error("failed to select subscription candidates", "error", err, "user_id", userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]*model.SubscriptionCandidate, 0)
+
+	for rows.Next() {
+		c, err := scanCandidate(rows)
+		if err != nil {
+			r.logger.Error("failed to scan subscription candidate row", "error", err)
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during subscription candidate rows iteration", "error", err)
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// Decide sets the final status of the candidate and, for an accepted one,
+// the subscription created from it.
+func (r *CandidateRepository) Decide(
+	ctx context.Context,
+	c *model.SubscriptionCandidate,
+	status string,
+	subscriptionID *uuid.UUID,
+) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE subscription_candidates
+	 SET status = $1, subscription_id = $2, decided_at = now(), updated_at = now()
+	 WHERE id = $3
+	 RETURNING updated_at, decided_at`, status, subscriptionID, c.ID).Scan(&c.UpdatedAt, &c.DecidedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrCandidateNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to decide subscription candidate", "error", err, "id", c.ID)
+		return err
+	}
+
+	c.Status = status
+	c.SubscriptionID = subscriptionID
+
+	r.logger.Info("subscription candidate decided in repository", "id", c.ID, "status", status)
+
+	return nil
+}
+
+func scanCandidate(row pgx.Row) (*model.SubscriptionCandidate, error) {
+	var c model.SubscriptionCandidate
+
+	if err := row.Scan(
+		&c.ID,
+		&c.UserID,
+		&c.MerchantKey,
+		&c.Merchant,
+		&c.ServiceID,
+		&c.ServiceName,
+		&c.Price,
+		&c.Amount,
+		&c.Currency,
+		&c.BillingPeriod,
+		&c.FirstCharge,
+		&c.LastCharge,
+		&c.Occurrences,
+		&c.Status,
+		&c.SubscriptionID,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.DecidedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	serviceColumns = `id, name, aliases, category_id, default_price, currency, billing_period, website,
+	 created_at, updated_at`
+
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgExclusionViolation  = "23P01"
+)
+
+type CatalogRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewCatalogRepository(db *pgxpool.Pool, logger *slog.Logger) *CatalogRepository {
+	return &CatalogRepository{db: db, logger: logger}
+}
+
+func (r *CatalogRepository) Create(ctx context.Context, s *model.Service) error {
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		err := q.QueryRow(ctx,
+			`INSERT INTO services(name, aliases, category_id, default_price, currency, billing_period, website)
+         VALUES($1, $2, $3, $4, $5, $6, $7)
+         RETURNING id, created_at, updated_at`,
+			s.Name, s.Aliases, s.CategoryID, s.DefaultPrice, s.Currency, s.BillingPeriod, s.Website,
+		).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		return insertServiceKeys(ctx, q, s)
+	})
+
+	if err := serviceError(err); err != nil {
+		if !errors.Is(err, model.ErrServiceNameTaken) {
+			r.logger.Error("failed to create service", "error", err, "name", s.Name)
+		}
+		return err
+	}
+
+	r.logger.Info("service created in repository", "id", s.ID)
+
+	return nil
+}
+
+// Update changes the service. A new name is copied to its subscriptions,
+// each of which gets a new version and an audit entry; they are returned
+// as they were before the rename.
+func (r *CatalogRepository) Update(ctx context.Context, s *model.Service) ([]*model.Subscription, error) {
+	var renamed []*model.Subscription
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		err := q.QueryRow(ctx,
+			`UPDATE services
+         SET name = $1,
+             aliases = $2,
+             category_id = $3,
+             default_price = $4,
+             currency = $5,
+             billing_period = $6,
+             website = $7,
+             updated_at = now()
+         WHERE id = $8
+         RETURNING created_at, updated_at`,
+			s.Name, s.Aliases, s.CategoryID, s.DefaultPrice, s.Currency, s.BillingPeriod, s.Website, s.ID,
+		).Scan(&s.CreatedAt, &s.UpdatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrServiceNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.Exec(ctx, `DELETE FROM service_name_keys WHERE service_id = $1`, s.ID); err != nil {
+			return err
+		}
+
+		if err := insertServiceKeys(ctx, q, s); err != nil {
+			return err
+		}
+
+		// Keep the denormalized name of subscriptions in sync.
+		rows, err := q.Query(ctx,
+			`SELECT `+subscriptionColumns+`
+	 FROM subscriptions
+	 WHERE service_id = $1 AND service_name <> $2
+	 FOR UPDATE`, s.ID, s.Name)
+		if err != nil {
+			return err
+		}
+
+		renamed, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Subscription, error) {
+			return scanSubscription(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.Exec(ctx,
+			`UPDATE subscriptions
+         SET service_name = $2,
+             version = version + 1
+         WHERE service_id = $1 AND service_name <> $2`, s.ID, s.Name); err != nil {
+			return err
+		}
+
+		for _, before := range renamed {
+			after := *before
+			after.ServiceName = s.Name
+			after.Version++
+
+			if err := insertAudit(ctx, q, model.AuditActionUpdate, before, &after); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err := serviceError(err); err != nil {
+		if !errors.Is(err, model.ErrServiceNotFound) && !errors.Is(err, model.ErrServiceNameTaken) {
+			r.logger.Error("failed to update service", "error", err, "id", s.ID)
+		}
+		return nil, err
+	}
+
+	r.logger.Info("service updated in repository", "id", s.ID, "renamed_subscriptions", len(renamed))
+
+	return renamed, nil
+}
+
+func (r *CatalogRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err := serviceError(err); err != nil {
+		if !errors.Is(err, model.ErrServiceInUse) {
+			r.logger.Error("failed to delete service", "error", err, "id", id)
+		}
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrServiceNotFound
+	}
+
+	r.logger.Info("service deleted in repository", "id", id)
+
+	return nil
+}
+
+func (r *CatalogRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Service, error) {
+	s, err := scanService(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+serviceColumns+` FROM services WHERE id = $1`, id))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrServiceNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get service", "error", err, "id", id)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Resolve returns the service whose canonical name or alias matches name,
+// ignoring case and extra whitespace.
+func (r *CatalogRepository) Resolve(ctx context.Context, name string) (*model.Service, error) {
+	s, err := scanService(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+serviceColumns+`
+	 FROM services
+	 WHERE id = (SELECT service_id FROM service_name_keys WHERE key = service_key($1))`, name))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrServiceNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to resolve service", "error", err, "name", name)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Ensure resolves name, adding a service named after it to the catalog if
+// none matches. Concurrent calls for the same name add one service.
+func (r *CatalogRepository) Ensure(ctx context.Context, name string) (*model.Service, error) {
+	var s *model.Service
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		_, err := conn(ctx, r.db).Exec(ctx,
+			`SELECT pg_advisory_xact_lock(hashtext(service_key($1)))`, name)
+		if err != nil {
+			return err
+		}
+
+		s, err = r.Resolve(ctx, name)
+		if !errors.Is(err, model.ErrServiceNotFound) {
+			return err
+		}
+
+		s = &model.Service{
+			Name:          name,
+			Aliases:       []string{},
+			BillingPeriod: model.BillingMonthly,
+		}
+		return r.Create(ctx, s)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// List returns the catalog, only the given category unless it is nil.
+func (r *CatalogRepository) List(ctx context.Context, categoryID *uuid.UUID) ([]*model.Service, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+serviceColumns+`
+	 FROM services
+	 WHERE $1::uuid IS NULL OR category_id = $1
+	 ORDER BY name`, categoryID)
+	if err != nil {
+		r.logger.Error("failed to select services", "error", err)
+		return nil, err
+	}
+
+	return r.collectServices(rows)
+}
+
+// Search returns up to limit services whose canonical name or an alias
+// contains query, best matches first.
+func (r *CatalogRepository) Search(ctx context.Context, query string, limit int) ([]*model.Service, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+serviceColumns+`
+	 FROM services
+	 JOIN LATERAL (
+	     SELECT min(strpos(k.key, service_key($1))) AS pos
+	     FROM service_name_keys k
+	     WHERE k.service_id = services.id AND strpos(k.key, service_key($1)) > 0
+	 ) m ON m.pos IS NOT NULL
+	 ORDER BY m.pos, length(name), name
+	 LIMIT $2`, query, limit)
+	if err != nil {
+		r.logger.Error("failed to search services", "error", err, "query", query)
+		return nil, err
+	}
+
+	return r.collectServices(rows)
+}
+
+// Match returns the service whose canonical name or an alias occurs as
+// whole words in text, such as the merchant of a bank transaction,
+// preferring the longest name. Only letters are compared.
+func (r *CatalogRepository) Match(ctx context.Context, text string) (*model.Service, error) {
+	s, err := scanService(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+serviceColumns+`
+	 FROM services
+	 WHERE id = (
+	     SELECT k.service_id
+	     FROM service_name_keys k,
+	          btrim(regexp_replace(k.key, '[^[:alpha:]]+', ' ', 'g')) AS words
+	     WHERE words <> ''
+	       AND strpos(' ' || regexp_replace(service_key($1), '[^[:alpha:]]+', ' ', 'g') || ' ',
+	                  ' ' || words || ' ') > 0
+	     ORDER BY length(words) DESC, k.key
+	     LIMIT 1)`, text))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrServiceNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to match service", "error", err, "text", text)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *CatalogRepository) collectServices(rows pgx.Rows) ([]*model.Service, error) {
+	defer rows.Close()
+
+	services := make([]*model.Service, 0)
+
+	for rows.Next() {
+		s, err := scanService(rows)
+		if err != nil {
+			r.logger.Error("failed to scan service row", "error", err)
+			return nil, err
+		}
+		services = append(services, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during service rows iteration", "error", err)
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// insertServiceKeys maps the name and aliases of s to it.
+func insertServiceKeys(ctx context.Context, q querier, s *model.Service) error {
+	names := append([]string{s.Name}, s.Aliases...)
+
+	_, err := q.Exec(ctx,
+		`INSERT INTO service_name_keys(key, service_id)
+         SELECT DISTINCT service_key(n), $1 FROM unnest($2::text[]) n`, s.ID, names)
+	return err
+}
+
+// serviceError translates constraint violations of the catalog tables.
+func serviceError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return model.ErrServiceNameTaken
+		case pgForeignKeyViolation:
+			return model.ErrServiceInUse
+		}
+	}
+	return err
+}
+
+func scanService(row pgx.Row) (*model.Service, error) {
+	var s model.Service
+
+	err := row.Scan(
+		&s.ID,
+		&s.Name,
+		&s.Aliases,
+		&s.CategoryID,
+		&s.DefaultPrice,
+		&s.Currency,
+		&s.BillingPeriod,
+		&s.Website,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
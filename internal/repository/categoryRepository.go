@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const categoryColumns = `id, name, created_at`
+
+type CategoryRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewCategoryRepository(db *pgxpool.Pool, logger *slog.Logger) *CategoryRepository {
+	return &CategoryRepository{db: db, logger: logger}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, c *model.Category) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO categories(name) VALUES($1)
+         RETURNING id, created_at`, c.Name).Scan(&c.ID, &c.CreatedAt)
+
+	if err := categoryError(err); err != nil {
+		if !errors.Is(err, model.ErrCategoryNameTaken) {
+			r.logger.Error("failed to create category", "error", err, "name", c.Name)
+		}
+		return err
+	}
+
+	r.logger.Info("category created in repository", "id", c.ID)
+
+	return nil
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, c *model.Category) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE categories SET name = $1 WHERE id = $2
+         RETURNING created_at`, c.Name, c.ID).Scan(&c.CreatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrCategoryNotFound
+	}
+	if err := categoryError(err); err != nil {
+		if !errors.Is(err, model.ErrCategoryNameTaken) {
+			r.logger.Error("failed to update category", "error", err, "id", c.ID)
+		}
+		return err
+	}
+
+	r.logger.Info("category updated in repository", "id", c.ID)
+
+	return nil
+}
+
+// Delete removes the category; its services and subscriptions become
+// uncategorized.
+func (r *CategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete category", "error", err, "id", id)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrCategoryNotFound
+	}
+
+	r.logger.Info("category deleted in repository", "id", id)
+
+	return nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Category, error) {
+	c, err := scanCategory(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrCategoryNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get category", "error", err, "id", id)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]*model.Category, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+categoryColumns+` FROM categories ORDER BY name`)
+	if err != nil {
+		r.logger.Error("failed to select categories", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make([]*model.Category, 0)
+
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			r.logger.Error("failed to scan category row", "error", err)
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during category rows iteration", "error", err)
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func categoryError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return model.ErrCategoryNameTaken
+	}
+	return err
+}
+
+func scanCategory(row pgx.Row) (*model.Category, error) {
+	var c model.Category
+
+	if err := row.Scan(&c.ID, &c.Name, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
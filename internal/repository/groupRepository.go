@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// groupColumns is the column list scanned by scanGroup. It must be
+// selected from the groups table without an alias.
+const groupColumns = `id, name,
+	 (SELECT coalesce(jsonb_agg(jsonb_build_object(
+	         'user_id', m.user_id, 'role', m.role) ORDER BY m.created_at, m.user_id), '[]')
+	  FROM group_members m WHERE m.group_id = groups.id),
+	 created_at`
+
+type GroupRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewGroupRepository(db *pgxpool.Pool, logger *slog.Logger) *GroupRepository {
+	return &GroupRepository{db: db, logger: logger}
+}
+
+// Create inserts the group with its members.
+func (r *GroupRepository) Create(ctx context.Context, g *model.UserGroup) error {
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		if err := q.QueryRow(ctx,
+			`INSERT INTO groups(name) VALUES($1) RETURNING id, created_at`, g.Name,
+		).Scan(&g.ID, &g.CreatedAt); err != nil {
+			return err
+		}
+
+		for _, m := range g.Members {
+			if _, err := q.Exec(ctx,
+				`INSERT INTO group_members(group_id, user_id, role) VALUES($1, $2, $3)`,
+				g.ID, m.UserID, m.Role); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		r.logger.Error("failed to create group", "error", err)
+		return err
+	}
+
+	r.logger.Info("group created in repository", "id", g.ID)
+
+	return nil
+}
+
+func (r *GroupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM groups WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete group", "error", err, "id", id)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrGroupNotFound
+	}
+
+	r.logger.Info("group deleted in repository", "id", id)
+
+	return nil
+}
+
+func (r *GroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.UserGroup, error) {
+	g, err := scanGroup(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+groupColumns+` FROM groups WHERE id = $1`, id))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrGroupNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get group", "error", err, "id", id)
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// GetForUpdate returns the group and locks it until the transaction in ctx
+// ends, so that changes of its members are serialized. It must be called
+// within Transactor.WithinTx.
+func (r *GroupRepository) GetForUpdate(ctx context.Context, id uuid.UUID) (*model.UserGroup, error) {
+	g, err := scanGroup(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+groupColumns+` FROM groups WHERE id = $1 FOR UPDATE`, id))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrGroupNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to lock group", "error", err, "id", id)
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// ListByUser returns the groups the user is a member of, by name.
+func (r *GroupRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*model.UserGroup, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+groupColumns+`
+	 FROM groups
+	 WHERE EXISTS (SELECT 1 FROM group_members m WHERE m.group_id = groups.id AND m.user_id = $1)
+	 ORDER BY name, id`, userID)
+	if err != nil {
+		r.logger.Error("failed to select groups", "error", err, "user_id", userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]*model.UserGroup, 0)
+
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			r.logger.Error("failed to scan group row", "error", err)
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during group rows iteration", "error", err)
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func (r *GroupRepository) AddMember(ctx context.Context, id uuid.UUID, m model.GroupMember) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO group_members(group_id, user_id, role) VALUES($1, $2, $3)`,
+		id, m.UserID, m.Role)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return model.ErrGroupMemberExists
+		case pgForeignKeyViolation:
+			return model.ErrGroupNotFound
+		}
+	}
+	if err != nil {
+		r.logger.Error("failed to add group member", "error", err, "id", id, "user_id", m.UserID)
+		return err
+	}
+
+	r.logger.Info("group member added in repository", "id", id, "user_id", m.UserID, "role", m.Role)
+
+	return nil
+}
+
+func (r *GroupRepository) UpdateMemberRole(ctx context.Context, id, userID uuid.UUID, role string) error {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE group_members SET role = $3 WHERE group_id = $1 AND user_id = $2`,
+		id, userID, role)
+	if err != nil {
+		r.logger.Error("failed to update group member", "error", err, "id", id, "user_id", userID)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrGroupMemberNotFound
+	}
+
+	r.logger.Info("group member updated in repository", "id", id, "user_id", userID, "role", role)
+
+	return nil
+}
+
+func (r *GroupRepository) RemoveMember(ctx context.Context, id, userID uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM group_members WHERE group_id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		r.logger.Error("failed to remove group member", "error", err, "id", id, "user_id", userID)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrGroupMemberNotFound
+	}
+
+	r.logger.Info("group member removed in repository", "id", id, "user_id", userID)
+
+	return nil
+}
+
+func scanGroup(row pgx.Row) (*model.UserGroup, error) {
+	var g model.UserGroup
+
+	if err := row.Scan(&g.ID, &g.Name, &g.Members, &g.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return &g, nil
+}
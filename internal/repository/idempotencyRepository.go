@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewIdempotencyRepository(db *pgxpool.Pool, logger *slog.Logger) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db, logger: logger}
+}
+
+// Reserve claims the key for a new request. It returns false when a live
+// record already exists for the key; expired records are taken over.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key, fingerprint string, expiresAt time.Time) (bool, error) {
+	var reserved string
+
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO idempotency_keys(key, fingerprint, expires_at)
+         VALUES($1, $2, $3)
+         ON CONFLICT (key) DO UPDATE
+         SET fingerprint = EXCLUDED.fingerprint,
+             status_code = NULL,
+             headers = NULL,
+             body = NULL,
+             created_at = now(),
+             expires_at = EXCLUDED.expires_at
+         WHERE idempotency_keys.expires_at <= now()
+         RETURNING key`,
+		key, fingerprint, expiresAt).Scan(&reserved)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error("failed to reserve idempotency key", "error", err, "key", key)
+		return false, err
+	}
+
+	r.logger.Debug("idempotency key reserved", "key", key)
+
+	return true, nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, key string) (*model.IdempotencyRecord, error) {
+	var rec model.IdempotencyRecord
+
+	err := r.db.QueryRow(ctx,
+		`SELECT key, fingerprint, status_code, headers, body, created_at, expires_at
+	 From idempotency_keys
+	 Where key=$1`, key).Scan(
+		&rec.Key,
+		&rec.Fingerprint,
+		&rec.StatusCode,
+		&rec.Headers,
+		&rec.Body,
+		&rec.CreatedAt,
+		&rec.ExpiresAt)
+
+	if err != nil {
+		r.logger.Error("failed to get idempotency key", "error", err, "key", key)
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+func (r *IdempotencyRepository) Complete(
+	ctx context.Context,
+	key string,
+	statusCode int,
+	headers map[string][]string,
+	body []byte,
+) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE idempotency_keys
+         SET status_code = $1,
+             headers = $2,
+             body = $3
+         WHERE key = $4`,
+		statusCode, headers, body, key)
+
+	if err != nil {
+		r.logger.Error("failed to store idempotent response", "error", err, "key", key)
+		return err
+	}
+
+	r.logger.Debug("idempotent response stored", "key", key, "status_code", statusCode)
+
+	return nil
+}
+
+// Release drops a reservation whose request did not produce a response
+// worth replaying, so that the client can retry with the same key.
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	_, err := r.db.Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`, key)
+	if err != nil {
+		r.logger.Error("failed to release idempotency key", "error", err, "key", key)
+		return err
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		r.logger.Error("failed to delete expired idempotency keys", "error", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
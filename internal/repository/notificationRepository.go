@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewNotificationRepository(db *pgxpool.Pool, logger *slog.Logger) *NotificationRepository {
+	return &NotificationRepository{db: db, logger: logger}
+}
+
+// GetSettings returns the user's settings, or empty settings if the user
+// never saved any.
+func (r *NotificationRepository) GetSettings(ctx context.Context, userID uuid.UUID) (*model.NotificationSettings, error) {
+	var s model.NotificationSettings
+
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`SELECT user_id, email, renewal_lead_days, end_lead_days, updated_at
+	 FROM notification_settings
+	 WHERE user_id = $1`, userID).Scan(
+		&s.UserID,
+		&s.Email,
+		&s.RenewalLeadDays,
+		&s.EndLeadDays,
+		&s.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &model.NotificationSettings{UserID: userID}, nil
+	}
+	if err != nil {
+		r.logger.Error("failed to get notification settings", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// ListSettings returns the settings of every user who saved any, by user id.
+func (r *NotificationRepository) ListSettings(ctx context.Context) (map[uuid.UUID]*model.NotificationSettings, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT user_id, email, renewal_lead_days, end_lead_days, updated_at
+	 FROM notification_settings`)
+	if err != nil {
+		r.logger.Error("failed to select notification settings", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[uuid.UUID]*model.NotificationSettings)
+
+	for rows.Next() {
+		var s model.NotificationSettings
+		if err := rows.Scan(
+			&s.UserID,
+			&s.Email,
+			&s.RenewalLeadDays,
+			&s.EndLeadDays,
+			&s.UpdatedAt,
+		); err != nil {
+			r.logger.Error("failed to scan notification settings row", "error", err)
+			return nil, err
+		}
+		settings[s.UserID] = &s
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during notification settings rows iteration", "error", err)
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (r *NotificationRepository) SaveSettings(ctx context.Context, s *model.NotificationSettings) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO notification_settings(user_id, email, renewal_lead_days, end_lead_days)
+         VALUES($1, $2, $3, $4)
+         ON CONFLICT (user_id) DO UPDATE
+         SET email = EXCLUDED.email,
+             renewal_lead_days = EXCLUDED.renewal_lead_days,
+             end_lead_days = EXCLUDED.end_lead_days,
+             updated_at = now()
+         RETURNING updated_at`,
+		s.UserID, s.Email, s.RenewalLeadDays, s.EndLeadDays).Scan(&s.UpdatedAt)
+
+	if err != nil {
+		r.logger.Error("failed to save notification settings", "error", err, "user_id", s.UserID)
+		return err
+	}
+
+	r.logger.Info("notification settings saved", "user_id", s.UserID)
+
+	return nil
+}
+
+// Claim marks the notification identified by key as being sent. It returns
+// false if it was already sent, or is being sent by someone who claimed it
+// less than staleAfter ago.
+func (r *NotificationRepository) Claim(
+	ctx context.Context,
+	key, kind string,
+	userID uuid.UUID,
+	staleAfter time.Duration,
+) (bool, error) {
+	var claimed string
+
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO sent_notifications(key, kind, user_id)
+         VALUES($1, $2, $3)
+         ON CONFLICT (key) DO UPDATE
+         SET claimed_at = now()
+         WHERE sent_notifications.sent_at IS NULL
+           AND sent_notifications.claimed_at < $4
+         RETURNING key`,
+		key, kind, userID, time.Now().Add(-staleAfter)).Scan(&claimed)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error("failed to claim notification", "error", err, "key", key)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *NotificationRepository) MarkSent(ctx context.Context, key string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE sent_notifications SET sent_at = now() WHERE key = $1`, key)
+	if err != nil {
+		r.logger.Error("failed to mark notification sent", "error", err, "key", key)
+	}
+
+	return err
+}
+
+// Release gives up a claim so that the notification is retried.
+func (r *NotificationRepository) Release(ctx context.Context, key string) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM sent_notifications WHERE key = $1 AND sent_at IS NULL`, key)
+	if err != nil {
+		r.logger.Error("failed to release notification", "error", err, "key", key)
+	}
+
+	return err
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewOutboxRepository(db *pgxpool.Pool, logger *slog.Logger) *OutboxRepository {
+	return &OutboxRepository{db: db, logger: logger}
+}
+
+// Add stores events in the outbox. Call it in the transaction that makes
+// the change the events describe.
+func (r *OutboxRepository) Add(ctx context.Context, events ...model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	b := &pgx.Batch{}
+
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		b.Queue(
+			`INSERT INTO outbox(event_id, event_type, subscription_id, event)
+         VALUES($1, $2, $3, $4)`,
+			e.ID, e.Type, e.SubscriptionID, data)
+	}
+
+	if err := conn(ctx, r.db).SendBatch(ctx, b).Close(); err != nil {
+		r.logger.Error("failed to add events to outbox", "error", err, "count", len(events))
+		return err
+	}
+
+	return nil
+}
+
+// ClaimPending claims up to limit messages that are due for publishing by
+// postponing their next attempt to until, so that other relays skip them
+// while they are published outside of any transaction. Only the oldest
+// unpublished message of each subscription is returned, so events of one
+// subscription are published in order. Messages are returned by seq.
+func (r *OutboxRepository) ClaimPending(ctx context.Context, limit int, until time.Time) ([]*model.OutboxMessage, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`UPDATE outbox
+	 SET next_attempt_at = $2
+	 WHERE seq IN (
+	     SELECT o.seq
+	     FROM outbox o
+	     WHERE o.published_at IS NULL
+	       AND o.next_attempt_at <= now()
+	       AND NOT EXISTS (
+	           SELECT 1 FROM outbox p
+	           WHERE p.subscription_id = o.subscription_id
+	             AND p.published_at IS NULL
+	             AND p.seq < o.seq)
+	     ORDER BY o.seq
+	     LIMIT $1
+	     FOR UPDATE OF o SKIP LOCKED)
+	 RETURNING seq, event, attempts`, limit, until)
+	if err != nil {
+		r.logger.Error("failed to select pending outbox messages", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	msgs := make([]*model.OutboxMessage, 0)
+
+	for rows.Next() {
+		var m model.OutboxMessage
+		if err := rows.Scan(&m.Seq, &m.Event, &m.Attempts); err != nil {
+			r.logger.Error("failed to scan outbox row", "error", err)
+			return nil, err
+		}
+		msgs = append(msgs, &m)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during outbox rows iteration", "error", err)
+		return nil, err
+	}
+
+	slices.SortFunc(msgs, func(a, b *model.OutboxMessage) int {
+		return cmp.Compare(a.Seq, b.Seq)
+	})
+
+	return msgs, nil
+}
+
+func (r *OutboxRepository) MarkPublished(ctx context.Context, seq int64) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox
+         SET published_at = now(),
+             attempts = attempts + 1,
+             last_error = NULL
+         WHERE seq = $1`, seq)
+	if err != nil {
+		r.logger.Error("failed to mark outbox message published", "error", err, "seq", seq)
+	}
+
+	return err
+}
+
+func (r *OutboxRepository) MarkFailed(ctx context.Context, seq int64, publishErr error, nextAttempt time.Time) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE outbox
+         SET attempts = attempts + 1,
+             last_error = $2,
+             next_attempt_at = $3
+         WHERE seq = $1`, seq, publishErr.Error(), nextAttempt)
+	if err != nil {
+		r.logger.Error("failed to mark outbox message failed", "error", err, "seq", seq)
+	}
+
+	return err
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const priceChangeColumns = `id, subscription_id, effective_date, price, created_at, applied_at, previous_price`
+
+type PriceChangeRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewPriceChangeRepository(db *pgxpool.Pool, logger *slog.Logger) *PriceChangeRepository {
+	return &PriceChangeRepository{db: db, logger: logger}
+}
+
+func (r *PriceChangeRepository) Create(ctx context.Context, c *model.ScheduledPriceChange) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO scheduled_price_changes(subscription_id, effective_date, price)
+         VALUES($1, $2, $3)
+         RETURNING id, created_at`,
+		c.SubscriptionID, c.EffectiveDate, c.Price).Scan(&c.ID, &c.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return model.ErrPriceChangeConflict
+	}
+	if err != nil {
+		r.logger.Error("failed to create price change", "error", err, "subscription_id", c.SubscriptionID)
+		return err
+	}
+
+	r.logger.Info("price change scheduled in repository", "id", c.ID)
+
+	return nil
+}
+
+// Delete removes a price change that was not applied yet.
+func (r *PriceChangeRepository) Delete(ctx context.Context, subscriptionID, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`DELETE FROM scheduled_price_changes
+	 WHERE id = $1 AND subscription_id = $2 AND applied_at IS NULL`, id, subscriptionID)
+	if err != nil {
+		r.logger.Error("failed to delete price change", "error", err, "id", id)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrPriceChangeNotFound
+	}
+
+	r.logger.Info("price change deleted in repository", "id", id)
+
+	return nil
+}
+
+// List returns the price changes of the subscription, by effective date.
+func (r *PriceChangeRepository) List(ctx context.Context, subscriptionID uuid.UUID) ([]*model.ScheduledPriceChange, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+priceChangeColumns+`
+	 FROM scheduled_price_changes
+	 WHERE subscription_id = $1
+	 ORDER BY effective_date`, subscriptionID)
+	if err != nil {
+		r.logger.Error("failed to select price changes", "error", err, "subscription_id", subscriptionID)
+		return nil, err
+	}
+
+	return r.collectPriceChanges(rows)
+}
+
+// ListBySubscriptions returns the price changes of the subscriptions, by
+// effective date.
+func (r *PriceChangeRepository) ListBySubscriptions(ctx context.Context, subscriptionIDs []uuid.UUID) ([]*model.ScheduledPriceChange, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+priceChangeColumns+`
+	 FROM scheduled_price_changes
+	 WHERE subscription_id = any($1)
+	 ORDER BY effective_date, id`, subscriptionIDs)
+	if err != nil {
+		r.logger.Error("failed to select price changes", "error", err, "subscriptions", len(subscriptionIDs))
+		return nil, err
+	}
+
+	return r.collectPriceChanges(rows)
+}
+
+// ListPendingByUser returns the price changes not applied yet of the live
+// subscriptions the user owns or is a member of, by effective date.
+func (r *PriceChangeRepository) ListPendingByUser(ctx context.Context, userID uuid.UUID) ([]*model.ScheduledPriceChange, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT c.id, c.subscription_id, c.effective_date, c.price, c.created_at, c.applied_at, c.previous_price
+	 FROM scheduled_price_changes c
+	 JOIN subscriptions s ON s.id = c.subscription_id
+	 WHERE (s.user_id = $1 OR EXISTS (
+	        SELECT 1 FROM subscription_members m WHERE m.subscription_id = s.id AND m.user_id = $1))
+	   AND s.deleted_at IS NULL AND c.applied_at IS NULL
+	 ORDER BY c.effective_date`, userID)
+	if err != nil {
+		r.logger.Error("failed to select pending price changes", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return r.collectPriceChanges(rows)
+}
+
+// LockDue locks up to limit price changes of live subscriptions that take
+// effect at or before now and were not applied, skipping those locked by
+// other workers. Must run in a transaction.
+func (r *PriceChangeRepository) LockDue(ctx context.Context, now time.Time, limit int) ([]*model.ScheduledPriceChange, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT c.id, c.subscription_id, c.effective_date, c.price, c.created_at, c.applied_at, c.previous_price
+	 FROM scheduled_price_changes c
+	 JOIN subscriptions s ON s.id = c.subscription_id
+	 WHERE c.effective_date <= $1 AND c.applied_at IS NULL AND s.deleted_at IS NULL
+	 ORDER BY c.effective_date
+	 LIMIT $2
+	 FOR UPDATE OF c SKIP LOCKED`, now, limit)
+	if err != nil {
+		r.logger.Error("failed to select due price changes", "error", err)
+		return nil, err
+	}
+
+	return r.collectPriceChanges(rows)
+}
+
+// MarkApplied records that the price change replaced previousPrice.
+func (r *PriceChangeRepository) MarkApplied(ctx context.Context, id uuid.UUID, previousPrice int) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE scheduled_price_changes SET applied_at = now(), previous_price = $2 WHERE id = $1`,
+		id, previousPrice)
+	if err != nil {
+		r.logger.Error("failed to mark price change applied", "error", err, "id", id)
+	}
+
+	return err
+}
+
+func (r *PriceChangeRepository) collectPriceChanges(rows pgx.Rows) ([]*model.ScheduledPriceChange, error) {
+	defer rows.Close()
+
+	changes := make([]*model.ScheduledPriceChange, 0)
+
+	for rows.Next() {
+		var c model.ScheduledPriceChange
+		if err := rows.Scan(
+			&c.ID,
+			&c.SubscriptionID,
+			&c.EffectiveDate,
+			&c.Price,
+			&c.CreatedAt,
+			&c.AppliedAt,
+			&c.PreviousPrice,
+		); err != nil {
+			r.logger.Error("failed to scan price change row", "error", err)
+			return nil, err
+		}
+		changes = append(changes, &c)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during price change rows iteration", "error", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
@@ -3,9 +3,14 @@ package repository
 import (
 	"github.com/Lirohop/App/internal/model"
 	"context"
+	"errors"
 	"log/slog"
+	"slices"
+	"time"
 
 	. "github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -14,20 +19,67 @@ type SubscriptionRepository struct {
 	logger *slog.Logger
 }
 
+// subscriptionColumns is the column list scanned by scanSubscription. It
+// must be selected from the subscriptions table without an alias.
+const subscriptionColumns = `id, service_id, service_name, price, user_id, start_date, end_date, trial_end, trial_price,
+	 (SELECT coalesce(jsonb_agg(jsonb_build_object(
+	         'id', p.id, 'start_date', p.start_date, 'resume_date', p.resume_date) ORDER BY p.start_date), '[]')
+	  FROM subscription_pauses p WHERE p.subscription_id = subscriptions.id),
+	 (SELECT coalesce(jsonb_agg(jsonb_build_object(
+	         'user_id', m.user_id, 'weight', m.weight, 'amount', m.amount) ORDER BY m.created_at, m.user_id), '[]')
+	  FROM subscription_members m WHERE m.subscription_id = subscriptions.id),
+	 category_id,
+	 ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+	       WHERE st.subscription_id = subscriptions.id ORDER BY t.name),
+	 version, deleted_at, overlap_allowed,
+	 (SELECT coalesce(jsonb_agg(jsonb_build_object(
+	         'price', c.previous_price, 'until', c.effective_date) ORDER BY c.effective_date), '[]')
+	  FROM scheduled_price_changes c
+	  WHERE c.subscription_id = subscriptions.id AND c.previous_price IS NOT NULL)`
+
+// pauseRow is a pause as aggregated by subscriptionColumns.
+type pauseRow struct {
+	ID         UUID    `json:"id"`
+	StartDate  string  `json:"start_date"`
+	ResumeDate *string `json:"resume_date"`
+}
+
+// pricePeriodRow is a price period as aggregated by subscriptionColumns.
+type pricePeriodRow struct {
+	Price int    `json:"price"`
+	Until string `json:"until"`
+}
+
 func NewSubscriptionRepository(db *pgxpool.Pool, logger *slog.Logger) *SubscriptionRepository {
 	return &SubscriptionRepository{db: db, logger: logger}
 }
 
 func (r *SubscriptionRepository) Create(ctx context.Context, s *model.Subscription) error {
-	err := r.db.QueryRow(ctx,
-		`INSERT INTO subscriptions(service_name, price, user_id, start_date, end_date)
-         VALUES($1, $2, $3, $4, $5)
-         RETURNING id`,
-		s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate).Scan(&s.ID)
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		err := q.QueryRow(ctx,
+			`INSERT INTO subscriptions(service_id, service_name, price, user_id, start_date, end_date, trial_end,
+             trial_price, category_id, overlap_allowed)
+         VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+         RETURNING id, version`,
+			s.ServiceID, s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate, s.TrialEnd, s.TrialPrice,
+			s.CategoryID, s.OverlapAllowed,
+		).Scan(&s.ID, &s.Version)
+		if err != nil {
+			return err
+		}
+
+		if err := replaceTags(ctx, q, s.ID, s.Tags); err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, q, model.AuditActionCreate, nil, s)
+	})
 
 	if err != nil {
 		r.logger.Error("failed to create subscription", "error", err, "user_id", s.UserId)
-		return err
+		return subscriptionError(err)
 	}
 
 	r.logger.Info("subscription created in repository", "id", s.ID)
@@ -35,9 +87,47 @@ func (r *SubscriptionRepository) Create(ctx context.Context, s *model.Subscripti
 	return nil
 }
 
+// Delete soft-deletes the subscription; it stays in the trash until Restore
+// or PurgeDeleted.
 func (r *SubscriptionRepository) Delete(ctx context.Context, id UUID) error {
-	_, err := r.db.Exec(ctx,
-		`DELETE FROM subscriptions WHERE id = $1`, id)
+	return r.delete(ctx, id, nil)
+}
+
+// DeleteIfVersion deletes the subscription only if its stored version is one
+// of versions, reporting mismatches like UpdateIfVersion.
+func (r *SubscriptionRepository) DeleteIfVersion(ctx context.Context, id UUID, versions []int) error {
+	return r.delete(ctx, id, func(before *model.Subscription) error {
+		return checkVersion(before, versions)
+	})
+}
+
+func (r *SubscriptionRepository) delete(ctx context.Context, id UUID, check func(*model.Subscription) error) error {
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		before, err := lockSubscription(ctx, q, id, false)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(before); err != nil {
+				return err
+			}
+		}
+
+		after, err := scanSubscription(q.QueryRow(ctx,
+			`UPDATE subscriptions
+         SET deleted_at = now(),
+             version = version + 1
+         WHERE id = $1
+         RETURNING `+subscriptionColumns, id))
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, q, model.AuditActionDelete, before, after)
+	})
+
 	if err != nil {
 		r.logger.Error("failed to delete subscription", "error", err, "id", id)
 		return err
@@ -48,49 +138,377 @@ func (r *SubscriptionRepository) Delete(ctx context.Context, id UUID) error {
 	return nil
 }
 
+func (r *SubscriptionRepository) Restore(ctx context.Context, id UUID) (*model.Subscription, error) {
+	var after *model.Subscription
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		before, err := lockSubscription(ctx, q, id, true)
+		if err != nil {
+			return err
+		}
+		if before.DeletedAt == nil {
+			return model.ErrNotFound
+		}
+
+		after, err = scanSubscription(q.QueryRow(ctx,
+			`UPDATE subscriptions
+         SET deleted_at = NULL,
+             version = version + 1
+         WHERE id = $1
+         RETURNING `+subscriptionColumns, id))
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, q, model.AuditActionRestore, before, after)
+	})
+
+	if err != nil {
+		r.logger.Error("failed to restore subscription", "error", err, "id", id)
+		return nil, subscriptionError(err)
+	}
+
+	r.logger.Info("subscription was restored in repository", "id", id)
+
+	return after, nil
+}
+
+// PurgeDeleted removes subscriptions that were soft-deleted before the given time.
+func (r *SubscriptionRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		rows, err := q.Query(ctx,
+			`DELETE FROM subscriptions WHERE deleted_at < $1
+         RETURNING `+subscriptionColumns, before)
+		if err != nil {
+			return err
+		}
+
+		subs, err := r.collectSubscriptions(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range subs {
+			if err := insertAudit(ctx, q, model.AuditActionPurge, s, nil); err != nil {
+				return err
+			}
+		}
+
+		purged = int64(len(subs))
+		return nil
+	})
+
+	if err != nil {
+		r.logger.Error("failed to purge deleted subscriptions", "error", err)
+		return 0, err
+	}
+
+	r.logger.Info("deleted subscriptions purged", "count", purged)
+
+	return purged, nil
+}
+
+// Update replaces the stored subscription with s, and then s with the
+// stored result, keeping its Overlaps.
 func (r *SubscriptionRepository) Update(
 	ctx context.Context,
 	s *model.Subscription,
 ) error {
-	_, err := r.db.Exec(ctx,
-		`UPDATE subscriptions
+	return r.update(ctx, s, nil)
+}
+
+// UpdateIfVersion updates the subscription only if its stored version is one
+// of versions. Otherwise it returns *model.VersionConflictError, or
+// model.ErrNotFound if the subscription does not exist.
+func (r *SubscriptionRepository) UpdateIfVersion(
+	ctx context.Context,
+	s *model.Subscription,
+	versions []int,
+) error {
+	return r.update(ctx, s, func(before *model.Subscription) error {
+		return checkVersion(before, versions)
+	})
+}
+
+func (r *SubscriptionRepository) update(
+	ctx context.Context,
+	s *model.Subscription,
+	check func(*model.Subscription) error,
+) error {
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		before, err := lockSubscription(ctx, q, s.ID, false)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(before); err != nil {
+				return err
+			}
+		}
+
+		if err := replaceTags(ctx, q, s.ID, s.Tags); err != nil {
+			return err
+		}
+
+		after, err := scanSubscription(q.QueryRow(ctx, updateSubscriptionSQL, updateSubscriptionArgs(s)...))
+		if err != nil {
+			return err
+		}
+		after.Overlaps = s.Overlaps
+		*s = *after
+
+		return insertAudit(ctx, q, model.AuditActionUpdate, before, s)
+	})
+
+	if err != nil {
+		r.logger.Error("failed to update subscription", "error", err, "id", s.ID)
+		return subscriptionError(err)
+	}
+
+	r.logger.Info("subscription was updated in repository", "id", s.ID, "version", s.Version)
+
+	return nil
+}
+
+// updateSubscriptionSQL writes the fields of a subscription, given by
+// updateSubscriptionArgs, and returns the result.
+const updateSubscriptionSQL = `UPDATE subscriptions
          SET service_name = $1,
              price = $2,
              user_id = $3,
              start_date = $4,
-             end_date = $5
-         WHERE id = $6`,
+             end_date = $5,
+             trial_end = $6,
+             trial_price = $7,
+             trial_converted_at = CASE WHEN trial_end IS DISTINCT FROM $6
+                                       THEN NULL ELSE trial_converted_at END,
+             service_id = $9,
+             category_id = $10,
+             overlap_allowed = $11,
+             version = version + 1
+         WHERE id = $8
+         RETURNING ` + subscriptionColumns
+
+func updateSubscriptionArgs(s *model.Subscription) []any {
+	return []any{
 		s.ServiceName,
 		s.Price,
 		s.UserId,
 		s.StartDate,
 		s.EndDate,
+		s.TrialEnd,
+		s.TrialPrice,
 		s.ID,
-	)
+		s.ServiceID,
+		s.CategoryID,
+		s.OverlapAllowed,
+	}
+}
+
+// AddPause adds the pause to the subscription. The caller checks that it
+// does not overlap the other pauses while holding the lock of GetForUpdate.
+func (r *SubscriptionRepository) AddPause(ctx context.Context, id UUID, p *model.Pause) (*model.Subscription, error) {
+	return r.changeRelated(ctx, id, model.AuditActionPause, func(ctx context.Context, q querier) error {
+		return q.QueryRow(ctx,
+			`INSERT INTO subscription_pauses(subscription_id, start_date, resume_date)
+         VALUES($1, $2, $3)
+         RETURNING id`,
+			id, p.StartDate, p.ResumeDate).Scan(&p.ID)
+	})
+}
+
+// ResumePause sets the month in which the subscription is charged again
+// after the pause.
+func (r *SubscriptionRepository) ResumePause(ctx context.Context, id, pauseID UUID, resume time.Time) (*model.Subscription, error) {
+	return r.changeRelated(ctx, id, model.AuditActionResume, func(ctx context.Context, q querier) error {
+		_, err := q.Exec(ctx,
+			`UPDATE subscription_pauses SET resume_date = $3
+         WHERE id = $2 AND subscription_id = $1`, id, pauseID, resume)
+		return err
+	})
+}
+
+// DeletePause removes a pause that has not started yet.
+func (r *SubscriptionRepository) DeletePause(ctx context.Context, id, pauseID UUID) (*model.Subscription, error) {
+	return r.changeRelated(ctx, id, model.AuditActionResume, func(ctx context.Context, q querier) error {
+		_, err := q.Exec(ctx,
+			`DELETE FROM subscription_pauses WHERE id = $2 AND subscription_id = $1`, id, pauseID)
+		return err
+	})
+}
+
+// changeRelated runs change, which writes the pauses or members of the
+// subscription, and bumps its version so that its ETag reflects them,
+// recording both in the audit log.
+func (r *SubscriptionRepository) changeRelated(
+	ctx context.Context,
+	id UUID,
+	action string,
+	change func(context.Context, querier) error,
+) (*model.Subscription, error) {
+	var after *model.Subscription
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		before, err := lockSubscription(ctx, q, id, false)
+		if err != nil {
+			return err
+		}
+
+		if err := change(ctx, q); err != nil {
+			return err
+		}
+
+		after, err = scanSubscription(q.QueryRow(ctx,
+			`UPDATE subscriptions
+         SET version = version + 1
+         WHERE id = $1
+         RETURNING `+subscriptionColumns, id))
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, q, action, before, after)
+	})
 
 	if err != nil {
-		r.logger.Error("failed to update subscription", "error", err, "id", s.ID)
+		r.logger.Error("failed to change subscription", "error", err, "id", id, "action", action)
+		return nil, err
+	}
+
+	r.logger.Info("subscription changed in repository", "id", id, "action", action)
+
+	return after, nil
+}
+
+// AddMember adds a member to the subscription. The caller checks the share
+// while holding the lock of GetForUpdate.
+func (r *SubscriptionRepository) AddMember(ctx context.Context, id UUID, m model.Member) (*model.Subscription, error) {
+	sub, err := r.changeRelated(ctx, id, model.AuditActionAddMember, func(ctx context.Context, q querier) error {
+		_, err := q.Exec(ctx,
+			`INSERT INTO subscription_members(subscription_id, user_id, weight, amount)
+         VALUES($1, $2, $3, $4)`,
+			id, m.UserID, m.Weight, m.Amount)
 		return err
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return nil, model.ErrMemberExists
 	}
 
-	r.logger.Info("subscription was updated in repository", "id", s.ID)
+	return sub, err
+}
 
-	return nil
+// RemoveMember removes a member from the subscription.
+func (r *SubscriptionRepository) RemoveMember(ctx context.Context, id, userID UUID) (*model.Subscription, error) {
+	return r.changeRelated(ctx, id, model.AuditActionRemoveMember, func(ctx context.Context, q querier) error {
+		tag, err := q.Exec(ctx,
+			`DELETE FROM subscription_members WHERE subscription_id = $1 AND user_id = $2`, id, userID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return model.ErrMemberNotFound
+		}
+		return nil
+	})
+}
+
+// GetForUpdate returns the live subscription and locks it until the
+// transaction in ctx ends. It must be called within Transactor.WithinTx.
+func (r *SubscriptionRepository) GetForUpdate(ctx context.Context, id UUID) (*model.Subscription, error) {
+	s, err := lockSubscription(ctx, conn(ctx, r.db), id, false)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		r.logger.Error("failed to lock subscription", "error", err, "id", id)
+	}
+
+	return s, err
 }
 
-func (r *SubscriptionRepository) GetByID(ctx context.Context, id UUID) (*model.Subscription, error) {
-	var s model.Subscription
+// GetManyForUpdate returns the live subscriptions among ids by id and locks
+// them like GetForUpdate. Missing ids are left out of the result.
+func (r *SubscriptionRepository) GetManyForUpdate(ctx context.Context, ids []UUID) (map[UUID]*model.Subscription, error) {
+	subs, err := r.lockSubscriptions(ctx, conn(ctx, r.db), ids)
+	if err != nil {
+		r.logger.Error("failed to lock subscriptions", "error", err, "count", len(ids))
+		return nil, err
+	}
 
-	err := r.db.QueryRow(ctx,
-		`SELECT id, service_name, price, user_id, start_date, end_date
+	return subs, nil
+}
+
+// lockSubscriptions is lockSubscription for several live subscriptions.
+// Rows are locked in id order, so that two batches locking the same rows
+// do not deadlock.
+func (r *SubscriptionRepository) lockSubscriptions(ctx context.Context, q querier, ids []UUID) (map[UUID]*model.Subscription, error) {
+	rows, err := q.Query(ctx,
+		`SELECT `+subscriptionColumns+`
 	 From subscriptions
-	 Where id=$1`, id).Scan(
-		&s.ID,
-		&s.ServiceName,
-		&s.Price, &s.UserId,
-		&s.StartDate,
-		&s.EndDate)
+	 Where id = any($1) and deleted_at is null
+	 Order by id
+	 For update`, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := r.collectSubscriptions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[UUID]*model.Subscription, len(subs))
+	for _, s := range subs {
+		byID[s.ID] = s
+	}
+
+	return byID, nil
+}
+
+// lockSubscription reads the subscription FOR UPDATE so that it can be
+// changed and audited within the same transaction.
+func lockSubscription(ctx context.Context, q querier, id UUID, includeDeleted bool) (*model.Subscription, error) {
+	s, err := scanSubscription(q.QueryRow(ctx,
+		`SELECT `+subscriptionColumns+`
+	 From subscriptions
+	 Where id=$1 and ($2 or deleted_at is null)
+	 For update`, id, includeDeleted))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrNotFound
+	}
 
+	return s, err
+}
+
+func checkVersion(s *model.Subscription, versions []int) error {
+	if slices.Contains(versions, s.Version) {
+		return nil
+	}
+
+	return &model.VersionConflictError{ID: s.ID, Expected: versions, Actual: s.Version}
+}
+
+// GetByID returns the subscription unless it is soft-deleted and
+// includeDeleted is false.
+func (r *SubscriptionRepository) GetByID(ctx context.Context, id UUID, includeDeleted bool) (*model.Subscription, error) {
+	s, err := scanSubscription(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+subscriptionColumns+`
+	 From subscriptions
+	 Where id=$1 and ($2 or deleted_at is null)`, id, includeDeleted))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrNotFound
+	}
 	if err != nil {
 		r.logger.Error("failed to find subscription by id", "error", err, "id", id)
 		return nil, err
@@ -98,75 +516,192 @@ func (r *SubscriptionRepository) GetByID(ctx context.Context, id UUID) (*model.S
 
 	r.logger.Info("subscription finded by id", "id", s.ID)
 
-	return &s, nil
+	return s, nil
 }
 
 func (r *SubscriptionRepository) GetListByUserID(ctx context.Context, userId UUID) ([]*model.Subscription, error) {
 
-	rows, err := r.db.Query(ctx,
-		`SELECT id, service_name, price, user_id, start_date, end_date 
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+subscriptionColumns+`
 		From subscriptions
-	 	Where user_id=$1`, userId)
+	 	Where user_id=$1 and deleted_at is null`, userId)
 
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	subs := make([]*model.Subscription, 0)
+	return r.collectSubscriptions(rows)
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
+	rows, err := r.query(ctx, filter)
+	if err != nil {
+		r.logger.Error("failed to select subscriptions for list", "error", err)
+		return nil, err
+	}
+
+	return r.collectSubscriptions(rows)
+}
 
+// Stream calls fn with every subscription List would return, in the same
+// order. Rows are decoded one at a time as they arrive from the
+// connection, so memory does not grow with the number of subscriptions.
+func (r *SubscriptionRepository) Stream(
+	ctx context.Context,
+	filter model.SubscriptionFilter,
+	fn func(*model.Subscription) error,
+) error {
+	rows, err := r.query(ctx, filter)
+	if err != nil {
+		r.logger.Error("failed to select subscriptions for stream", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	count := 0
 	for rows.Next() {
-		var s model.Subscription
-		if err := rows.Scan(
-			&s.ID,
-			&s.ServiceName,
-			&s.Price,
-			&s.UserId,
-			&s.StartDate,
-			&s.EndDate,
-		); err != nil {
+		s, err := scanSubscription(rows)
+		if err != nil {
 			r.logger.Error("failed to scan subscription row", "error", err)
-			return nil, err
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
 		}
-		subs = append(subs, &s)
+		count++
 	}
 
 	if err := rows.Err(); err != nil {
 		r.logger.Error("error during subscriptions rows iteration", "error", err)
+		return err
+	}
+
+	r.logger.Info("subscriptions successfully streamed", "count", count)
+
+	return nil
+}
+
+func (r *SubscriptionRepository) query(ctx context.Context, filter model.SubscriptionFilter) (pgx.Rows, error) {
+	return conn(ctx, r.db).Query(ctx,
+		`SELECT `+subscriptionColumns+` FROM subscriptions
+	 Where ($1 or deleted_at is null)
+	   and ($2::uuid is null or user_id = $2)
+	   and ($3::uuid is null or category_id = $3)
+	   and coalesce(cardinality($4::text[]), 0) = (
+	       select count(*) from subscription_tags st join tags t on t.id = st.tag_id
+	       where st.subscription_id = subscriptions.id and t.name = any($4))
+	   and ($5::uuid is null or user_id = $5 or exists (
+	       select 1 from subscription_members m
+	       where m.subscription_id = subscriptions.id and m.user_id = $5))
+	   and (not $6 or deleted_at is not null)
+	 Order by start_date, id`,
+		filter.IncludeDeleted || filter.OnlyDeleted, filter.UserID, filter.CategoryID, filter.Tags, filter.MemberID,
+		filter.OnlyDeleted)
+}
+
+// ListByMembers returns the live subscriptions that any of the users owns
+// or is a member of, like List with MemberID for several users at once.
+func (r *SubscriptionRepository) ListByMembers(ctx context.Context, userIDs []UUID) ([]*model.Subscription, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+subscriptionColumns+`
+	 FROM subscriptions
+	 WHERE deleted_at IS NULL
+	   AND (user_id = any($1) OR EXISTS (
+	       SELECT 1 FROM subscription_members m
+	       WHERE m.subscription_id = subscriptions.id AND m.user_id = any($1)))
+	 ORDER BY start_date, id`, userIDs)
+
+	if err != nil {
+		r.logger.Error("failed to select subscriptions of members", "error", err, "users", len(userIDs))
 		return nil, err
 	}
 
-	r.logger.Info("subscriptions successfully fetched", "count", len(subs))
+	return r.collectSubscriptions(rows)
+}
 
-	return subs, nil
+// ListTrialsEnding returns live subscriptions whose trial ends in [from, to],
+// soonest first.
+func (r *SubscriptionRepository) ListTrialsEnding(ctx context.Context, from, to time.Time) ([]*model.Subscription, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+subscriptionColumns+`
+	 FROM subscriptions
+	 WHERE trial_end BETWEEN $1 AND $2 AND deleted_at IS NULL
+	 ORDER BY trial_end, id`, from, to)
+
+	if err != nil {
+		r.logger.Error("failed to select subscriptions with ending trials", "error", err)
+		return nil, err
+	}
+
+	return r.collectSubscriptions(rows)
 }
 
-func (r *SubscriptionRepository) List(ctx context.Context) ([]*model.Subscription, error) {
-	rows, err := r.db.Query(ctx,
-		`SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions`)
+// LockEndedTrials locks up to limit live subscriptions whose trial ended at
+// or before now and was not handled yet, skipping those locked by other
+// workers. Must run in a transaction.
+func (r *SubscriptionRepository) LockEndedTrials(ctx context.Context, now time.Time, limit int) ([]*model.Subscription, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+subscriptionColumns+`
+	 FROM subscriptions
+	 WHERE trial_end <= $1 AND trial_converted_at IS NULL AND deleted_at IS NULL
+	 ORDER BY trial_end
+	 LIMIT $2
+	 FOR UPDATE SKIP LOCKED`, now, limit)
 
 	if err != nil {
-		r.logger.Error("failed to select subscriptions for list", "error", err)
+		r.logger.Error("failed to select subscriptions with ended trials", "error", err)
 		return nil, err
 	}
+
+	return r.collectSubscriptions(rows)
+}
+
+// MarkTrialHandled records that the end of the subscription's current trial
+// was handled. Changing trial_end clears the mark.
+func (r *SubscriptionRepository) MarkTrialHandled(ctx context.Context, id UUID) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE subscriptions SET trial_converted_at = now() WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to mark trial handled", "error", err, "id", id)
+	}
+
+	return err
+}
+
+// replaceTags sets the tags of the subscription, creating missing ones.
+func replaceTags(ctx context.Context, q querier, id UUID, tags []string) error {
+	if _, err := q.Exec(ctx, `DELETE FROM subscription_tags WHERE subscription_id = $1`, id); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := q.Exec(ctx,
+		`INSERT INTO tags(name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, tags)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(ctx,
+		`INSERT INTO subscription_tags(subscription_id, tag_id)
+         SELECT $1, id FROM tags WHERE name = any($2)`, id, tags)
+	return err
+}
+
+func (r *SubscriptionRepository) collectSubscriptions(rows pgx.Rows) ([]*model.Subscription, error) {
 	defer rows.Close()
 
 	subs := make([]*model.Subscription, 0)
 
 	for rows.Next() {
-		var s model.Subscription
-		if err := rows.Scan(
-			&s.ID,
-			&s.ServiceName,
-			&s.Price,
-			&s.UserId,
-			&s.StartDate,
-			&s.EndDate,
-		); err != nil {
+		s, err := scanSubscription(rows)
+		if err != nil {
 			r.logger.Error("failed to scan subscription row", "error", err)
 			return nil, err
 		}
-		subs = append(subs, &s)
+		subs = append(subs, s)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -179,36 +714,344 @@ func (r *SubscriptionRepository) List(ctx context.Context) ([]*model.Subscriptio
 	return subs, nil
 }
 
-func (r *SubscriptionRepository) GetByUserAndService(ctx context.Context, userId UUID, serviceName string) (*model.Subscription, error) {
-	var s model.Subscription
+func scanSubscription(row pgx.Row) (*model.Subscription, error) {
+	var (
+		s      model.Subscription
+		pauses []pauseRow
+		prices []pricePeriodRow
+	)
 
-	err := r.db.QueryRow(ctx,
-		`SELECT id, service_name, price, user_id, start_date, end_date
-	 From subscriptions
-	 Where user_id=$1 and service_name=$2`, userId, serviceName).Scan(
+	err := row.Scan(
 		&s.ID,
+		&s.ServiceID,
 		&s.ServiceName,
 		&s.Price,
 		&s.UserId,
 		&s.StartDate,
-		&s.EndDate)
+		&s.EndDate,
+		&s.TrialEnd,
+		&s.TrialPrice,
+		&pauses,
+		&s.Members,
+		&s.CategoryID,
+		&s.Tags,
+		&s.Version,
+		&s.DeletedAt,
+		&s.OverlapAllowed,
+		&prices,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Pauses = make([]model.Pause, len(pauses))
+	for i, p := range pauses {
+		s.Pauses[i].ID = p.ID
+		if s.Pauses[i].StartDate, err = time.Parse(time.DateOnly, p.StartDate); err != nil {
+			return nil, err
+		}
+		if p.ResumeDate != nil {
+			resume, err := time.Parse(time.DateOnly, *p.ResumeDate)
+			if err != nil {
+				return nil, err
+			}
+			s.Pauses[i].ResumeDate = &resume
+		}
+	}
+
+	s.PricePeriods = make([]model.PricePeriod, len(prices))
+	for i, p := range prices {
+		s.PricePeriods[i].Price = p.Price
+		if s.PricePeriods[i].Until, err = time.Parse(time.DateOnly, p.Until); err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+// CreateMany stores the subscriptions like Create. The statements for all
+// of them are sent as pgx batches, so the number of round trips does not
+// grow with the number of subscriptions.
+func (r *SubscriptionRepository) CreateMany(ctx context.Context, subs []*model.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		b := &pgx.Batch{}
+		queueTagNames(b, subs)
+		for _, s := range subs {
+			b.Queue(
+				`INSERT INTO subscriptions(service_id, service_name, price, user_id, start_date, end_date, trial_end,
+             trial_price, category_id, overlap_allowed)
+         VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+         RETURNING id, version`,
+				s.ServiceID, s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate, s.TrialEnd, s.TrialPrice,
+				s.CategoryID, s.OverlapAllowed,
+			).QueryRow(func(row pgx.Row) error {
+				return row.Scan(&s.ID, &s.Version)
+			})
+		}
+		if err := q.SendBatch(ctx, b).Close(); err != nil {
+			return err
+		}
+
+		b = &pgx.Batch{}
+		queueSubscriptionTags(b, subs)
+		for _, s := range subs {
+			if err := queueAudit(ctx, b, model.AuditActionCreate, nil, s); err != nil {
+				return err
+			}
+		}
+		return q.SendBatch(ctx, b).Close()
+	})
+
+	if err != nil {
+		r.logger.Error("failed to create subscriptions", "error", err, "count", len(subs))
+		return subscriptionError(err)
+	}
+
+	r.logger.Info("subscriptions created in repository", "count", len(subs))
+
+	return nil
+}
+
+// UpdateMany is Update for several subscriptions, sent as pgx batches like
+// CreateMany. Each subscription is replaced by the stored result, keeping
+// its Overlaps.
+func (r *SubscriptionRepository) UpdateMany(ctx context.Context, subs []*model.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+
+	ids := make([]UUID, len(subs))
+	for i, s := range subs {
+		ids[i] = s.ID
+	}
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		befores, err := r.lockSubscriptions(ctx, q, ids)
+		if err != nil {
+			return err
+		}
+
+		b := &pgx.Batch{}
+		b.Queue(`DELETE FROM subscription_tags WHERE subscription_id = any($1)`, ids)
+		queueTagNames(b, subs)
+		queueSubscriptionTags(b, subs)
+		for _, s := range subs {
+			if befores[s.ID] == nil {
+				return model.ErrNotFound
+			}
+
+			b.Queue(updateSubscriptionSQL, updateSubscriptionArgs(s)...).QueryRow(func(row pgx.Row) error {
+				after, err := scanSubscription(row)
+				if err != nil {
+					return err
+				}
+				after.Overlaps = s.Overlaps
+				*s = *after
+				return nil
+			})
+		}
+		if err := q.SendBatch(ctx, b).Close(); err != nil {
+			return err
+		}
+
+		b = &pgx.Batch{}
+		for _, s := range subs {
+			if err := queueAudit(ctx, b, model.AuditActionUpdate, befores[s.ID], s); err != nil {
+				return err
+			}
+		}
+		return q.SendBatch(ctx, b).Close()
+	})
 
 	if err != nil {
-		r.logger.Error(
-			"failed to get subscription by user and service",
-			"user_id", userId,
-			"service_name", serviceName,
-			"error", err,
-		)
+		r.logger.Error("failed to update subscriptions", "error", err, "count", len(subs))
+		return subscriptionError(err)
+	}
+
+	r.logger.Info("subscriptions updated in repository", "count", len(subs))
+
+	return nil
+}
+
+// DeleteMany soft-deletes the subscriptions like Delete, in one statement.
+func (r *SubscriptionRepository) DeleteMany(ctx context.Context, ids []UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := withinTx(ctx, r.db, func(ctx context.Context) error {
+		q := conn(ctx, r.db)
+
+		befores, err := r.lockSubscriptions(ctx, q, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if befores[id] == nil {
+				return model.ErrNotFound
+			}
+		}
+
+		rows, err := q.Query(ctx,
+			`UPDATE subscriptions
+         SET deleted_at = now(),
+             version = version + 1
+         WHERE id = any($1)
+         RETURNING `+subscriptionColumns, ids)
+		if err != nil {
+			return err
+		}
+
+		afters, err := r.collectSubscriptions(rows)
+		if err != nil {
+			return err
+		}
+
+		b := &pgx.Batch{}
+		for _, after := range afters {
+			if err := queueAudit(ctx, b, model.AuditActionDelete, befores[after.ID], after); err != nil {
+				return err
+			}
+		}
+		return q.SendBatch(ctx, b).Close()
+	})
+
+	if err != nil {
+		r.logger.Error("failed to delete subscriptions", "error", err, "count", len(ids))
+		return err
+	}
+
+	r.logger.Info("subscriptions deleted in repository", "count", len(ids))
+
+	return nil
+}
+
+// ListOverlapping returns the ids of the live subscriptions of the user of
+// s to the same service that share a month with s, apart from s itself.
+func (r *SubscriptionRepository) ListOverlapping(ctx context.Context, s *model.Subscription) ([]UUID, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT id
+	 FROM subscriptions
+	 WHERE user_id = $1
+	   AND service_id = $2
+	   AND id <> $3
+	   AND deleted_at IS NULL
+	   AND subscription_months(start_date, end_date) && subscription_months($4, $5)
+	 ORDER BY start_date, id`, s.UserId, s.ServiceID, s.ID, s.StartDate, s.EndDate)
+	if err != nil {
+		r.logger.Error("failed to find overlapping subscriptions", "error", err, "id", s.ID)
+		return nil, err
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[UUID])
+	if err != nil {
+		r.logger.Error("failed to scan overlapping subscriptions", "error", err, "id", s.ID)
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ListOverlaps returns every pair of live subscriptions of the user to the
+// same service that share a month.
+func (r *SubscriptionRepository) ListOverlaps(ctx context.Context, userID UUID) ([]*model.Overlap, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT a.service_id, a.service_name, a.id, b.id,
+	        greatest(a.start_date, b.start_date), least(a.end_date, b.end_date)
+	 FROM subscriptions a
+	 JOIN subscriptions b
+	   ON b.user_id = a.user_id
+	  AND b.service_id = a.service_id
+	  AND b.id > a.id
+	  AND b.deleted_at IS NULL
+	 WHERE a.user_id = $1
+	   AND a.deleted_at IS NULL
+	   AND subscription_months(a.start_date, a.end_date) && subscription_months(b.start_date, b.end_date)
+	 ORDER BY a.service_name, 5, a.id, b.id`, userID)
+	if err != nil {
+		r.logger.Error("failed to select overlaps", "error", err, "user_id", userID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	overlaps := make([]*model.Overlap, 0)
+
+	for rows.Next() {
+		var o model.Overlap
+		if err := rows.Scan(
+			&o.ServiceID,
+			&o.ServiceName,
+			&o.SubscriptionIDs[0],
+			&o.SubscriptionIDs[1],
+			&o.StartDate,
+			&o.EndDate,
+		); err != nil {
+			r.logger.Error("failed to scan overlap row", "error", err)
+			return nil, err
+		}
+		overlaps = append(overlaps, &o)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during overlap rows iteration", "error", err)
 		return nil, err
 	}
 
-	r.logger.Info(
-		"subscription found",
-		"user_id", userId,
-		"service_name", serviceName,
-		"subscription_id", s.ID,
+	return overlaps, nil
+}
+
+// subscriptionError translates constraint violations of the subscriptions
+// table.
+func subscriptionError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgExclusionViolation {
+		return model.ErrSubscriptionOverlap
+	}
+	return err
+}
+
+// queueTagNames adds the tags of subs to the tags table.
+func queueTagNames(b *pgx.Batch, subs []*model.Subscription) {
+	var names []string
+	for _, s := range subs {
+		names = append(names, s.Tags...)
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	b.Queue(`INSERT INTO tags(name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`, names)
+}
+
+// queueSubscriptionTags links subs to their tags, which must exist by the
+// time the statement runs. Links are not replaced; see replaceTags.
+func queueSubscriptionTags(b *pgx.Batch, subs []*model.Subscription) {
+	var (
+		ids   []UUID
+		names []string
 	)
+	for _, s := range subs {
+		for _, tag := range s.Tags {
+			ids = append(ids, s.ID)
+			names = append(names, tag)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
 
-	return &s, nil
+	b.Queue(
+		`INSERT INTO subscription_tags(subscription_id, tag_id)
+         SELECT v.subscription_id, t.id
+         FROM unnest($1::uuid[], $2::text[]) AS v(subscription_id, name)
+         JOIN tags t ON t.name = v.name`, ids, names)
 }
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	webhookColumns  = `id, url, secret, event_types, user_id, created_at, updated_at`
+	deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	 last_status_code, last_error, created_at, delivered_at`
+)
+
+type WebhookRepository struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+}
+
+func NewWebhookRepository(db *pgxpool.Pool, logger *slog.Logger) *WebhookRepository {
+	return &WebhookRepository{db: db, logger: logger}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, w *model.Webhook) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`INSERT INTO webhooks(url, secret, event_types, user_id)
+         VALUES($1, $2, $3, $4)
+         RETURNING id, created_at, updated_at`,
+		w.URL, w.Secret, w.EventTypes, w.UserID).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+
+	if err != nil {
+		r.logger.Error("failed to create webhook", "error", err)
+		return err
+	}
+
+	r.logger.Info("webhook created in repository", "id", w.ID)
+
+	return nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, w *model.Webhook) error {
+	err := conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE webhooks
+         SET url = $1,
+             secret = $2,
+             event_types = $3,
+             user_id = $4,
+             updated_at = now()
+         WHERE id = $5
+         RETURNING created_at, updated_at`,
+		w.URL, w.Secret, w.EventTypes, w.UserID, w.ID).Scan(&w.CreatedAt, &w.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrWebhookNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to update webhook", "error", err, "id", w.ID)
+		return err
+	}
+
+	r.logger.Info("webhook updated in repository", "id", w.ID)
+
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete webhook", "error", err, "id", id)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrWebhookNotFound
+	}
+
+	r.logger.Info("webhook deleted in repository", "id", id)
+
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	w, err := scanWebhook(conn(ctx, r.db).QueryRow(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrWebhookNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to get webhook", "error", err, "id", id)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]*model.Webhook, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at`)
+	if err != nil {
+		r.logger.Error("failed to select webhooks", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := make([]*model.Webhook, 0)
+
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			r.logger.Error("failed to scan webhook row", "error", err)
+			return nil, err
+		}
+		hooks = append(hooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during webhook rows iteration", "error", err)
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// Enqueue creates a pending delivery of the event for every webhook whose
+// filter matches it. Enqueueing the same event again is a no-op.
+func (r *WebhookRepository) Enqueue(ctx context.Context, event model.Event) (int64, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	tag, err := conn(ctx, r.db).Exec(ctx,
+		`INSERT INTO webhook_deliveries(webhook_id, event_id, event_type, payload)
+         SELECT id, $1, $2, $3
+         FROM webhooks
+         WHERE (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+           AND (user_id IS NULL OR user_id = $4)
+         ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		event.ID, event.Type, payload, event.UserID)
+	if err != nil {
+		r.logger.Error("failed to enqueue webhook deliveries", "error", err, "event_id", event.ID)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDue claims up to limit pending deliveries whose next attempt is due
+// by postponing it to until, so that other workers skip them while they
+// are sent outside of any transaction.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, until time.Time) ([]*model.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`UPDATE webhook_deliveries
+	 SET next_attempt_at = $3
+	 WHERE id IN (
+	     SELECT id
+	     FROM webhook_deliveries
+	     WHERE status = $1 AND next_attempt_at <= now()
+	     ORDER BY next_attempt_at
+	     LIMIT $2
+	     FOR UPDATE SKIP LOCKED)
+	 RETURNING `+deliveryColumns, model.DeliveryPending, limit, until)
+	if err != nil {
+		r.logger.Error("failed to select due webhook deliveries", "error", err)
+		return nil, err
+	}
+
+	return r.collectDeliveries(rows)
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit int) ([]*model.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).Query(ctx,
+		`SELECT `+deliveryColumns+`
+	 FROM webhook_deliveries
+	 WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+	 ORDER BY created_at DESC
+	 LIMIT $3`, webhookID, status, limit)
+	if err != nil {
+		r.logger.Error("failed to select webhook deliveries", "error", err, "webhook_id", webhookID)
+		return nil, err
+	}
+
+	return r.collectDeliveries(rows)
+}
+
+// RecordAttempt stores the state of d after a delivery attempt.
+func (r *WebhookRepository) RecordAttempt(
+	ctx context.Context,
+	d *model.WebhookDelivery,
+) error {
+	_, err := conn(ctx, r.db).Exec(ctx,
+		`UPDATE webhook_deliveries
+         SET status = $2,
+             attempts = $3,
+             next_attempt_at = $4,
+             last_status_code = $5,
+             last_error = $6,
+             delivered_at = $7
+         WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt)
+	if err != nil {
+		r.logger.Error("failed to record webhook delivery attempt", "error", err, "id", d.ID)
+	}
+
+	return err
+}
+
+// Redeliver puts a delivery of the webhook back in the queue, due now,
+// with a fresh budget of attempts.
+func (r *WebhookRepository) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	d, err := scanDelivery(conn(ctx, r.db).QueryRow(ctx,
+		`UPDATE webhook_deliveries
+         SET status = $3,
+             attempts = 0,
+             next_attempt_at = $4
+         WHERE id = $1 AND webhook_id = $2
+         RETURNING `+deliveryColumns,
+		deliveryID, webhookID, model.DeliveryPending, time.Now()))
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrDeliveryNotFound
+	}
+	if err != nil {
+		r.logger.Error("failed to redeliver webhook delivery", "error", err, "id", deliveryID)
+		return nil, err
+	}
+
+	r.logger.Info("webhook delivery queued again", "id", deliveryID)
+
+	return d, nil
+}
+
+func (r *WebhookRepository) collectDeliveries(rows pgx.Rows) ([]*model.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			r.logger.Error("failed to scan webhook delivery row", "error", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("error during webhook delivery rows iteration", "error", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var w model.Webhook
+
+	err := row.Scan(
+		&w.ID,
+		&w.URL,
+		&w.Secret,
+		&w.EventTypes,
+		&w.UserID,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+func scanDelivery(row pgx.Row) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+
+	err := row.Scan(
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.EventType,
+		&d.Payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
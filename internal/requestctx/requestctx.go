@@ -0,0 +1,66 @@
+// Package requestctx carries per-request metadata, such as who made the
+// request, from the transport layer down to the repositories.
+package requestctx
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// SystemActor is recorded for changes made by background jobs.
+const SystemActor = "system"
+
+// Headers carrying the request id and the acting user. gRPC clients send
+// them as metadata, whose keys are lowercase.
+const (
+	RequestIDHeader = "X-Request-ID"
+	ActorHeader     = "X-Actor"
+)
+
+type actorKey struct{}
+type requestIDKey struct{}
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor stored in ctx, or SystemActor if there is none.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// FromHeaders stores the request id and the actor read with header in ctx.
+// A missing request id is generated. The request id is returned so that
+// the transport can echo it back.
+func FromHeaders(ctx context.Context, header func(name string) string) (context.Context, string) {
+	requestID := header(RequestIDHeader)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+
+	ctx = WithRequestID(ctx, requestID)
+	if actor := header(ActorHeader); actor != "" {
+		ctx = WithActor(ctx, actor)
+	}
+
+	return ctx, requestID
+}
+
+// Logger returns logger with the request id and the actor of ctx.
+func Logger(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	return logger.With("request_id", RequestID(ctx), "actor", Actor(ctx))
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const maxAuditLimit = 1000
+
+type AuditService struct {
+	repo   *repository.AuditRepository
+	logger *slog.Logger
+}
+
+func NewAuditService(repo *repository.AuditRepository, logger *slog.Logger) *AuditService {
+	return &AuditService{repo: repo, logger: logger}
+}
+
+// History returns every recorded change of the subscription, oldest first.
+func (s *AuditService) History(ctx context.Context, id uuid.UUID) ([]*model.AuditEntry, error) {
+
+	entries, err := s.repo.List(ctx, model.AuditFilter{SubscriptionID: &id, Limit: maxAuditLimit})
+	if err != nil {
+		s.logger.Error("failed to get subscription history", "error", err, "id", id)
+		return nil, err
+	}
+
+	s.logger.Info("subscription history fetched", "id", id, "count", len(entries))
+
+	return entries, nil
+}
+
+func (s *AuditService) List(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error) {
+
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	entries, err := s.repo.List(ctx, filter)
+	if err != nil {
+		s.logger.Error("failed to list audit entries", "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("audit entries fetched", "count", len(entries))
+
+	return entries, nil
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// maxBatchOperations bounds the operations of one batch, which runs in a
+// single transaction.
+const maxBatchOperations = 1000
+
+// errBatchRolledBack rolls back the transaction of an atomic batch with a
+// failed operation.
+var errBatchRolledBack = errors.New("batch rolled back")
+
+// Batch applies the operations in one transaction and returns the result
+// of each. Every operation is validated like the single create, update or
+// delete, and for overlaps with the other operations; the valid ones are
+// then written together. An atomic batch is
+// rolled back if any operation fails, leaving ErrBatchAborted as the result
+// of the others; otherwise only the failed operations are left out. The
+// returned bool reports whether the batch was applied.
+func (s *SubscriptionService) Batch(
+	ctx context.Context,
+	ops []model.BatchOperation,
+	atomic bool,
+) ([]model.BatchOperationResult, bool, error) {
+
+	if len(ops) == 0 {
+		return nil, false, apperr.Invalidf("batch has no operations")
+	}
+	if len(ops) > maxBatchOperations {
+		return nil, false, apperr.Invalidf("batch has more than %d operations", maxBatchOperations)
+	}
+
+	results := make([]model.BatchOperationResult, len(ops))
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var ids []uuid.UUID
+		for _, op := range ops {
+			if op.Op == model.BatchUpdate || op.Op == model.BatchDelete {
+				ids = append(ids, op.ID)
+			}
+		}
+
+		befores, err := s.repo.GetManyForUpdate(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[uuid.UUID]bool)
+		for i, op := range ops {
+			results[i] = model.BatchOperationResult{Op: op.Op, ID: op.ID}
+
+			sub, err := s.prepareBatchOperation(ctx, op, befores, seen)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+
+			if op.Op != model.BatchDelete {
+				results[i].Subscription = sub
+			}
+		}
+
+		overlaps := s.checkBatchOverlaps(ops, results, befores)
+
+		creates, updates, deletes, failed := splitBatch(ops, results)
+		if failed && atomic {
+			return errBatchRolledBack
+		}
+
+		if err := s.repo.CreateMany(ctx, creates); err != nil {
+			return err
+		}
+		if err := s.repo.UpdateMany(ctx, updates); err != nil {
+			return err
+		}
+		if err := s.repo.DeleteMany(ctx, deletes); err != nil {
+			return err
+		}
+
+		// The created subscriptions have IDs only now.
+		for _, pair := range overlaps {
+			a, b := results[pair[0]].Subscription, results[pair[1]].Subscription
+			a.Overlaps = append(a.Overlaps, b.ID)
+			b.Overlaps = append(b.Overlaps, a.ID)
+		}
+
+		var events []model.Event
+		for i, op := range ops {
+			if results[i].Err != nil {
+				continue
+			}
+
+			var opEvents []model.Event
+			switch op.Op {
+			case model.BatchCreate:
+				results[i].ID = results[i].Subscription.ID
+				event, err := newEvent(model.EventSubscriptionCreated, results[i].Subscription, results[i].Subscription)
+				if err != nil {
+					return err
+				}
+				opEvents = []model.Event{event}
+			case model.BatchUpdate:
+				if opEvents, err = changeEvents(befores[op.ID], results[i].Subscription); err != nil {
+					return err
+				}
+			case model.BatchDelete:
+				event, err := newEvent(model.EventSubscriptionDeleted, befores[op.ID], befores[op.ID])
+				if err != nil {
+					return err
+				}
+				opEvents = []model.Event{event}
+			}
+			events = append(events, opEvents...)
+		}
+
+		return s.outbox.Add(ctx, events...)
+	})
+
+	if errors.Is(err, errBatchRolledBack) {
+		abortBatch(results)
+		s.logger.Warn("atomic batch rolled back", "operations", len(ops))
+		return results, false, nil
+	}
+	if err != nil {
+		s.logger.Error("failed to apply batch", "error", err, "operations", len(ops))
+		return nil, false, err
+	}
+
+	s.logger.Info("batch applied", "operations", len(ops))
+
+	return results, true, nil
+}
+
+// splitBatch returns the subscriptions to create and update and the IDs to
+// delete of the operations that did not fail, and whether any failed.
+func splitBatch(
+	ops []model.BatchOperation,
+	results []model.BatchOperationResult,
+) (creates, updates []*model.Subscription, deletes []uuid.UUID, failed bool) {
+
+	for i, op := range ops {
+		if results[i].Err != nil {
+			failed = true
+			continue
+		}
+
+		switch op.Op {
+		case model.BatchCreate:
+			creates = append(creates, results[i].Subscription)
+		case model.BatchUpdate:
+			updates = append(updates, results[i].Subscription)
+		case model.BatchDelete:
+			deletes = append(deletes, op.ID)
+		}
+	}
+
+	return creates, updates, deletes, failed
+}
+
+// abortBatch marks the operations of a rolled back batch that did not fail
+// themselves as aborted.
+func abortBatch(results []model.BatchOperationResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Subscription = nil
+			results[i].Err = model.ErrBatchAborted
+		}
+	}
+}
+
+// prepareBatchOperation validates op against the subscriptions locked for
+// the batch and returns the subscription to create or update, or the one
+// to delete.
+func (s *SubscriptionService) prepareBatchOperation(
+	ctx context.Context,
+	op model.BatchOperation,
+	befores map[uuid.UUID]*model.Subscription,
+	seen map[uuid.UUID]bool,
+) (*model.Subscription, error) {
+
+	if op.Op == model.BatchCreate {
+		if op.Subscription == nil {
+			return nil, apperr.Invalidf("create needs a subscription")
+		}
+		sub := op.Subscription
+		svc, err := s.prepare(ctx, sub, nil)
+		if err != nil {
+			return nil, err
+		}
+		if sub.CategoryID == nil && svc != nil {
+			sub.CategoryID = svc.CategoryID
+		}
+		return sub, nil
+	}
+
+	if op.Op != model.BatchUpdate && op.Op != model.BatchDelete {
+		return nil, apperr.Invalidf("unknown operation %q, want create, update or delete", op.Op)
+	}
+
+	if seen[op.ID] {
+		return nil, model.ErrBatchDuplicate
+	}
+	seen[op.ID] = true
+
+	before, ok := befores[op.ID]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	if len(op.Versions) > 0 && !slices.Contains(op.Versions, before.Version) {
+		return nil, &model.VersionConflictError{ID: op.ID, Expected: op.Versions, Actual: before.Version}
+	}
+
+	if op.Op == model.BatchDelete {
+		return before, nil
+	}
+
+	sub := *before
+	sub.Tags = slices.Clone(before.Tags)
+	sub.Pauses = slices.Clone(before.Pauses)
+
+	if op.Patch != nil {
+		if err := op.Patch(&sub); err != nil {
+			return nil, err
+		}
+	}
+	sub.ID = op.ID
+
+	if _, err := s.prepare(ctx, &sub, before); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+// checkBatchOverlaps applies the overlap mode to the subscriptions the
+// batch creates and updates among themselves, which checkOverlaps does not
+// see as they are not written yet. In reject mode an operation overlapping
+// an earlier one fails; in warn mode the indexes of the overlapping pairs
+// are returned, to be recorded once the subscriptions are written. Updates
+// that keep their user, service and months are not checked against each
+// other, like in checkOverlaps.
+func (s *SubscriptionService) checkBatchOverlaps(
+	ops []model.BatchOperation,
+	results []model.BatchOperationResult,
+	befores map[uuid.UUID]*model.Subscription,
+) [][2]int {
+
+	if s.overlapMode == model.OverlapAllow {
+		return nil
+	}
+
+	unchanged := func(i int) bool {
+		return ops[i].Op == model.BatchUpdate && samePeriod(befores[ops[i].ID], results[i].Subscription)
+	}
+
+	var pairs [][2]int
+
+	for j := range results {
+		b := results[j].Subscription
+		if results[j].Err != nil || b == nil {
+			continue
+		}
+
+		for i := range j {
+			a := results[i].Subscription
+			if results[i].Err != nil || a == nil || !overlapping(a, b) || unchanged(i) && unchanged(j) {
+				continue
+			}
+
+			if s.overlapMode == model.OverlapWarn {
+				s.logger.Warn("batch operations overlap", "operation", j, "overlaps", i, "user_id", b.UserId)
+				pairs = append(pairs, [2]int{i, j})
+				continue
+			}
+
+			// Subscriptions kept from another mode are left out, like by the
+			// exclusion constraint.
+			if a.OverlapAllowed || b.OverlapAllowed {
+				continue
+			}
+
+			s.logger.Warn("invalid batch operation", "reason", "overlaps another operation", "operation", j, "overlaps", i)
+			if ops[i].Op == model.BatchUpdate {
+				results[j].Err = fmt.Errorf("%w: %s", model.ErrSubscriptionOverlap, ops[i].ID)
+			} else {
+				results[j].Err = fmt.Errorf("%w: operation %d of the batch", model.ErrSubscriptionOverlap, i)
+			}
+			break
+		}
+	}
+
+	return pairs
+}
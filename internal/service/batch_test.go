@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestSplitBatch(t *testing.T) {
+	created := &model.Subscription{ServiceName: "Netflix"}
+	updated := &model.Subscription{ID: alice, ServiceName: "Spotify"}
+
+	ops := []model.BatchOperation{
+		{Op: model.BatchCreate, Subscription: created},
+		{Op: model.BatchUpdate, ID: alice},
+		{Op: model.BatchDelete, ID: bob},
+		{Op: model.BatchDelete, ID: owner},
+	}
+	results := []model.BatchOperationResult{
+		{Op: model.BatchCreate, Subscription: created},
+		{Op: model.BatchUpdate, ID: alice, Subscription: updated},
+		{Op: model.BatchDelete, ID: bob},
+		{Op: model.BatchDelete, ID: owner, Err: model.ErrNotFound},
+	}
+
+	creates, updates, deletes, failed := splitBatch(ops, results)
+
+	// A batch that is not atomic writes the operations that did not fail.
+	if !reflect.DeepEqual(creates, []*model.Subscription{created}) ||
+		!reflect.DeepEqual(updates, []*model.Subscription{updated}) ||
+		!reflect.DeepEqual(deletes, []uuid.UUID{bob}) ||
+		!failed {
+		t.Errorf("split = %v, %v, %v, %t", creates, updates, deletes, failed)
+	}
+
+	if _, _, _, failed := splitBatch(ops[:3], results[:3]); failed {
+		t.Error("split of succeeded operations failed")
+	}
+}
+
+func TestAbortBatch(t *testing.T) {
+	results := []model.BatchOperationResult{
+		{Op: model.BatchCreate, Subscription: &model.Subscription{}},
+		{Op: model.BatchDelete, ID: owner, Err: model.ErrNotFound},
+		{Op: model.BatchUpdate, ID: alice, Subscription: &model.Subscription{}},
+	}
+
+	abortBatch(results)
+
+	want := []model.BatchOperationResult{
+		{Op: model.BatchCreate, Err: model.ErrBatchAborted},
+		{Op: model.BatchDelete, ID: owner, Err: model.ErrNotFound},
+		{Op: model.BatchUpdate, ID: alice, Err: model.ErrBatchAborted},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %+v, want %+v", results, want)
+	}
+}
+
+func TestPrepareBatchDelete(t *testing.T) {
+	s := &SubscriptionService{logger: slog.New(slog.DiscardHandler)}
+	before := &model.Subscription{ID: alice, Version: 2}
+	befores := map[uuid.UUID]*model.Subscription{alice: before}
+
+	tests := []struct {
+		name string
+		op   model.BatchOperation
+		want error
+	}{
+		{name: "delete", op: model.BatchOperation{Op: model.BatchDelete, ID: alice}},
+		{name: "matching version", op: model.BatchOperation{Op: model.BatchDelete, ID: alice, Versions: []int{1, 2}}},
+		{name: "not found", op: model.BatchOperation{Op: model.BatchDelete, ID: bob}, want: model.ErrNotFound},
+		{
+			name: "version conflict",
+			op:   model.BatchOperation{Op: model.BatchDelete, ID: alice, Versions: []int{1}},
+			want: &model.VersionConflictError{ID: alice, Expected: []int{1}, Actual: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := s.prepareBatchOperation(context.Background(), tt.op, befores, make(map[uuid.UUID]bool))
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if err == nil && sub != before {
+				t.Errorf("subscription = %+v, want the one deleted", sub)
+			}
+		})
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		seen := make(map[uuid.UUID]bool)
+		op := model.BatchOperation{Op: model.BatchDelete, ID: alice}
+
+		if _, err := s.prepareBatchOperation(context.Background(), op, befores, seen); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.prepareBatchOperation(context.Background(), op, befores, seen); !errors.Is(err, model.ErrBatchDuplicate) {
+			t.Errorf("err = %v, want %v", err, model.ErrBatchDuplicate)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, op := range []model.BatchOperation{{Op: "upsert", ID: alice}, {Op: model.BatchCreate}} {
+			if _, err := s.prepareBatchOperation(context.Background(), op, befores, nil); apperr.KindOf(err) != apperr.Invalid {
+				t.Errorf("%s: err = %v, want an invalid error", op.Op, err)
+			}
+		}
+	})
+}
+
+func TestCheckBatchOverlaps(t *testing.T) {
+	service := uuid.MustParse("8b2e4d5f-3c6a-4f7b-9d0e-1f2a3b4c5d6e")
+	sub := func(start, end time.Month) *model.Subscription {
+		return &model.Subscription{UserId: owner, ServiceID: service, StartDate: month(2025, start), EndDate: monthPtr(2025, end)}
+	}
+
+	ops := []model.BatchOperation{{Op: model.BatchCreate}, {Op: model.BatchCreate}, {Op: model.BatchCreate}}
+	results := func() []model.BatchOperationResult {
+		return []model.BatchOperationResult{
+			{Subscription: sub(time.January, time.March)},
+			{Subscription: sub(time.April, time.June)},
+			{Subscription: sub(time.March, time.April)},
+		}
+	}
+
+	t.Run("warn", func(t *testing.T) {
+		s := &SubscriptionService{overlapMode: model.OverlapWarn, logger: slog.New(slog.DiscardHandler)}
+		r := results()
+
+		want := [][2]int{{0, 2}, {1, 2}}
+		if got := s.checkBatchOverlaps(ops, r, nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("pairs = %v, want %v", got, want)
+		}
+		for i := range r {
+			if r[i].Err != nil {
+				t.Errorf("operation %d failed: %v", i, r[i].Err)
+			}
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		s := &SubscriptionService{overlapMode: model.OverlapReject, logger: slog.New(slog.DiscardHandler)}
+		r := results()
+
+		if pairs := s.checkBatchOverlaps(ops, r, nil); pairs != nil {
+			t.Errorf("pairs = %v, want none", pairs)
+		}
+		if r[0].Err != nil || r[1].Err != nil || !errors.Is(r[2].Err, model.ErrSubscriptionOverlap) {
+			t.Errorf("errors = %v, %v, %v, want only the last to overlap", r[0].Err, r[1].Err, r[2].Err)
+		}
+	})
+}
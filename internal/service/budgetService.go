@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+var budgetScopes = []string{model.BudgetScopeUser, model.BudgetScopeCategory, model.BudgetScopeService}
+
+type BudgetService struct {
+	repo          *repository.BudgetRepository
+	subs          *repository.SubscriptionRepository
+	catalog       *repository.CatalogRepository
+	categories    *repository.CategoryRepository
+	notifications *NotificationService
+	thresholds    []int
+	logger        *slog.Logger
+}
+
+// NewBudgetService creates a budget service alerting when the projected
+// spend of a period reaches any of thresholds, given in percent of the
+// budget.
+func NewBudgetService(
+	repo *repository.BudgetRepository,
+	subs *repository.SubscriptionRepository,
+	catalog *repository.CatalogRepository,
+	categories *repository.CategoryRepository,
+	notifications *NotificationService,
+	thresholds []int,
+	logger *slog.Logger,
+) *BudgetService {
+	sorted := slices.Clone(thresholds)
+	slices.Sort(sorted)
+
+	return &BudgetService{
+		repo:          repo,
+		subs:          subs,
+		catalog:       catalog,
+		categories:    categories,
+		notifications: notifications,
+		thresholds:    slices.Compact(sorted),
+		logger:        logger,
+	}
+}
+
+func (s *BudgetService) CreateBudget(ctx context.Context, b *model.Budget) error {
+
+	if err := s.validate(ctx, b); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, b); err != nil {
+		s.logger.Error("failed to create budget", "error", err)
+		return err
+	}
+
+	s.logger.Info("budget created", "id", b.ID, "user_id", b.UserID)
+
+	s.evaluate(ctx, b.UserID)
+
+	return nil
+}
+
+func (s *BudgetService) UpdateBudget(ctx context.Context, b *model.Budget) error {
+
+	if err := s.validate(ctx, b); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, b); err != nil {
+		s.logger.Error("failed to update budget", "error", err, "id", b.ID)
+		return err
+	}
+
+	s.logger.Info("budget updated", "id", b.ID)
+
+	s.evaluate(ctx, b.UserID)
+
+	return nil
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, id uuid.UUID) error {
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Error("failed to delete budget", "error", err, "id", id)
+		return err
+	}
+
+	s.logger.Info("budget deleted", "id", id)
+	return nil
+}
+
+func (s *BudgetService) GetBudget(ctx context.Context, id uuid.UUID) (*model.Budget, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListBudgets returns the budgets of the user, or all budgets if userID is
+// nil.
+func (s *BudgetService) ListBudgets(ctx context.Context, userID *uuid.UUID) ([]*model.Budget, error) {
+	return s.repo.List(ctx, userID)
+}
+
+// BudgetStatus returns the spend of the budget's current period.
+func (s *BudgetService) BudgetStatus(ctx context.Context, id uuid.UUID) (*model.BudgetStatus, error) {
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to get budget", "error", err, "id", id)
+		return nil, err
+	}
+
+	subs, err := s.subs.List(ctx, model.SubscriptionFilter{MemberID: &b.UserID})
+	if err != nil {
+		s.logger.Error("failed to list subscriptions for budget", "error", err, "id", id)
+		return nil, err
+	}
+
+	return budgetStatus(b, subs, time.Now().UTC()), nil
+}
+
+// Publish implements sink.Sink by evaluating the budgets of the owner and
+// the members of the subscription of the event. Failures are logged rather
+// than returned so that budget alerts never hold up the outbox relay.
+func (s *BudgetService) Publish(ctx context.Context, event model.Event) error {
+	var members []model.Member
+
+	sub, err := s.subs.GetByID(ctx, event.SubscriptionID, true)
+	switch {
+	case err == nil:
+		members = sub.Members
+	case !errors.Is(err, model.ErrNotFound):
+		s.logger.Error("failed to get subscription for budget evaluation", "error", err, "id", event.SubscriptionID)
+	}
+
+	for _, userID := range sharingUsers(event.UserID, members) {
+		s.evaluate(ctx, userID)
+	}
+
+	return nil
+}
+
+func (s *BudgetService) Close() error {
+	return nil
+}
+
+// RunEvaluation evaluates every budget every interval until ctx is done,
+// catching spend that changes without a subscription event, such as a new
+// period starting or a subscription being paused.
+func (s *BudgetService) RunEvaluation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			budgets, err := s.repo.List(ctx, nil)
+			if err != nil {
+				s.logger.Error("failed to list budgets for evaluation", "error", err)
+				continue
+			}
+
+			users := make(map[uuid.UUID]bool)
+			for _, b := range budgets {
+				if !users[b.UserID] {
+					users[b.UserID] = true
+					s.evaluate(ctx, b.UserID)
+				}
+			}
+		}
+	}
+}
+
+// evaluate sends an alert for every budget of the user whose projected
+// spend reached a threshold, at most once per threshold and period.
+func (s *BudgetService) evaluate(ctx context.Context, userID uuid.UUID) {
+	if len(s.thresholds) == 0 {
+		return
+	}
+
+	budgets, err := s.repo.List(ctx, &userID)
+	if err != nil || len(budgets) == 0 {
+		if err != nil {
+			s.logger.Error("failed to list budgets for evaluation", "error", err, "user_id", userID)
+		}
+		return
+	}
+
+	subs, err := s.subs.List(ctx, model.SubscriptionFilter{MemberID: &userID})
+	if err != nil {
+		s.logger.Error("failed to list subscriptions for budget evaluation", "error", err, "user_id", userID)
+		return
+	}
+
+	settings, err := s.notifications.GetSettings(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get notification settings for budget alert", "error", err, "user_id", userID)
+		return
+	}
+
+	now := time.Now().UTC()
+
+	for _, b := range budgets {
+		status := budgetStatus(b, subs, now)
+
+		threshold := 0
+		for _, t := range s.thresholds {
+			if status.Projected*100 >= b.Amount*t {
+				threshold = t
+			}
+		}
+		if threshold == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s:%s:%d",
+			model.NotificationBudget, b.ID, status.PeriodStart.Format(time.DateOnly), threshold)
+		claimed, err := s.notifications.Send(ctx, key, budgetAlert(status, threshold, settings))
+		if err != nil {
+			s.logger.Error("failed to send budget alert", "error", err, "key", key)
+		}
+		if !claimed {
+			continue
+		}
+
+		s.logger.Warn("budget threshold reached",
+			"budget_id", b.ID,
+			"user_id", b.UserID,
+			"threshold", threshold,
+			"projected", status.Projected,
+			"amount", b.Amount,
+		)
+	}
+}
+
+// sharingUsers returns the owner followed by the members sharing the cost
+// of a subscription, each once.
+func sharingUsers(ownerID uuid.UUID, members []model.Member) []uuid.UUID {
+	users := []uuid.UUID{ownerID}
+	for _, m := range members {
+		if !slices.Contains(users, m.UserID) {
+			users = append(users, m.UserID)
+		}
+	}
+
+	return users
+}
+
+func (s *BudgetService) validate(ctx context.Context, b *model.Budget) error {
+
+	if b.UserID == uuid.Nil {
+		s.logger.Warn("invalid budget data", "reason", "user id is nil")
+		return apperr.Invalidf("user_id is required")
+	}
+
+	if b.Amount <= 0 {
+		s.logger.Warn("invalid budget data", "reason", "amount is not positive")
+		return apperr.Invalidf("amount must be positive")
+	}
+
+	if b.Period == "" {
+		b.Period = model.GranularityMonth
+	}
+	if !slices.Contains(granularities, b.Period) {
+		s.logger.Warn("invalid budget data", "reason", "unknown period")
+		return apperr.Invalidf("period must be month, quarter or year")
+	}
+
+	if b.Scope == "" {
+		b.Scope = model.BudgetScopeUser
+	}
+	if !slices.Contains(budgetScopes, b.Scope) {
+		s.logger.Warn("invalid budget data", "reason", "unknown scope")
+		return apperr.Invalidf("scope must be user, category or service")
+	}
+
+	if (b.Scope == model.BudgetScopeCategory) != (b.CategoryID != nil) {
+		s.logger.Warn("invalid budget data", "reason", "category id does not match scope")
+		return apperr.Invalidf("category_id is required for, and only for, the category scope")
+	}
+
+	if (b.Scope == model.BudgetScopeService) != (b.ServiceID != nil) {
+		s.logger.Warn("invalid budget data", "reason", "service id does not match scope")
+		return apperr.Invalidf("service_id is required for, and only for, the service scope")
+	}
+
+	if err := checkCategory(ctx, s.categories, b.CategoryID); err != nil {
+		return err
+	}
+
+	if b.ServiceID != nil {
+		if _, err := s.catalog.GetByID(ctx, *b.ServiceID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// budgetStatus returns the budget user's share of the spend of the
+// subscriptions in the scope of the budget in the period containing now.
+func budgetStatus(b *model.Budget, subs []*model.Subscription, now time.Time) *model.BudgetStatus {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	start, length := month, 1
+	switch b.Period {
+	case model.GranularityQuarter:
+		start, length = month.AddDate(0, -int(month.Month()-1)%3, 0), 3
+	case model.GranularityYear:
+		start, length = month.AddDate(0, -int(month.Month()-1), 0), 12
+	}
+
+	status := &model.BudgetStatus{
+		Budget:      b,
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, length-1, 0),
+	}
+
+	for _, sub := range subs {
+		switch {
+		case b.Scope == model.BudgetScopeCategory && (sub.CategoryID == nil || *sub.CategoryID != *b.CategoryID),
+			b.Scope == model.BudgetScopeService && sub.ServiceID != *b.ServiceID:
+			continue
+		}
+
+		status.Actual += memberCost(sub, b.UserID, start, month)
+		status.Projected += memberCost(sub, b.UserID, start, status.PeriodEnd)
+	}
+
+	status.Percent = status.Projected * 100 / b.Amount
+
+	return status
+}
+
+func budgetAlert(status *model.BudgetStatus, threshold int, settings *model.NotificationSettings) model.Notification {
+	b := status.Budget
+	period := utils.ParseMonthYearToString(status.PeriodStart)
+	if status.PeriodEnd.After(status.PeriodStart) {
+		period += " to " + utils.ParseMonthYearToString(status.PeriodEnd)
+	}
+
+	n := model.Notification{
+		Kind:    model.NotificationBudget,
+		UserID:  b.UserID,
+		Date:    status.PeriodStart,
+		Subject: fmt.Sprintf("Subscriptions reached %d%% of your %s budget", threshold, b.Period),
+		Body: fmt.Sprintf("Your subscriptions are projected to cost %d of your budget of %d for %s (%d%%). So far %d was charged.",
+			status.Projected, b.Amount, period, status.Percent, status.Actual),
+	}
+	if settings.Email != nil {
+		n.Email = *settings.Email
+	}
+	return n
+}
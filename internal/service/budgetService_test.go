@@ -0,0 +1,48 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestSharingUsers(t *testing.T) {
+	members := []model.Member{weight(owner, 2), weight(alice, 1), amount(bob, 100)}
+
+	want := []uuid.UUID{owner, alice, bob}
+	if got := sharingUsers(owner, members); !reflect.DeepEqual(got, want) {
+		t.Errorf("users = %v, want %v", got, want)
+	}
+
+	if got := sharingUsers(owner, nil); !reflect.DeepEqual(got, []uuid.UUID{owner}) {
+		t.Errorf("users without members = %v, want the owner", got)
+	}
+}
+
+func TestBudgetStatusOfMember(t *testing.T) {
+	sub := &model.Subscription{
+		UserId:    owner,
+		Price:     1000,
+		StartDate: month(2025, time.January),
+		Members:   []model.Member{weight(alice, 1)},
+	}
+	b := &model.Budget{UserID: alice, Scope: model.BudgetScopeUser, Amount: 1000, Period: model.GranularityQuarter}
+
+	got := budgetStatus(b, []*model.Subscription{sub}, month(2025, time.May).AddDate(0, 0, 14))
+
+	want := &model.BudgetStatus{
+		Budget:      b,
+		PeriodStart: month(2025, time.April),
+		PeriodEnd:   month(2025, time.June),
+		Actual:      1000,
+		Projected:   1500,
+		Percent:     150,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("status = %+v, want %+v", got, want)
+	}
+}
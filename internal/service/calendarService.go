@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/requestctx"
+
+	"github.com/google/uuid"
+)
+
+// calendarTokenBytes is the entropy of a calendar feed token.
+const calendarTokenBytes = 32
+
+type CalendarService struct {
+	repo          *repository.CalendarRepository
+	reports       *ReportService
+	horizonMonths int
+	logger        *slog.Logger
+}
+
+// NewCalendarService creates a calendar service whose feeds hold the
+// charges of the current month and the following horizonMonths-1 months.
+func NewCalendarService(
+	repo *repository.CalendarRepository,
+	reports *ReportService,
+	horizonMonths int,
+	logger *slog.Logger,
+) *CalendarService {
+	return &CalendarService{repo: repo, reports: reports, horizonMonths: max(horizonMonths, 1), logger: logger}
+}
+
+// CreateToken returns a new secret token for the user's renewal feed. Only
+// its hash is stored; a previous token stops working. Only the user can
+// create it.
+func (s *CalendarService) CreateToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	if !actorIs(ctx, userID) {
+		s.logger.Warn("calendar token denied", "user_id", userID, "actor", requestctx.Actor(ctx))
+		return "", model.ErrNotOwner
+	}
+
+	secret := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		s.logger.Error("failed to generate calendar token", "error", err)
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	hash := sha256.Sum256([]byte(token))
+	if err := s.repo.SaveToken(ctx, userID, hash[:]); err != nil {
+		s.logger.Error("failed to create calendar token", "error", err, "user_id", userID)
+		return "", err
+	}
+
+	s.logger.Info("calendar token created", "user_id", userID)
+
+	return token, nil
+}
+
+// RevokeToken stops the user's token from working. Only the user can
+// revoke it.
+func (s *CalendarService) RevokeToken(ctx context.Context, userID uuid.UUID) error {
+
+	if !actorIs(ctx, userID) {
+		s.logger.Warn("calendar token denied", "user_id", userID, "actor", requestctx.Actor(ctx))
+		return model.ErrNotOwner
+	}
+
+	if err := s.repo.DeleteToken(ctx, userID); err != nil {
+		s.logger.Error("failed to revoke calendar token", "error", err, "user_id", userID)
+		return err
+	}
+
+	s.logger.Info("calendar token revoked", "user_id", userID)
+	return nil
+}
+
+// Renewals returns the charges of the user's feed if token is the user's
+// current token, and model.ErrCalendarTokenInvalid otherwise.
+func (s *CalendarService) Renewals(ctx context.Context, userID uuid.UUID, token string) ([]model.ChargeEvent, error) {
+
+	stored, err := s.repo.GetTokenHash(ctx, userID)
+	if errors.Is(err, model.ErrCalendarTokenNotFound) {
+		err = model.ErrCalendarTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	if subtle.ConstantTimeCompare(stored, hash[:]) != 1 {
+		s.logger.Warn("calendar token rejected", "user_id", userID)
+		return nil, model.ErrCalendarTokenInvalid
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	charges, err := s.reports.Charges(ctx, userID, from, from.AddDate(0, s.horizonMonths-1, 0))
+	if err != nil {
+		s.logger.Error("failed to get renewals for calendar", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("calendar feed generated", "user_id", userID, "events", len(charges))
+
+	return charges, nil
+}
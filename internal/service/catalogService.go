@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var (
+	billingPeriods = []string{model.BillingMonthly, model.BillingQuarterly, model.BillingYearly}
+	currencyCode   = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+type CatalogService struct {
+	repo       *repository.CatalogRepository
+	categories *repository.CategoryRepository
+	outbox     *repository.OutboxRepository
+	tx         *repository.Transactor
+	logger     *slog.Logger
+}
+
+func NewCatalogService(
+	repo *repository.CatalogRepository,
+	categories *repository.CategoryRepository,
+	outbox *repository.OutboxRepository,
+	tx *repository.Transactor,
+	logger *slog.Logger,
+) *CatalogService {
+	return &CatalogService{repo: repo, categories: categories, outbox: outbox, tx: tx, logger: logger}
+}
+
+func (s *CatalogService) CreateService(ctx context.Context, svc *model.Service) error {
+
+	if err := s.validate(svc); err != nil {
+		return err
+	}
+
+	if err := checkCategory(ctx, s.categories, svc.CategoryID); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, svc); err != nil {
+		s.logger.Error("failed to create service", "error", err)
+		return err
+	}
+
+	s.logger.Info("service created", "id", svc.ID, "name", svc.Name)
+	return nil
+}
+
+// UpdateService replaces the catalog entry. Subscriptions of the service
+// take its new name.
+func (s *CatalogService) UpdateService(ctx context.Context, svc *model.Service) error {
+
+	if err := s.validate(svc); err != nil {
+		return err
+	}
+
+	if err := checkCategory(ctx, s.categories, svc.CategoryID); err != nil {
+		return err
+	}
+
+	// Renamed subscriptions are announced like any other update.
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		renamed, err := s.repo.Update(ctx, svc)
+		if err != nil {
+			return err
+		}
+
+		for _, before := range renamed {
+			after := *before
+			after.ServiceName = svc.Name
+
+			events, err := changeEvents(before, &after)
+			if err != nil {
+				return err
+			}
+			if err := s.outbox.Add(ctx, events...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to update service", "error", err, "id", svc.ID)
+		return err
+	}
+
+	s.logger.Info("service updated", "id", svc.ID)
+	return nil
+}
+
+// DeleteService removes a service that no subscription references.
+func (s *CatalogService) DeleteService(ctx context.Context, id uuid.UUID) error {
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Error("failed to delete service", "error", err, "id", id)
+		return err
+	}
+
+	s.logger.Info("service deleted", "id", id)
+	return nil
+}
+
+func (s *CatalogService) GetService(ctx context.Context, id uuid.UUID) (*model.Service, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListServices returns the catalog, optionally only one category.
+func (s *CatalogService) ListServices(ctx context.Context, categoryID *uuid.UUID) ([]*model.Service, error) {
+	return s.repo.List(ctx, categoryID)
+}
+
+// SearchServices finds services by part of their name or an alias.
+func (s *CatalogService) SearchServices(ctx context.Context, query string, limit int) ([]*model.Service, error) {
+
+	if strings.TrimSpace(query) == "" {
+		s.logger.Warn("invalid service search", "reason", "query is empty")
+		return nil, apperr.Invalidf("query is required")
+	}
+
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	return s.repo.Search(ctx, query, limit)
+}
+
+func (s *CatalogService) validate(svc *model.Service) error {
+
+	svc.Name = normalizeName(svc.Name)
+	if svc.Name == "" {
+		s.logger.Warn("invalid service data", "reason", "name is empty")
+		return apperr.Invalidf("name is required")
+	}
+
+	aliases := make([]string, 0, len(svc.Aliases))
+	for _, alias := range svc.Aliases {
+		alias = normalizeName(alias)
+		if alias == "" {
+			s.logger.Warn("invalid service data", "reason", "alias is empty")
+			return apperr.Invalidf("aliases must not be empty")
+		}
+		aliases = append(aliases, alias)
+	}
+	svc.Aliases = aliases
+
+	if svc.BillingPeriod == "" {
+		svc.BillingPeriod = model.BillingMonthly
+	}
+	if !slices.Contains(billingPeriods, svc.BillingPeriod) {
+		s.logger.Warn("invalid service data", "reason", "unknown billing period")
+		return apperr.Invalidf("billing_period must be monthly, quarterly or yearly")
+	}
+
+	if svc.DefaultPrice != nil && *svc.DefaultPrice <= 0 {
+		s.logger.Warn("invalid service data", "reason", "default price is not valid")
+		return apperr.Invalidf("default price must be greater than zero")
+	}
+
+	if svc.Currency != nil && !currencyCode.MatchString(*svc.Currency) {
+		s.logger.Warn("invalid service data", "reason", "currency is not valid")
+		return apperr.Invalidf("currency must be an ISO 4217 code such as RUB")
+	}
+
+	if svc.Website != nil {
+		u, err := url.Parse(*svc.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			s.logger.Warn("invalid service data", "reason", "website is not valid")
+			return apperr.Invalidf("website must be an absolute http or https URL")
+		}
+	}
+
+	return nil
+}
+
+// normalizeName trims name and collapses runs of whitespace, like the
+// service_key function of the database does before lowercasing.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
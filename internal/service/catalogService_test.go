@@ -0,0 +1,82 @@
+package service
+
+import (
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Netflix", "Netflix"},
+		{"  Yandex   Plus ", "Yandex Plus"},
+		{"Apple\tTV\n+", "Apple TV +"},
+		{" \t ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeName(tt.name); got != tt.want {
+			t.Errorf("normalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateService(t *testing.T) {
+	s := &CatalogService{logger: slog.New(slog.DiscardHandler)}
+
+	svc := &model.Service{Name: " Yandex  Plus", Aliases: []string{"Яндекс  Плюс ", "Plus"}}
+	if err := s.validate(svc); err != nil {
+		t.Fatalf("validate = %v", err)
+	}
+
+	want := &model.Service{
+		Name:          "Yandex Plus",
+		Aliases:       []string{"Яндекс Плюс", "Plus"},
+		BillingPeriod: model.BillingMonthly,
+	}
+	if !reflect.DeepEqual(svc, want) {
+		t.Errorf("service = %+v, want %+v", svc, want)
+	}
+
+	invalid := []*model.Service{
+		{Name: "  "},
+		{Name: "Netflix", Aliases: []string{" "}},
+		{Name: "Netflix", BillingPeriod: "weekly"},
+	}
+	for _, svc := range invalid {
+		if err := s.validate(svc); err == nil {
+			t.Errorf("validate(%+v) = nil, want an error", svc)
+		}
+	}
+}
+
+func TestApplyService(t *testing.T) {
+	price := 299
+	svc := &model.Service{ID: alice, Name: "Yandex Plus", DefaultPrice: &price}
+
+	tests := []struct {
+		name  string
+		price int
+		want  int
+	}{
+		{"default price", 0, 299},
+		{"own price", 199, 199},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{ServiceName: "yandex  plus", Price: tt.price}
+			applyService(sub, svc)
+
+			if sub.ServiceID != svc.ID || sub.ServiceName != svc.Name || sub.Price != tt.want {
+				t.Errorf("subscription = %s %q %d, want %s %q %d",
+					sub.ServiceID, sub.ServiceName, sub.Price, svc.ID, svc.Name, tt.want)
+			}
+		})
+	}
+}
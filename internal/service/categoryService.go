@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type CategoryService struct {
+	repo   *repository.CategoryRepository
+	logger *slog.Logger
+}
+
+func NewCategoryService(repo *repository.CategoryRepository, logger *slog.Logger) *CategoryService {
+	return &CategoryService{repo: repo, logger: logger}
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, c *model.Category) error {
+
+	if err := s.validate(c); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, c); err != nil {
+		s.logger.Error("failed to create category", "error", err)
+		return err
+	}
+
+	s.logger.Info("category created", "id", c.ID, "name", c.Name)
+	return nil
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, c *model.Category) error {
+
+	if err := s.validate(c); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, c); err != nil {
+		s.logger.Error("failed to update category", "error", err, "id", c.ID)
+		return err
+	}
+
+	s.logger.Info("category updated", "id", c.ID)
+	return nil
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Error("failed to delete category", "error", err, "id", id)
+		return err
+	}
+
+	s.logger.Info("category deleted", "id", id)
+	return nil
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, id uuid.UUID) (*model.Category, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *CategoryService) ListCategories(ctx context.Context) ([]*model.Category, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *CategoryService) validate(c *model.Category) error {
+
+	c.Name = normalizeName(c.Name)
+	if c.Name == "" {
+		s.logger.Warn("invalid category data", "reason", "name is empty")
+		return apperr.Invalidf("name is required")
+	}
+
+	return nil
+}
+
+// checkCategory returns model.ErrCategoryNotFound unless id is nil or an
+// existing category.
+func checkCategory(ctx context.Context, repo *repository.CategoryRepository, id *uuid.UUID) error {
+	if id == nil {
+		return nil
+	}
+
+	_, err := repo.GetByID(ctx, *id)
+	return err
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// subscriptionCost returns what the subscription is charged for the months
+// from start through end, both given as the first day of a month.
+func subscriptionCost(sub *model.Subscription, start, end time.Time) int {
+	return sumCharges(sub, start, end, func(price, charge int) int {
+		return charge
+	})
+}
+
+// memberCost is subscriptionCost restricted to the share of userID.
+func memberCost(sub *model.Subscription, userID uuid.UUID, start, end time.Time) int {
+	return sumCharges(sub, start, end, func(price, charge int) int {
+		return memberShare(sub, price, charge, userID)
+	})
+}
+
+// sumCharges adds up amount of the charge of every month from start through
+// end that the subscription is charged for, given the price of the month.
+func sumCharges(sub *model.Subscription, start, end time.Time, amount func(price, charge int) int) int {
+	total := 0
+	eachCharge(sub, start, end, func(_ time.Time, price, charge int) {
+		total += amount(price, charge)
+	})
+	return total
+}
+
+// eachCharge calls fn with every month from start through end that the
+// subscription is charged for, the price in effect and what is charged.
+func eachCharge(sub *model.Subscription, start, end time.Time, fn func(month time.Time, price, charge int)) {
+	periodStart := utils.MaxTime(start, sub.StartDate)
+
+	periodEnd := end
+	if sub.EndDate != nil {
+		periodEnd = utils.MinTime(end, *sub.EndDate)
+	}
+
+	for month := periodStart; !month.After(periodEnd); month = month.AddDate(0, 1, 0) {
+		if !sub.PausedAt(month) {
+			price := sub.PriceAt(month)
+			fn(month, price, chargeAt(sub, price, month))
+		}
+	}
+}
+
+// memberShare returns the part of a month's charge of sub that userID
+// pays, where price is the price of that month. Fixed amounts are paid in
+// full unless the charge is lower than the price, as in a trial, or than
+// their sum, when they are scaled down. The rest is split by weight among
+// the other members and the owner, who has weight 1 unless listed, and the
+// owner pays what is left after rounding.
+func memberShare(sub *model.Subscription, price, charge int, userID uuid.UUID) int {
+	if len(sub.Members) == 0 {
+		if userID == sub.UserId {
+			return charge
+		}
+		return 0
+	}
+
+	fixed, weights, ownerListed := 0, 0, false
+	for _, m := range sub.Members {
+		if m.Amount != nil {
+			fixed += *m.Amount
+		} else {
+			weights += *m.Weight
+		}
+		ownerListed = ownerListed || m.UserID == sub.UserId
+	}
+	if !ownerListed {
+		weights++
+	}
+
+	base := max(price, fixed, charge, 1)
+	shares := make(map[uuid.UUID]int, len(sub.Members))
+	rest := charge
+	for _, m := range sub.Members {
+		if m.Amount != nil {
+			shares[m.UserID] = *m.Amount * charge / base
+			rest -= shares[m.UserID]
+		}
+	}
+
+	owner := charge
+	for _, m := range sub.Members {
+		if m.Weight != nil {
+			shares[m.UserID] = rest * *m.Weight / weights
+		}
+		if m.UserID != sub.UserId {
+			owner -= shares[m.UserID]
+		}
+	}
+
+	if userID == sub.UserId {
+		return owner
+	}
+	return shares[userID]
+}
+
+// priceAt returns the price charged on the given charge date.
+func priceAt(sub *model.Subscription, date time.Time) int {
+	return chargeAt(sub, sub.PriceAt(date), date)
+}
+
+// chargeAt returns what is charged on the given date when the price is
+// price: the trial price before the end of the trial, the price after.
+func chargeAt(sub *model.Subscription, price int, date time.Time) int {
+	if sub.TrialEnd != nil && date.Before(*sub.TrialEnd) {
+		return sub.TrialPrice
+	}
+	return price
+}
+
+// monthsBetween counts the months from start through end, inclusive.
+func monthsBetween(start, end time.Time) int {
+	return (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+}
+
+// nextMonth returns the first day of the month after t.
+func nextMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	owner = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	alice = uuid.MustParse("0b0a1b5e-5d7c-4f43-9a2b-8f8a3c1e0d01")
+	bob   = uuid.MustParse("0b0a1b5e-5d7c-4f43-9a2b-8f8a3c1e0d02")
+)
+
+// month returns the first day of the month.
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// monthPtr is month for optional dates.
+func monthPtr(year int, m time.Month) *time.Time {
+	t := month(year, m)
+	return &t
+}
+
+func weight(userID uuid.UUID, w int) model.Member {
+	return model.Member{UserID: userID, Weight: &w}
+}
+
+func amount(userID uuid.UUID, a int) model.Member {
+	return model.Member{UserID: userID, Amount: &a}
+}
+
+func TestMemberShare(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []model.Member
+		charge  int
+		want    map[uuid.UUID]int
+	}{
+		{
+			name:   "no members",
+			charge: 1000,
+			want:   map[uuid.UUID]int{owner: 1000, alice: 0},
+		},
+		{
+			name:    "fixed amounts scaled down to the price",
+			members: []model.Member{amount(alice, 600), amount(bob, 600)},
+			charge:  1000,
+			want:    map[uuid.UUID]int{owner: 0, alice: 500, bob: 500},
+		},
+		{
+			name:    "trial charge below the price",
+			members: []model.Member{amount(alice, 300)},
+			charge:  100,
+			want:    map[uuid.UUID]int{owner: 70, alice: 30},
+		},
+		{
+			name:    "owner not listed",
+			members: []model.Member{weight(alice, 1)},
+			charge:  1000,
+			want:    map[uuid.UUID]int{owner: 500, alice: 500},
+		},
+		{
+			name:    "owner listed",
+			members: []model.Member{weight(owner, 2), weight(alice, 1)},
+			charge:  1000,
+			want:    map[uuid.UUID]int{owner: 667, alice: 333},
+		},
+		{
+			name:    "rounding remainder goes to the owner",
+			members: []model.Member{weight(alice, 1), weight(bob, 1)},
+			charge:  1000,
+			want:    map[uuid.UUID]int{owner: 334, alice: 333, bob: 333},
+		},
+		{
+			name:    "fixed amount and weights",
+			members: []model.Member{amount(alice, 200), weight(bob, 1)},
+			charge:  1000,
+			want:    map[uuid.UUID]int{owner: 400, alice: 200, bob: 400},
+		},
+		{
+			name:    "not a member",
+			members: []model.Member{weight(alice, 1)},
+			charge:  1000,
+			want:    map[uuid.UUID]int{bob: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{UserId: owner, Price: 1000, Members: tt.members}
+
+			for userID, want := range tt.want {
+				if got := memberShare(sub, sub.Price, tt.charge, userID); got != want {
+					t.Errorf("share of %s = %d, want %d", userID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSumCharges(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		endDate  *time.Time
+		trialEnd *time.Time
+		pauses   []model.Pause
+		end      time.Time
+		want     int
+	}{
+		{
+			name:   "pause at the start of the window",
+			pauses: []model.Pause{{StartDate: month(2025, time.January), ResumeDate: monthPtr(2025, time.March)}},
+			end:    month(2025, time.June),
+			want:   400,
+		},
+		{
+			name:   "pause resumed at the start of the window",
+			pauses: []model.Pause{{StartDate: month(2024, time.November), ResumeDate: monthPtr(2025, time.January)}},
+			end:    month(2025, time.March),
+			want:   300,
+		},
+		{
+			name:   "pause at the end of the window",
+			pauses: []model.Pause{{StartDate: month(2025, time.June)}},
+			end:    month(2025, time.June),
+			want:   500,
+		},
+		{
+			name:   "open pause",
+			pauses: []model.Pause{{StartDate: month(2025, time.March)}},
+			end:    month(2025, time.December),
+			want:   200,
+		},
+		{
+			name:     "pause overlapping the trial",
+			trialEnd: monthPtr(2025, time.April),
+			pauses:   []model.Pause{{StartDate: month(2025, time.February), ResumeDate: monthPtr(2025, time.May)}},
+			end:      month(2025, time.June),
+			want:     10 + 100 + 100,
+		},
+		{
+			name:    "end month inside a pause",
+			endDate: monthPtr(2025, time.April),
+			pauses:  []model.Pause{{StartDate: month(2025, time.March)}},
+			end:     month(2025, time.December),
+			want:    200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{
+				UserId:     owner,
+				Price:      100,
+				StartDate:  month(2025, time.January),
+				EndDate:    tt.endDate,
+				TrialEnd:   tt.trialEnd,
+				TrialPrice: 10,
+				Pauses:     tt.pauses,
+			}
+
+			if got := subscriptionCost(sub, month(2025, time.January), tt.end); got != tt.want {
+				t.Errorf("cost = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func newEvent(eventType string, sub *model.Subscription, payload any) (model.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return model.Event{}, err
+	}
+
+	return model.Event{
+		ID:             uuid.New(),
+		Type:           eventType,
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserId,
+		OccurredAt:     time.Now().UTC(),
+		Payload:        data,
+	}, nil
+}
+
+// changeEvents returns the events describing an update from before to after.
+func changeEvents(before, after *model.Subscription) ([]model.Event, error) {
+	var events []model.Event
+
+	if before.Price != after.Price {
+		e, err := newEvent(model.EventPriceChanged, after, model.PriceChange{
+			OldPrice: before.Price,
+			NewPrice: after.Price,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	if before.ServiceName != after.ServiceName {
+		e, err := newEvent(model.EventServiceRenamed, after, model.ServiceRename{
+			OldName: before.ServiceName,
+			NewName: after.ServiceName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	if after.EndDate != nil && (before.EndDate == nil || !before.EndDate.Equal(*after.EndDate)) {
+		e, err := newEvent(model.EventSubscriptionEnded, after, model.SubscriptionEnd{
+			EndDate: *after.EndDate,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// maxForecastMonths bounds the forecast to five years.
+const maxForecastMonths = 60
+
+var billingMonths = map[string]int{
+	model.BillingMonthly:   1,
+	model.BillingQuarterly: 3,
+	model.BillingYearly:    12,
+}
+
+// Forecast projects the user's share of the charges of the subscriptions
+// the user owns or is a member of for the given number of months from next
+// month on. Renewals costing more than threshold, or the configured
+// threshold if nil, are flagged; a threshold of 0 flags none.
+func (s *ReportService) Forecast(
+	ctx context.Context,
+	userID uuid.UUID,
+	months int,
+	threshold *int,
+) (*model.Forecast, error) {
+
+	if months < 1 || months > maxForecastMonths {
+		s.logger.Warn("invalid forecast", "reason", "months out of range", "months", months)
+		return nil, apperr.Invalidf("months must be between 1 and %d", maxForecastMonths)
+	}
+
+	limit := s.renewalThreshold
+	if threshold != nil {
+		if *threshold < 0 {
+			s.logger.Warn("invalid forecast", "reason", "threshold is negative")
+			return nil, apperr.Invalidf("threshold must not be negative")
+		}
+		limit = *threshold
+	}
+
+	from := nextMonth(time.Now().UTC())
+	to := from.AddDate(0, months-1, 0)
+
+	charges, err := s.Charges(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := &model.Forecast{
+		Months:  make([]model.ForecastMonth, months),
+		Charges: charges,
+	}
+	for i := range forecast.Months {
+		forecast.Months[i].Month = from.AddDate(0, i, 0)
+	}
+
+	for i := range charges {
+		charge := &charges[i]
+		charge.OverThreshold = charge.Renewal && limit > 0 && charge.Amount > limit
+
+		// Charges returns charges dated on the first day of one of the
+		// months from through to, the months of the forecast.
+		month := &forecast.Months[monthsBetween(from, charge.Date)-1]
+		month.Total += charge.Amount
+		month.Charges++
+
+		forecast.Total += charge.Amount
+	}
+
+	s.logger.Info("forecast calculated", "user_id", userID, "months", months, "charges", len(forecast.Charges))
+
+	return forecast, nil
+}
+
+// Charges returns the user's share of the expected charges of the
+// subscriptions the user owns or is a member of in the months from through
+// to, by date and service name. Charges are dated on the first day of their
+// month, like from and to.
+func (s *ReportService) Charges(ctx context.Context, userID uuid.UUID, from, to time.Time) ([]model.ChargeEvent, error) {
+	subs, err := s.subs.List(ctx, model.SubscriptionFilter{MemberID: &userID})
+	if err != nil {
+		s.logger.Error("failed to list subscriptions for charges", "error", err)
+		return nil, err
+	}
+
+	pending, err := s.priceChanges.ListPendingByUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list price changes for charges", "error", err)
+		return nil, err
+	}
+
+	changes := make(map[uuid.UUID][]*model.ScheduledPriceChange)
+	for _, c := range pending {
+		changes[c.SubscriptionID] = append(changes[c.SubscriptionID], c)
+	}
+
+	periods := make(map[uuid.UUID]string)
+	charges := make([]model.ChargeEvent, 0)
+
+	for _, sub := range subs {
+		period, ok := periods[sub.ServiceID]
+		if !ok {
+			period, err = s.billingPeriod(ctx, sub.ServiceID)
+			if err != nil {
+				return nil, err
+			}
+			periods[sub.ServiceID] = period
+		}
+
+		charges = append(charges, forecastCharges(sub, userID, period, changes[sub.ID], from, to)...)
+	}
+
+	sort.SliceStable(charges, func(i, j int) bool {
+		a, b := charges[i], charges[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		return a.ServiceName < b.ServiceName
+	})
+
+	return charges, nil
+}
+
+func (s *ReportService) billingPeriod(ctx context.Context, serviceID uuid.UUID) (string, error) {
+	svc, err := s.catalog.GetByID(ctx, serviceID)
+	if errors.Is(err, model.ErrServiceNotFound) {
+		return model.BillingMonthly, nil
+	}
+	if err != nil {
+		s.logger.Error("failed to get service for forecast", "error", err, "service_id", serviceID)
+		return "", err
+	}
+
+	return svc.BillingPeriod, nil
+}
+
+// forecastCharges returns the share of userID of the charges of the
+// subscription in the months from through to, which are first days of
+// months. Price is monthly; a subscription billed every quarter or year is
+// charged at the start of each period for its months, counted from its
+// start month, that are not paused.
+func forecastCharges(
+	sub *model.Subscription,
+	userID uuid.UUID,
+	period string,
+	changes []*model.ScheduledPriceChange,
+	from, to time.Time,
+) []model.ChargeEvent {
+
+	length, ok := billingMonths[period]
+	if !ok {
+		period, length = model.BillingMonthly, 1
+	}
+
+	var charges []model.ChargeEvent
+
+	for month := utils.MaxTime(from, sub.StartDate); !month.After(to); month = month.AddDate(0, 1, 0) {
+		if sub.EndDate != nil && month.After(*sub.EndDate) {
+			break
+		}
+
+		index := monthsBetween(sub.StartDate, month) - 1
+		if index%length != 0 {
+			continue
+		}
+
+		amount := 0
+		for i := 0; i < length; i++ {
+			covered := month.AddDate(0, i, 0)
+			if sub.EndDate != nil && covered.After(*sub.EndDate) {
+				break
+			}
+			if !sub.PausedAt(covered) {
+				price := scheduledPriceAt(sub, changes, covered)
+				amount += memberShare(sub, price, chargeAt(sub, price, covered), userID)
+			}
+		}
+
+		if amount == 0 {
+			continue
+		}
+
+		charges = append(charges, model.ChargeEvent{
+			SubscriptionID: sub.ID,
+			ServiceName:    sub.ServiceName,
+			Date:           month,
+			Amount:         amount,
+			BillingPeriod:  period,
+			Renewal:        index > 0,
+		})
+	}
+
+	return charges
+}
+
+// scheduledPriceAt is Subscription.PriceAt with the pending price changes,
+// sorted by effective date, that take effect by the given date.
+func scheduledPriceAt(sub *model.Subscription, changes []*model.ScheduledPriceChange, date time.Time) int {
+	price := sub.PriceAt(date)
+	for _, c := range changes {
+		if c.EffectiveDate.After(date) {
+			break
+		}
+		price = c.Price
+	}
+
+	return price
+}
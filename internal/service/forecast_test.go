@@ -0,0 +1,140 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+func TestForecastCharges(t *testing.T) {
+	type charge struct {
+		date    time.Time
+		amount  int
+		renewal bool
+	}
+
+	tests := []struct {
+		name     string
+		period   string
+		start    time.Time
+		endDate  *time.Time
+		pauses   []model.Pause
+		changes  []*model.ScheduledPriceChange
+		from, to time.Time
+		want     []charge
+	}{
+		{
+			name:   "monthly",
+			period: model.BillingMonthly,
+			start:  month(2025, time.January),
+			from:   month(2025, time.January),
+			to:     month(2025, time.March),
+			want: []charge{
+				{month(2025, time.January), 100, false},
+				{month(2025, time.February), 100, true},
+				{month(2025, time.March), 100, true},
+			},
+		},
+		{
+			name:   "quarterly",
+			period: model.BillingQuarterly,
+			start:  month(2025, time.January),
+			from:   month(2025, time.February),
+			to:     month(2025, time.December),
+			want: []charge{
+				{month(2025, time.April), 300, true},
+				{month(2025, time.July), 300, true},
+				{month(2025, time.October), 300, true},
+			},
+		},
+		{
+			name:   "yearly",
+			period: model.BillingYearly,
+			start:  month(2024, time.March),
+			from:   month(2025, time.January),
+			to:     month(2026, time.December),
+			want: []charge{
+				{month(2025, time.March), 1200, true},
+				{month(2026, time.March), 1200, true},
+			},
+		},
+		{
+			name:    "end in the middle of a period",
+			period:  model.BillingQuarterly,
+			start:   month(2025, time.January),
+			endDate: monthPtr(2025, time.May),
+			from:    month(2025, time.January),
+			to:      month(2025, time.December),
+			want: []charge{
+				{month(2025, time.January), 300, false},
+				{month(2025, time.April), 200, true},
+			},
+		},
+		{
+			name:   "paused month of a period",
+			period: model.BillingQuarterly,
+			start:  month(2025, time.January),
+			pauses: []model.Pause{{StartDate: month(2025, time.February), ResumeDate: monthPtr(2025, time.March)}},
+			from:   month(2025, time.January),
+			to:     month(2025, time.March),
+			want: []charge{
+				{month(2025, time.January), 200, false},
+			},
+		},
+		{
+			name:    "scheduled change",
+			period:  model.BillingMonthly,
+			start:   month(2025, time.January),
+			changes: []*model.ScheduledPriceChange{{EffectiveDate: month(2025, time.March), Price: 150}},
+			from:    month(2025, time.February),
+			to:      month(2025, time.April),
+			want: []charge{
+				{month(2025, time.February), 100, true},
+				{month(2025, time.March), 150, true},
+				{month(2025, time.April), 150, true},
+			},
+		},
+		{
+			name:    "scheduled change in the middle of a period",
+			period:  model.BillingQuarterly,
+			start:   month(2025, time.January),
+			changes: []*model.ScheduledPriceChange{{EffectiveDate: month(2025, time.February), Price: 150}},
+			from:    month(2025, time.January),
+			to:      month(2025, time.April),
+			want: []charge{
+				{month(2025, time.January), 400, false},
+				{month(2025, time.April), 450, true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{
+				UserId:    owner,
+				Price:     100,
+				StartDate: tt.start,
+				EndDate:   tt.endDate,
+				Pauses:    tt.pauses,
+			}
+
+			got := forecastCharges(sub, owner, tt.period, tt.changes, tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d charges, want %d: %+v", len(got), len(tt.want), got)
+			}
+
+			for i, w := range tt.want {
+				g := got[i]
+				if !g.Date.Equal(w.date) || g.Amount != w.amount || g.Renewal != w.renewal {
+					t.Errorf("charge %d = %s %d renewal %t, want %s %d renewal %t",
+						i, g.Date.Format("01-2006"), g.Amount, g.Renewal,
+						w.date.Format("01-2006"), w.amount, w.renewal)
+				}
+				if g.BillingPeriod != tt.period {
+					t.Errorf("charge %d: billing period = %s, want %s", i, g.BillingPeriod, tt.period)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/requestctx"
+
+	"github.com/google/uuid"
+)
+
+const maxGroupNameLength = 100
+
+var groupRoles = []string{model.GroupRoleOwner, model.GroupRoleAdmin, model.GroupRoleMember}
+
+// GroupService manages groups of users and combines their spending. The
+// actor of the request must be the ID of a member, whose role decides
+// what the actor may see and change.
+type GroupService struct {
+	repo   *repository.GroupRepository
+	subs   *SubscriptionService
+	tx     *repository.Transactor
+	logger *slog.Logger
+}
+
+func NewGroupService(
+	repo *repository.GroupRepository,
+	subs *SubscriptionService,
+	tx *repository.Transactor,
+	logger *slog.Logger,
+) *GroupService {
+	return &GroupService{
+		repo:   repo,
+		subs:   subs,
+		tx:     tx,
+		logger: logger,
+	}
+}
+
+// CreateGroup creates a group owned by the actor.
+func (s *GroupService) CreateGroup(ctx context.Context, g *model.UserGroup) error {
+	actor, ok := actorID(ctx)
+	if !ok {
+		s.logger.Warn("group creation denied", "reason", "actor is not a user", "actor", requestctx.Actor(ctx))
+		return model.ErrGroupForbidden
+	}
+
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		s.logger.Warn("invalid group", "reason", "name is empty")
+		return apperr.Invalidf("name is required")
+	}
+	if len(g.Name) > maxGroupNameLength {
+		s.logger.Warn("invalid group", "reason", "name is too long")
+		return apperr.Invalidf("name is too long")
+	}
+
+	g.Members = []model.GroupMember{{UserID: actor, Role: model.GroupRoleOwner}}
+
+	if err := s.repo.Create(ctx, g); err != nil {
+		return err
+	}
+
+	s.logger.Info("group created", "id", g.ID, "owner", actor)
+
+	return nil
+}
+
+// GetGroup returns the group to any of its members.
+func (s *GroupService) GetGroup(ctx context.Context, id uuid.UUID) (*model.UserGroup, error) {
+	g, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorize(ctx, g, model.GroupRoleMember); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// ListUserGroups returns the groups the user is a member of. Only the user
+// can list them.
+func (s *GroupService) ListUserGroups(ctx context.Context, userID uuid.UUID) ([]*model.UserGroup, error) {
+	if !actorIs(ctx, userID) {
+		s.logger.Warn("group access denied", "user_id", userID, "actor", requestctx.Actor(ctx))
+		return nil, model.ErrGroupForbidden
+	}
+
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// DeleteGroup deletes the group. Only owners can delete it.
+func (s *GroupService) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		g, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.authorize(ctx, g, model.GroupRoleOwner); err != nil {
+			return err
+		}
+
+		return s.repo.Delete(ctx, id)
+	})
+	if err != nil {
+		s.logger.Error("failed to delete group", "error", err, "id", id)
+		return err
+	}
+
+	s.logger.Info("group deleted", "id", id)
+
+	return nil
+}
+
+// AddMember adds a user to the group. Owners can add users with any role,
+// admins only with the member role.
+func (s *GroupService) AddMember(ctx context.Context, id uuid.UUID, m model.GroupMember) (*model.UserGroup, error) {
+	if m.Role == "" {
+		m.Role = model.GroupRoleMember
+	}
+
+	if err := s.validateMember(m); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		g, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.authorize(ctx, g, requiredToAdd(m.Role)); err != nil {
+			return err
+		}
+
+		return s.repo.AddMember(ctx, id, m)
+	})
+	if err != nil {
+		s.logger.Error("failed to add group member", "error", err, "id", id, "user_id", m.UserID)
+		return nil, err
+	}
+
+	s.logger.Info("group member added", "id", id, "user_id", m.UserID, "role", m.Role)
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// UpdateMemberRole changes the role of a member. Only owners can change
+// roles, and the last owner cannot give up the role.
+func (s *GroupService) UpdateMemberRole(ctx context.Context, id, userID uuid.UUID, role string) (*model.UserGroup, error) {
+	if err := s.validateMember(model.GroupMember{UserID: userID, Role: role}); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		g, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.authorize(ctx, g, model.GroupRoleOwner); err != nil {
+			return err
+		}
+
+		if losesLastOwner(g, userID, role) {
+			return model.ErrLastGroupOwner
+		}
+
+		return s.repo.UpdateMemberRole(ctx, id, userID, role)
+	})
+	if err != nil {
+		s.logger.Error("failed to update group member", "error", err, "id", id, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("group member updated", "id", id, "user_id", userID, "role", role)
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// RemoveMember removes a user from the group. Owners can remove anyone,
+// admins only members, and everyone can leave; the last owner cannot.
+func (s *GroupService) RemoveMember(ctx context.Context, id, userID uuid.UUID) (*model.UserGroup, error) {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		g, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		role := g.Role(userID)
+		if role == "" {
+			return model.ErrGroupMemberNotFound
+		}
+
+		if _, err := s.authorize(ctx, g, requiredToRemove(role, actorIs(ctx, userID))); err != nil {
+			return err
+		}
+
+		if losesLastOwner(g, userID, "") {
+			return model.ErrLastGroupOwner
+		}
+
+		return s.repo.RemoveMember(ctx, id, userID)
+	})
+	if err != nil {
+		s.logger.Error("failed to remove group member", "error", err, "id", id, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("group member removed", "id", id, "user_id", userID)
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListSubscriptions returns the subscriptions the members of the group own
+// or are members of, each once. Only owners and admins can list them.
+func (s *GroupService) ListSubscriptions(ctx context.Context, id uuid.UUID) ([]*model.Subscription, error) {
+	g, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.authorize(ctx, g, model.GroupRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	subs := make([]*model.Subscription, 0)
+
+	for _, m := range g.Members {
+		userID := m.UserID
+		memberSubs, err := s.subs.List(ctx, model.SubscriptionFilter{MemberID: &userID})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sub := range memberSubs {
+			if !seen[sub.ID] {
+				seen[sub.ID] = true
+				subs = append(subs, sub)
+			}
+		}
+	}
+
+	return subs, nil
+}
+
+// TotalCost sums the total cost of every member of the group, calculated
+// like CalculateSubscriptionsTotalCost, so that a subscription shared by
+// several members counts once. The cost of each member is only returned
+// to owners and admins.
+func (s *GroupService) TotalCost(
+	ctx context.Context,
+	id uuid.UUID,
+	serviceName string,
+	start, end time.Time,
+) (*model.GroupCost, error) {
+
+	g, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.authorize(ctx, g, model.GroupRoleMember)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := &model.GroupCost{}
+	members := make([]model.MemberCost, 0, len(g.Members))
+
+	for _, m := range g.Members {
+		total, err := s.subs.CalculateSubscriptionsTotalCost(ctx, m.UserID, serviceName, start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		cost.Total += total
+		members = append(members, model.MemberCost{UserID: m.UserID, Total: total})
+	}
+
+	if role != model.GroupRoleMember {
+		cost.Members = members
+	}
+
+	s.logger.Debug("calculated group cost", "id", id, "service", serviceName, "total", cost.Total)
+
+	return cost, nil
+}
+
+// authorize returns the role of the actor in the group if it is at least
+// as privileged as required.
+func (s *GroupService) authorize(ctx context.Context, g *model.UserGroup, required string) (string, error) {
+	actor, _ := actorID(ctx)
+
+	role := g.Role(actor)
+	if role == "" || slices.Index(groupRoles, role) > slices.Index(groupRoles, required) {
+		s.logger.Warn("group access denied", "id", g.ID, "actor", requestctx.Actor(ctx), "required", required)
+		return "", model.ErrGroupForbidden
+	}
+
+	return role, nil
+}
+
+// requiredToAdd returns the role needed to add a member with role.
+func requiredToAdd(role string) string {
+	if role == model.GroupRoleMember {
+		return model.GroupRoleAdmin
+	}
+	return model.GroupRoleOwner
+}
+
+// requiredToRemove returns the role needed to remove a member with role,
+// the actor themselves if self.
+func requiredToRemove(role string, self bool) string {
+	switch {
+	case self:
+		return model.GroupRoleMember
+	case role == model.GroupRoleMember:
+		return model.GroupRoleAdmin
+	default:
+		return model.GroupRoleOwner
+	}
+}
+
+// losesLastOwner reports whether giving the user the role, or removing the
+// user if it is empty, leaves the group without an owner.
+func losesLastOwner(g *model.UserGroup, userID uuid.UUID, role string) bool {
+	return g.Role(userID) == model.GroupRoleOwner && role != model.GroupRoleOwner && g.Owners() == 1
+}
+
+func (s *GroupService) validateMember(m model.GroupMember) error {
+
+	if m.UserID == uuid.Nil {
+		s.logger.Warn("invalid group member", "reason", "user_id is missing")
+		return apperr.Invalidf("user_id is required")
+	}
+
+	if !slices.Contains(groupRoles, m.Role) {
+		s.logger.Warn("invalid group member", "reason", "unknown role", "role", m.Role)
+		return apperr.Invalidf("role must be owner, admin or member")
+	}
+
+	return nil
+}
+
+// actorID returns the actor of the request as a user ID.
+func actorID(ctx context.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(requestctx.Actor(ctx))
+	return id, err == nil
+}
+
+func actorIs(ctx context.Context, userID uuid.UUID) bool {
+	id, ok := actorID(ctx)
+	return ok && id == userID
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/requestctx"
+)
+
+func TestAuthorize(t *testing.T) {
+	s := &GroupService{logger: slog.New(slog.DiscardHandler)}
+	g := &model.UserGroup{Members: []model.GroupMember{
+		{UserID: owner, Role: model.GroupRoleOwner},
+		{UserID: alice, Role: model.GroupRoleAdmin},
+		{UserID: bob, Role: model.GroupRoleMember},
+	}}
+
+	tests := []struct {
+		actor    string
+		required string
+		want     string // empty if denied
+	}{
+		{owner.String(), model.GroupRoleOwner, model.GroupRoleOwner},
+		{owner.String(), model.GroupRoleMember, model.GroupRoleOwner},
+		{alice.String(), model.GroupRoleOwner, ""},
+		{alice.String(), model.GroupRoleAdmin, model.GroupRoleAdmin},
+		{bob.String(), model.GroupRoleAdmin, ""},
+		{bob.String(), model.GroupRoleMember, model.GroupRoleMember},
+		{"60601fee-0000-4721-ae6f-7636e79a0cba", model.GroupRoleMember, ""},
+		{requestctx.SystemActor, model.GroupRoleMember, ""},
+	}
+
+	for _, tt := range tests {
+		ctx := requestctx.WithActor(context.Background(), tt.actor)
+
+		role, err := s.authorize(ctx, g, tt.required)
+		if role != tt.want || (tt.want == "") != errors.Is(err, model.ErrGroupForbidden) {
+			t.Errorf("%s as %s: role = %q, %v, want %q", tt.actor, tt.required, role, err, tt.want)
+		}
+	}
+}
+
+func TestGroupRoleRequirements(t *testing.T) {
+	add := map[string]string{
+		model.GroupRoleMember: model.GroupRoleAdmin,
+		model.GroupRoleAdmin:  model.GroupRoleOwner,
+		model.GroupRoleOwner:  model.GroupRoleOwner,
+	}
+	for role, want := range add {
+		if got := requiredToAdd(role); got != want {
+			t.Errorf("adding %s requires %s, want %s", role, got, want)
+		}
+	}
+
+	remove := []struct {
+		role string
+		self bool
+		want string
+	}{
+		{model.GroupRoleMember, false, model.GroupRoleAdmin},
+		{model.GroupRoleAdmin, false, model.GroupRoleOwner},
+		{model.GroupRoleOwner, false, model.GroupRoleOwner},
+		{model.GroupRoleOwner, true, model.GroupRoleMember},
+		{model.GroupRoleAdmin, true, model.GroupRoleMember},
+	}
+	for _, tt := range remove {
+		if got := requiredToRemove(tt.role, tt.self); got != tt.want {
+			t.Errorf("removing %s (self %t) requires %s, want %s", tt.role, tt.self, got, tt.want)
+		}
+	}
+}
+
+func TestLosesLastOwner(t *testing.T) {
+	single := &model.UserGroup{Members: []model.GroupMember{
+		{UserID: owner, Role: model.GroupRoleOwner},
+		{UserID: alice, Role: model.GroupRoleMember},
+	}}
+	shared := &model.UserGroup{Members: []model.GroupMember{
+		{UserID: owner, Role: model.GroupRoleOwner},
+		{UserID: alice, Role: model.GroupRoleOwner},
+	}}
+
+	tests := []struct {
+		name string
+		g    *model.UserGroup
+		role string
+		want bool
+	}{
+		{"last owner leaves", single, "", true},
+		{"last owner becomes admin", single, model.GroupRoleAdmin, true},
+		{"last owner stays owner", single, model.GroupRoleOwner, false},
+		{"one of two owners leaves", shared, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := losesLastOwner(tt.g, owner, tt.role); got != tt.want {
+			t.Errorf("%s: loses last owner = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+
+	if losesLastOwner(single, alice, "") {
+		t.Error("removing a member loses the last owner")
+	}
+}
+
+func TestGroupActorChecks(t *testing.T) {
+	s := &GroupService{logger: slog.New(slog.DiscardHandler)}
+	ctx := requestctx.WithActor(context.Background(), alice.String())
+
+	if _, err := s.ListUserGroups(ctx, bob); !errors.Is(err, model.ErrGroupForbidden) {
+		t.Errorf("listing another user's groups: err = %v, want %v", err, model.ErrGroupForbidden)
+	}
+
+	if err := s.CreateGroup(context.Background(), &model.UserGroup{Name: "Home"}); !errors.Is(err, model.ErrGroupForbidden) {
+		t.Errorf("creating a group as the system: err = %v, want %v", err, model.ErrGroupForbidden)
+	}
+
+	if err := s.CreateGroup(ctx, &model.UserGroup{Name: "  "}); apperr.KindOf(err) != apperr.Invalid {
+		t.Errorf("creating a group without a name: err = %v, want an invalid error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+)
+
+var (
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still in progress")
+)
+
+type IdempotencyService struct {
+	repo   *repository.IdempotencyRepository
+	ttl    time.Duration
+	logger *slog.Logger
+}
+
+func NewIdempotencyService(repo *repository.IdempotencyRepository, ttl time.Duration, logger *slog.Logger) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl, logger: logger}
+}
+
+// Begin reserves the key for the request identified by fingerprint.
+// A nil record means the caller owns the key and must Complete or Release it;
+// otherwise the record holds the response to replay.
+func (s *IdempotencyService) Begin(ctx context.Context, key, fingerprint string) (*model.IdempotencyRecord, error) {
+
+	reserved, err := s.repo.Reserve(ctx, key, fingerprint, time.Now().Add(s.ttl))
+	if err != nil {
+		s.logger.Error("failed to reserve idempotency key", "error", err)
+		return nil, err
+	}
+
+	if reserved {
+		return nil, nil
+	}
+
+	rec, err := s.repo.Get(ctx, key)
+	if err != nil {
+		s.logger.Error("failed to get idempotency record", "error", err)
+		return nil, err
+	}
+
+	if rec.Fingerprint != fingerprint {
+		s.logger.Warn("idempotency key reused with different request", "key", key)
+		return nil, ErrIdempotencyKeyMismatch
+	}
+
+	if rec.StatusCode == nil {
+		s.logger.Warn("idempotency key is still in progress", "key", key)
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	s.logger.Info("replaying idempotent response", "key", key, "status_code", *rec.StatusCode)
+
+	return rec, nil
+}
+
+func (s *IdempotencyService) Complete(
+	ctx context.Context,
+	key string,
+	statusCode int,
+	headers map[string][]string,
+	body []byte,
+) error {
+	return s.repo.Complete(ctx, key, statusCode, headers, body)
+}
+
+func (s *IdempotencyService) Release(ctx context.Context, key string) error {
+	return s.repo.Release(ctx, key)
+}
+
+// RunPurge deletes expired keys every interval until ctx is done.
+func (s *IdempotencyService) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpired(ctx)
+			if err != nil {
+				s.logger.Error("failed to purge idempotency keys", "error", err)
+				continue
+			}
+			s.logger.Debug("idempotency keys purged", "count", deleted)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/utils"
+)
+
+// maxImportRows bounds the rows of one import, which runs in a single
+// transaction.
+const maxImportRows = 10000
+
+// importTagSeparator separates the tags in the tags column.
+const importTagSeparator = ";"
+
+// importFields are the subscription fields that can be imported, named
+// like the fields of the create request.
+var importFields = []string{
+	"service_id",
+	"service_name",
+	"price",
+	"user_id",
+	"start_month",
+	"end_month",
+	"trial_end",
+	"trial_price",
+	"category_id",
+	"tags",
+}
+
+// errImportRolledBack rolls back the import transaction of a dry run or a
+// failed atomic import.
+var errImportRolledBack = errors.New("import rolled back")
+
+type ImportService struct {
+	subs   *SubscriptionService
+	tx     *repository.Transactor
+	logger *slog.Logger
+}
+
+func NewImportService(subs *SubscriptionService, tx *repository.Transactor, logger *slog.Logger) *ImportService {
+	return &ImportService{subs: subs, tx: tx, logger: logger}
+}
+
+// ParseImportMapping parses a column mapping given as comma separated
+// field=column pairs, such as "price=Cost,user_id=Owner".
+func ParseImportMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, apperr.Invalidf("invalid mapping %q, want field=column", pair)
+		}
+		mapping[field] = column
+	}
+
+	return mapping, nil
+}
+
+// ImportCSV creates a subscription for every row of the CSV read from r,
+// validated like CreateSubscription. All rows are created in one
+// transaction; in atomic mode it is rolled back if any row is invalid, in
+// skip mode only the invalid rows are. A dry run always rolls back.
+func (s *ImportService) ImportCSV(ctx context.Context, r io.Reader, opts model.ImportOptions) (*model.ImportResult, error) {
+
+	if opts.Mode == "" {
+		opts.Mode = model.ImportAtomic
+	}
+	if opts.Mode != model.ImportAtomic && opts.Mode != model.ImportSkipInvalid {
+		s.logger.Warn("invalid import", "reason", "unknown mode", "mode", opts.Mode)
+		return nil, apperr.Invalidf("mode must be atomic or skip")
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		s.logger.Warn("invalid import", "reason", "header is not readable", "error", err)
+		return nil, apperr.Invalidf("invalid csv header: %w", err)
+	}
+
+	columns, err := importColumns(header, opts.Mapping)
+	if err != nil {
+		s.logger.Warn("invalid import", "reason", "columns do not match", "error", err)
+		return nil, err
+	}
+
+	result := &model.ImportResult{DryRun: opts.DryRun, Results: make([]model.ImportRowResult, 0)}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for {
+			record, row, err := readImportRow(reader)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			result.Rows++
+			if result.Rows > maxImportRows {
+				return apperr.Invalidf("import must not have more than %d rows", maxImportRows)
+			}
+
+			if row.Error == "" {
+				if err := s.importRow(ctx, record, columns, &row); err != nil {
+					row.Error = err.Error()
+				}
+			}
+
+			if row.Error == "" {
+				result.Valid++
+			}
+			result.Results = append(result.Results, row)
+		}
+
+		if opts.DryRun || (opts.Mode == model.ImportAtomic && result.Valid < result.Rows) {
+			return errImportRolledBack
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		s.logger.Error("failed to import subscriptions", "error", err)
+		return nil, err
+	}
+
+	if err == nil {
+		result.Imported = result.Valid
+	} else {
+		for i := range result.Results {
+			result.Results[i].SubscriptionID = nil
+		}
+	}
+
+	s.logger.Info("subscriptions imported",
+		"rows", result.Rows,
+		"valid", result.Valid,
+		"imported", result.Imported,
+		"dry_run", opts.DryRun,
+		"mode", opts.Mode,
+	)
+
+	return result, nil
+}
+
+// readImportRow reads the next record and the result of its row, numbered
+// by the line the record starts on. A record that is not valid CSV is
+// returned with the parse error in its row, so that the rows after it can
+// still be read. It returns io.EOF after the last record.
+func readImportRow(reader *csv.Reader) ([]string, model.ImportRowResult, error) {
+	record, err := reader.Read()
+
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		return nil, model.ImportRowResult{Row: parseErr.Line, Error: err.Error()}, nil
+	case err != nil:
+		return nil, model.ImportRowResult{}, err
+	}
+
+	var row model.ImportRowResult
+	if len(record) > 0 {
+		row.Row, _ = reader.FieldPos(0)
+	}
+
+	return record, row, nil
+}
+
+// importRow creates the subscription of record in a savepoint, so that an
+// invalid row does not abort the import transaction.
+func (s *ImportService) importRow(
+	ctx context.Context,
+	record []string,
+	columns map[string]int,
+	row *model.ImportRowResult,
+) error {
+	sub, err := parseImportRecord(record, columns)
+	if err != nil {
+		return err
+	}
+
+	err = s.tx.WithinSavepoint(ctx, func(ctx context.Context) error {
+		return s.subs.CreateSubscription(ctx, sub)
+	})
+	if err != nil {
+		return err
+	}
+
+	row.SubscriptionID = &sub.ID
+	return nil
+}
+
+// importColumns returns the index in header of the column of every mapped
+// field that header has.
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for field := range mapping {
+		if !slices.Contains(importFields, field) {
+			return nil, apperr.Invalidf("unknown field %q in mapping", field)
+		}
+	}
+
+	columns := make(map[string]int)
+	for _, field := range importFields {
+		name, ok := mapping[field]
+		if !ok {
+			name = field
+		}
+
+		i := slices.IndexFunc(header, func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), name)
+		})
+		if i >= 0 {
+			columns[field] = i
+		} else if ok {
+			return nil, apperr.Invalidf("column %q of field %s is missing", name, field)
+		}
+	}
+
+	for _, field := range []string{"user_id", "start_month"} {
+		if _, ok := columns[field]; !ok {
+			return nil, apperr.Invalidf("column of required field %s is missing", field)
+		}
+	}
+
+	_, hasName := columns["service_name"]
+	_, hasID := columns["service_id"]
+	if !hasName && !hasID {
+		return nil, apperr.Invalidf("column of service_name or service_id is missing")
+	}
+
+	return columns, nil
+}
+
+// parseImportRecord reads a subscription from record. Empty cells leave
+// their field unset.
+func parseImportRecord(record []string, columns map[string]int) (*model.Subscription, error) {
+	value := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	sub := &model.Subscription{ServiceName: value("service_name")}
+
+	var err error
+
+	if sub.UserId, err = utils.ParseUUIDFromString(value("user_id")); err != nil {
+		return nil, apperr.Invalidf("invalid user_id")
+	}
+
+	if sub.StartDate, err = utils.ParseMonthYear(value("start_month")); err != nil {
+		return nil, apperr.Invalidf("invalid start_month")
+	}
+
+	if v := value("end_month"); v != "" {
+		t, err := utils.ParseMonthYear(v)
+		if err != nil {
+			return nil, apperr.Invalidf("invalid end_month")
+		}
+		sub.EndDate = &t
+	}
+
+	if v := value("trial_end"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return nil, apperr.Invalidf("invalid trial_end")
+		}
+		sub.TrialEnd = &t
+	}
+
+	if v := value("price"); v != "" {
+		if sub.Price, err = strconv.Atoi(v); err != nil {
+			return nil, apperr.Invalidf("invalid price")
+		}
+	}
+
+	if v := value("trial_price"); v != "" {
+		if sub.TrialPrice, err = strconv.Atoi(v); err != nil {
+			return nil, apperr.Invalidf("invalid trial_price")
+		}
+	}
+
+	if v := value("service_id"); v != "" {
+		if sub.ServiceID, err = utils.ParseUUIDFromString(v); err != nil {
+			return nil, apperr.Invalidf("invalid service_id")
+		}
+	}
+
+	if v := value("category_id"); v != "" {
+		id, err := utils.ParseUUIDFromString(v)
+		if err != nil {
+			return nil, apperr.Invalidf("invalid category_id")
+		}
+		sub.CategoryID = &id
+	}
+
+	if v := value("tags"); v != "" {
+		sub.Tags = strings.Split(v, importTagSeparator)
+	}
+
+	return sub, nil
+}
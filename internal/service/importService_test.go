@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadImportRowMalformed(t *testing.T) {
+	input := strings.Join([]string{
+		"service_name,price,user_id,start_month",
+		"Netflix,400,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025",
+		`Spotify,2"00,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025`,
+		"Yandex Plus,300",
+		"Kinopoisk,500,60601fee-2bf1-4721-ae6f-7636e79a0cba,08-2025",
+		`"Okko,100,60601fee-2bf1-4721-ae6f-7636e79a0cba,08-2025`,
+	}, "\n")
+
+	reader := csv.NewReader(strings.NewReader(input))
+	reader.TrimLeadingSpace = true
+
+	if _, err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		line    int
+		service string
+		invalid bool
+	}{
+		{line: 2, service: "Netflix"},
+		{line: 3, invalid: true},
+		{line: 4, invalid: true},
+		{line: 5, service: "Kinopoisk"},
+		{line: 6, invalid: true},
+	}
+
+	for _, w := range want {
+		record, row, err := readImportRow(reader)
+		if err != nil {
+			t.Fatalf("line %d: %v", w.line, err)
+		}
+
+		if row.Row != w.line {
+			t.Errorf("row = %d, want %d", row.Row, w.line)
+		}
+		if invalid := row.Error != ""; invalid != w.invalid {
+			t.Errorf("line %d: error = %q, want invalid %t", w.line, row.Error, w.invalid)
+		}
+		if !w.invalid && record[0] != w.service {
+			t.Errorf("line %d: service = %s, want %s", w.line, record[0], w.service)
+		}
+	}
+
+	if _, _, err := readImportRow(reader); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/requestctx"
+
+	"github.com/google/uuid"
+)
+
+// AddMember shares the subscription with a user. Only its owner, the actor
+// of the request, can add members.
+func (s *SubscriptionService) AddMember(ctx context.Context, id uuid.UUID, m model.Member) (*model.Subscription, error) {
+	var sub *model.Subscription
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !actorIs(ctx, before.UserId) {
+			s.logger.Warn("member change denied", "id", id, "actor", requestctx.Actor(ctx))
+			return model.ErrNotOwner
+		}
+
+		if err := s.validateMember(before, m); err != nil {
+			return err
+		}
+
+		sub, err = s.repo.AddMember(ctx, id, m)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("failed to add subscription member", "error", err, "id", id, "user_id", m.UserID)
+		return nil, err
+	}
+
+	s.logger.Info("subscription member added", "id", id, "user_id", m.UserID)
+
+	return sub, nil
+}
+
+// RemoveMember stops sharing the subscription with a user. The owner can
+// remove any member and members can remove themselves.
+func (s *SubscriptionService) RemoveMember(ctx context.Context, id, userID uuid.UUID) (*model.Subscription, error) {
+	var sub *model.Subscription
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !actorIs(ctx, before.UserId) && !actorIs(ctx, userID) {
+			s.logger.Warn("member change denied", "id", id, "actor", requestctx.Actor(ctx))
+			return model.ErrNotOwner
+		}
+
+		sub, err = s.repo.RemoveMember(ctx, id, userID)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("failed to remove subscription member", "error", err, "id", id, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("subscription member removed", "id", id, "user_id", userID)
+
+	return sub, nil
+}
+
+func (s *SubscriptionService) validateMember(sub *model.Subscription, m model.Member) error {
+
+	if m.UserID == uuid.Nil {
+		s.logger.Warn("invalid member", "reason", "user_id is missing")
+		return apperr.Invalidf("user_id is required")
+	}
+
+	if (m.Weight == nil) == (m.Amount == nil) {
+		s.logger.Warn("invalid member", "reason", "not exactly one of weight and amount")
+		return apperr.Invalidf("exactly one of weight and amount is required")
+	}
+
+	if m.Weight != nil && *m.Weight <= 0 {
+		s.logger.Warn("invalid member", "reason", "weight is not positive")
+		return apperr.Invalidf("weight must be positive")
+	}
+
+	if m.Amount == nil {
+		return nil
+	}
+
+	if *m.Amount <= 0 {
+		s.logger.Warn("invalid member", "reason", "amount is not positive")
+		return apperr.Invalidf("amount must be positive")
+	}
+
+	if m.UserID == sub.UserId {
+		s.logger.Warn("invalid member", "reason", "owner with a fixed amount")
+		return apperr.Invalidf("the owner can only be listed with a weight")
+	}
+
+	fixed := *m.Amount
+	for _, other := range sub.Members {
+		if other.Amount != nil {
+			fixed += *other.Amount
+		}
+	}
+	if fixed > sub.Price {
+		s.logger.Warn("invalid member", "reason", "fixed amounts exceed price", "fixed", fixed, "price", sub.Price)
+		return apperr.Invalidf("fixed amounts must not exceed the price")
+	}
+
+	return nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/mail"
+	"slices"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/notify"
+	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// notificationClaimTimeout is how long a claimed notification may stay
+// unsent before another replica retries it.
+const notificationClaimTimeout = 10 * time.Minute
+
+type NotificationService struct {
+	repo            *repository.NotificationRepository
+	subs            *repository.SubscriptionRepository
+	notifiers       map[string]notify.Notifier
+	renewalLeadDays int
+	endLeadDays     int
+	logger          *slog.Logger
+}
+
+func NewNotificationService(
+	repo *repository.NotificationRepository,
+	subs *repository.SubscriptionRepository,
+	notifiers map[string]notify.Notifier,
+	renewalLeadDays int,
+	endLeadDays int,
+	logger *slog.Logger,
+) *NotificationService {
+	return &NotificationService{
+		repo:            repo,
+		subs:            subs,
+		notifiers:       notifiers,
+		renewalLeadDays: renewalLeadDays,
+		endLeadDays:     endLeadDays,
+		logger:          logger,
+	}
+}
+
+func (s *NotificationService) GetSettings(ctx context.Context, userID uuid.UUID) (*model.NotificationSettings, error) {
+	return s.repo.GetSettings(ctx, userID)
+}
+
+func (s *NotificationService) SaveSettings(ctx context.Context, settings *model.NotificationSettings) error {
+
+	if settings.Email != nil {
+		if _, err := mail.ParseAddress(*settings.Email); err != nil {
+			s.logger.Warn("invalid notification settings", "reason", "email is not valid")
+			return apperr.Invalidf("email is not valid")
+		}
+	}
+
+	if settings.RenewalLeadDays != nil && *settings.RenewalLeadDays < 0 {
+		s.logger.Warn("invalid notification settings", "reason", "renewal lead days is negative")
+		return apperr.Invalidf("renewal_lead_days must not be negative")
+	}
+
+	if settings.EndLeadDays != nil && *settings.EndLeadDays < 0 {
+		s.logger.Warn("invalid notification settings", "reason", "end lead days is negative")
+		return apperr.Invalidf("end_lead_days must not be negative")
+	}
+
+	return s.repo.SaveSettings(ctx, settings)
+}
+
+// Send delivers n through every notifier once per key, however many
+// replicas or restarts try to send it. Delivery is tracked per notifier: a
+// failed send is released and retried by the next caller through that
+// notifier only. claimed reports whether this call tried any notifier.
+func (s *NotificationService) Send(ctx context.Context, key string, n model.Notification) (claimed bool, err error) {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(s.notifiers)) {
+		ok, err := s.sendThrough(ctx, name, key+":"+name, n)
+		claimed = claimed || ok
+		errs = append(errs, err)
+	}
+
+	return claimed, errors.Join(errs...)
+}
+
+func (s *NotificationService) sendThrough(ctx context.Context, name, key string, n model.Notification) (bool, error) {
+
+	claimed, err := s.repo.Claim(ctx, key, n.Kind, n.UserID, notificationClaimTimeout)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if notifyErr := s.notifiers[name].Notify(ctx, n); notifyErr != nil {
+		s.logger.Error("failed to send notification", "error", notifyErr, "key", key, "notifier", name)
+		if err := s.repo.Release(ctx, key); err != nil {
+			s.logger.Error("failed to release notification", "error", err, "key", key)
+		}
+		return true, notifyErr
+	}
+
+	s.logger.Info("notification sent", "key", key, "user_id", n.UserID, "notifier", name)
+
+	return true, s.repo.MarkSent(ctx, key)
+}
+
+// RunReminders checks every interval for upcoming renewals and ends of
+// subscriptions and sends reminders for those within the user's lead time.
+func (s *NotificationService) RunReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendReminders(ctx, time.Now().UTC())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *NotificationService) sendReminders(ctx context.Context, now time.Time) {
+	subs, err := s.subs.List(ctx, model.SubscriptionFilter{})
+	if err != nil {
+		s.logger.Error("failed to list subscriptions for reminders", "error", err)
+		return
+	}
+
+	settings, err := s.repo.ListSettings(ctx)
+	if err != nil {
+		s.logger.Error("failed to list notification settings", "error", err)
+		return
+	}
+
+	for _, sub := range subs {
+		userSettings := settings[sub.UserId]
+		if userSettings == nil {
+			userSettings = &model.NotificationSettings{UserID: sub.UserId}
+		}
+
+		for _, n := range s.reminders(sub, userSettings, now) {
+			key := reminderKey(sub, n)
+			if _, err := s.Send(ctx, key, n); err != nil {
+				s.logger.Error("failed to send reminder", "error", err, "key", key)
+			}
+		}
+	}
+}
+
+// reminderKey identifies a reminder by its kind, subscription and date, so
+// that it is sent once however many ticks find it due.
+func reminderKey(sub *model.Subscription, n model.Notification) string {
+	return fmt.Sprintf("%s:%s:%s", n.Kind, sub.ID, n.Date.Format(time.DateOnly))
+}
+
+// reminders returns the reminders due at now for the subscription.
+func (s *NotificationService) reminders(
+	sub *model.Subscription,
+	settings *model.NotificationSettings,
+	now time.Time,
+) []model.Notification {
+	var (
+		result      []model.Notification
+		renewalLead = leadTime(settings.RenewalLeadDays, s.renewalLeadDays)
+		endLead     = leadTime(settings.EndLeadDays, s.endLeadDays)
+	)
+
+	if renewal, ok := nextRenewal(sub, now); ok && renewal.Sub(now) <= renewalLead {
+		result = append(result, s.notification(sub, settings, model.NotificationRenewal, renewal,
+			fmt.Sprintf("%s renews on %s", sub.ServiceName, renewal.Format(time.DateOnly)),
+			fmt.Sprintf("Your %s subscription renews on %s for %d.",
+				sub.ServiceName, renewal.Format(time.DateOnly), priceAt(sub, renewal)),
+		))
+	}
+
+	if sub.EndDate != nil {
+		end := endOfService(sub)
+		if end.After(now) && end.Sub(now) <= endLead {
+			result = append(result, s.notification(sub, settings, model.NotificationEnd, end,
+				fmt.Sprintf("%s ends on %s", sub.ServiceName, end.Format(time.DateOnly)),
+				fmt.Sprintf("Your %s subscription ends on %s. The last billed month is %s.",
+					sub.ServiceName, end.Format(time.DateOnly), utils.ParseMonthYearToString(*sub.EndDate)),
+			))
+		}
+	}
+
+	return result
+}
+
+func (s *NotificationService) notification(
+	sub *model.Subscription,
+	settings *model.NotificationSettings,
+	kind string,
+	date time.Time,
+	subject, body string,
+) model.Notification {
+	n := model.Notification{
+		Kind:           kind,
+		UserID:         sub.UserId,
+		SubscriptionID: &sub.ID,
+		Date:           date,
+		Subject:        subject,
+		Body:           body,
+	}
+	if settings.Email != nil {
+		n.Email = *settings.Email
+	}
+	return n
+}
+
+// nextRenewal returns the first charge after now, not counting the initial
+// charge on the start date. Subscriptions are charged monthly through the
+// end month, except while paused.
+func nextRenewal(sub *model.Subscription, now time.Time) (time.Time, bool) {
+	months := (now.Year()-sub.StartDate.Year())*12 + int(now.Month()-sub.StartDate.Month())
+	months = max(months, 0)
+
+	for {
+		renewal := sub.StartDate.AddDate(0, months, 0)
+		months++
+
+		if sub.EndDate != nil && renewal.After(*sub.EndDate) {
+			return time.Time{}, false
+		}
+		if !renewal.After(now) || renewal.Equal(sub.StartDate) {
+			continue
+		}
+
+		for _, p := range sub.Pauses {
+			if p.Covers(renewal) && p.ResumeDate == nil {
+				return time.Time{}, false
+			}
+		}
+		if !sub.PausedAt(renewal) {
+			return renewal, true
+		}
+	}
+}
+
+// endOfService is the first day after the last billed month of a
+// subscription with an end date.
+func endOfService(sub *model.Subscription) time.Time {
+	return sub.EndDate.AddDate(0, 1, 0)
+}
+
+func leadTime(days *int, defaultDays int) time.Duration {
+	if days != nil {
+		return time.Duration(*days) * 24 * time.Hour
+	}
+	return time.Duration(defaultDays) * 24 * time.Hour
+}
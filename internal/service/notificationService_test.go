@@ -0,0 +1,122 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+func TestNextRenewal(t *testing.T) {
+	now := time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		start   time.Time
+		endDate *time.Time
+		pauses  []model.Pause
+		want    time.Time // zero if there is no renewal
+	}{
+		{
+			name:  "next month",
+			start: month(2025, time.January),
+			want:  month(2025, time.April),
+		},
+		{
+			name:  "the first charge is not a renewal",
+			start: month(2025, time.April),
+			want:  month(2025, time.May),
+		},
+		{
+			name:   "pause ending at the renewal",
+			start:  month(2025, time.January),
+			pauses: []model.Pause{{StartDate: month(2025, time.March), ResumeDate: monthPtr(2025, time.April)}},
+			want:   month(2025, time.April),
+		},
+		{
+			name:   "renewal inside a pause",
+			start:  month(2025, time.January),
+			pauses: []model.Pause{{StartDate: month(2025, time.April), ResumeDate: monthPtr(2025, time.June)}},
+			want:   month(2025, time.June),
+		},
+		{
+			name:   "open pause",
+			start:  month(2025, time.January),
+			pauses: []model.Pause{{StartDate: month(2025, time.May)}},
+			want:   month(2025, time.April),
+		},
+		{
+			name:   "renewal inside an open pause",
+			start:  month(2025, time.January),
+			pauses: []model.Pause{{StartDate: month(2025, time.March)}},
+		},
+		{
+			name:    "end month inside a pause",
+			start:   month(2025, time.January),
+			endDate: monthPtr(2025, time.May),
+			pauses:  []model.Pause{{StartDate: month(2025, time.April), ResumeDate: monthPtr(2025, time.July)}},
+		},
+		{
+			name:    "ended",
+			start:   month(2025, time.January),
+			endDate: monthPtr(2025, time.March),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{StartDate: tt.start, EndDate: tt.endDate, Pauses: tt.pauses}
+
+			got, ok := nextRenewal(sub, now)
+			if ok != !tt.want.IsZero() || !got.Equal(tt.want) {
+				t.Errorf("renewal = %s, %t, want %s", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestReminderKeys(t *testing.T) {
+	s := &NotificationService{renewalLeadDays: 3, endLeadDays: 7}
+
+	sub := &model.Subscription{
+		ID:          owner,
+		UserId:      owner,
+		ServiceName: "Netflix",
+		Price:       100,
+		StartDate:   month(2025, time.January),
+		EndDate:     monthPtr(2025, time.April),
+	}
+	settings := &model.NotificationSettings{UserID: owner}
+
+	keys := func(now time.Time) []string {
+		var keys []string
+		for _, n := range s.reminders(sub, settings, now) {
+			keys = append(keys, reminderKey(sub, n))
+		}
+		return keys
+	}
+
+	renewal := "renewal:" + owner.String() + ":2025-04-01"
+	end := "end:" + owner.String() + ":2025-05-01"
+
+	tests := []struct {
+		now  time.Time
+		want []string
+	}{
+		{time.Date(2025, time.March, 28, 23, 59, 0, 0, time.UTC), nil},
+		// Every tick within the lead time finds the same reminder.
+		{time.Date(2025, time.March, 29, 0, 0, 0, 0, time.UTC), []string{renewal}},
+		{time.Date(2025, time.March, 30, 12, 0, 0, 0, time.UTC), []string{renewal}},
+		{time.Date(2025, time.March, 31, 23, 0, 0, 0, time.UTC), []string{renewal}},
+		{time.Date(2025, time.April, 24, 0, 0, 0, 0, time.UTC), []string{end}},
+		{time.Date(2025, time.April, 30, 23, 0, 0, 0, time.UTC), []string{end}},
+		{time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), nil},
+	}
+
+	for _, tt := range tests {
+		if got := keys(tt.now); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: keys = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/sink"
+)
+
+const (
+	maxRelayBackoff = 5 * time.Minute
+	// relayClaimTimeout is how long a relay may take to publish a claimed
+	// batch. Messages left over are claimed again once it has passed.
+	relayClaimTimeout = 5 * time.Minute
+)
+
+// OutboxService relays events from the outbox to a sink. Delivery is
+// at-least-once: an event is marked published only after the sink accepted
+// it, and a failed event holds back later events of the same subscription.
+// Events are published outside of any transaction, so a slow sink holds
+// no database connection or lock.
+type OutboxService struct {
+	repo      *repository.OutboxRepository
+	sink      sink.Sink
+	batchSize int
+	logger    *slog.Logger
+}
+
+func NewOutboxService(
+	repo *repository.OutboxRepository,
+	sink sink.Sink,
+	batchSize int,
+	logger *slog.Logger,
+) *OutboxService {
+	return &OutboxService{repo: repo, sink: sink, batchSize: batchSize, logger: logger}
+}
+
+// RunRelay publishes pending events every interval until ctx is done.
+// A full batch is followed by the next one right away.
+func (s *OutboxService) RunRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				n, err := s.relayBatch(ctx)
+				if err != nil {
+					s.logger.Error("failed to relay outbox batch", "error", err)
+					break
+				}
+				if n < s.batchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (s *OutboxService) relayBatch(ctx context.Context) (int, error) {
+	claimedUntil := time.Now().Add(relayClaimTimeout)
+
+	msgs, err := s.repo.ClaimPending(ctx, s.batchSize, claimedUntil)
+	if err != nil {
+		return 0, err
+	}
+
+	publishCtx, cancel := context.WithDeadline(ctx, claimedUntil)
+	defer cancel()
+
+	for _, m := range msgs {
+		if publishCtx.Err() != nil {
+			s.logger.Warn("outbox batch not published within the claim timeout", "remaining_from_seq", m.Seq)
+			break
+		}
+
+		if err := s.sink.Publish(publishCtx, m.Event); err != nil {
+			s.logger.Warn("failed to publish event",
+				"error", err,
+				"event_id", m.Event.ID,
+				"type", m.Event.Type,
+				"attempts", m.Attempts+1,
+			)
+			if err := s.repo.MarkFailed(ctx, m.Seq, err, time.Now().Add(relayBackoff(m.Attempts))); err != nil {
+				return len(msgs), err
+			}
+			continue
+		}
+
+		if err := s.repo.MarkPublished(ctx, m.Seq); err != nil {
+			return len(msgs), err
+		}
+		s.logger.Debug("event published", "event_id", m.Event.ID, "type", m.Event.Type)
+	}
+
+	return len(msgs), nil
+}
+
+func relayBackoff(attempts int) time.Duration {
+	backoff := time.Second << min(attempts, 16)
+	return min(backoff, maxRelayBackoff)
+}
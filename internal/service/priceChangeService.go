@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+type PriceChangeService struct {
+	repo   *repository.PriceChangeRepository
+	subs   *SubscriptionService
+	tx     *repository.Transactor
+	logger *slog.Logger
+}
+
+func NewPriceChangeService(
+	repo *repository.PriceChangeRepository,
+	subs *SubscriptionService,
+	tx *repository.Transactor,
+	logger *slog.Logger,
+) *PriceChangeService {
+	return &PriceChangeService{repo: repo, subs: subs, tx: tx, logger: logger}
+}
+
+// SchedulePriceChange sets the price of the subscription from the given
+// month on, which must be after the current month and within the
+// subscription.
+func (s *PriceChangeService) SchedulePriceChange(ctx context.Context, change *model.ScheduledPriceChange) error {
+
+	if change.Price <= 0 {
+		s.logger.Warn("invalid price change", "reason", "price is not positive")
+		return apperr.Invalidf("price must be positive")
+	}
+
+	if !change.EffectiveDate.After(time.Now().UTC()) {
+		s.logger.Warn("invalid price change", "reason", "effective month is not in the future")
+		return apperr.Invalidf("effective month must be after the current month")
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		sub, err := s.subs.LockSubscription(ctx, change.SubscriptionID)
+		if err != nil {
+			return err
+		}
+
+		if sub.EndDate != nil && change.EffectiveDate.After(*sub.EndDate) {
+			s.logger.Warn("invalid price change", "reason", "effective month is after subscription end")
+			return apperr.Invalidf("effective month must not be after the subscription ends")
+		}
+
+		return s.repo.Create(ctx, change)
+	})
+	if err != nil {
+		s.logger.Error("failed to schedule price change", "error", err, "subscription_id", change.SubscriptionID)
+		return err
+	}
+
+	s.logger.Info("price change scheduled", "id", change.ID, "subscription_id", change.SubscriptionID)
+
+	return nil
+}
+
+// ListPriceChanges returns the applied and pending price changes of the
+// subscription.
+func (s *PriceChangeService) ListPriceChanges(ctx context.Context, subscriptionID uuid.UUID) ([]*model.ScheduledPriceChange, error) {
+
+	if _, err := s.subs.GetSubscriptionById(ctx, subscriptionID, false); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.repo.List(ctx, subscriptionID)
+	if err != nil {
+		s.logger.Error("failed to list price changes", "error", err, "subscription_id", subscriptionID)
+		return nil, err
+	}
+
+	s.logger.Info("price changes fetched", "subscription_id", subscriptionID, "count", len(changes))
+
+	return changes, nil
+}
+
+// ListPriceChangesBySubscriptions returns the applied and pending price
+// changes of several subscriptions by subscription ID. Unlike
+// ListPriceChanges it does not check that the subscriptions exist.
+func (s *PriceChangeService) ListPriceChangesBySubscriptions(
+	ctx context.Context,
+	subscriptionIDs []uuid.UUID,
+) (map[uuid.UUID][]*model.ScheduledPriceChange, error) {
+
+	changes, err := s.repo.ListBySubscriptions(ctx, subscriptionIDs)
+	if err != nil {
+		s.logger.Error("failed to list price changes", "error", err, "subscriptions", len(subscriptionIDs))
+		return nil, err
+	}
+
+	bySubscription := make(map[uuid.UUID][]*model.ScheduledPriceChange, len(subscriptionIDs))
+	for _, c := range changes {
+		bySubscription[c.SubscriptionID] = append(bySubscription[c.SubscriptionID], c)
+	}
+
+	return bySubscription, nil
+}
+
+// CancelPriceChange removes a price change that was not applied yet.
+func (s *PriceChangeService) CancelPriceChange(ctx context.Context, subscriptionID, id uuid.UUID) error {
+
+	if err := s.repo.Delete(ctx, subscriptionID, id); err != nil {
+		s.logger.Error("failed to cancel price change", "error", err, "id", id)
+		return err
+	}
+
+	s.logger.Info("price change cancelled", "id", id, "subscription_id", subscriptionID)
+
+	return nil
+}
+
+// RunPriceChanges sets the price of every subscription whose scheduled
+// price change has taken effect, checking every interval until ctx is done.
+func (s *PriceChangeService) RunPriceChanges(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.applyPriceChanges(ctx, batchSize); err != nil {
+				s.logger.Error("failed to apply price changes", "error", err)
+			}
+		}
+	}
+}
+
+func (s *PriceChangeService) applyPriceChanges(ctx context.Context, batchSize int) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		changes, err := s.repo.LockDue(ctx, time.Now().UTC(), batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, change := range changes {
+			previous, err := s.subs.ChangePrice(ctx, change.SubscriptionID, change.Price)
+			if err != nil {
+				return err
+			}
+
+			if err := s.repo.MarkApplied(ctx, change.ID, previous); err != nil {
+				return err
+			}
+
+			s.logger.Info("price change applied", "id", change.ID, "subscription_id", change.SubscriptionID, "price", change.Price)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	uncategorized = "Uncategorized"
+
+	// maxReportMonths bounds the window of the spend report to 100 years.
+	maxReportMonths = 1200
+)
+
+var granularities = []string{model.GranularityMonth, model.GranularityQuarter, model.GranularityYear}
+
+type ReportService struct {
+	subs             *repository.SubscriptionRepository
+	catalog          *repository.CatalogRepository
+	categories       *repository.CategoryRepository
+	priceChanges     *repository.PriceChangeRepository
+	renewalThreshold int
+	logger           *slog.Logger
+}
+
+func NewReportService(
+	subs *repository.SubscriptionRepository,
+	catalog *repository.CatalogRepository,
+	categories *repository.CategoryRepository,
+	priceChanges *repository.PriceChangeRepository,
+	renewalThreshold int,
+	logger *slog.Logger,
+) *ReportService {
+	return &ReportService{
+		subs:             subs,
+		catalog:          catalog,
+		categories:       categories,
+		priceChanges:     priceChanges,
+		renewalThreshold: renewalThreshold,
+		logger:           logger,
+	}
+}
+
+// Spend returns the charges of every month, quarter or year in the window.
+// Charges follow the same rules as the other costs: every month is charged
+// at the price in effect that month, or the trial price before the end of
+// the trial, except while paused. For a user, the subscriptions the user is
+// a member of are included and only the user's share of each charge counts.
+func (s *ReportService) Spend(ctx context.Context, filter model.SpendFilter) ([]model.SpendBucket, error) {
+
+	if filter.Granularity == "" {
+		filter.Granularity = model.GranularityMonth
+	}
+	if !slices.Contains(granularities, filter.Granularity) {
+		s.logger.Warn("invalid report period", "reason", "unknown granularity")
+		return nil, apperr.Invalidf("granularity must be month, quarter or year")
+	}
+
+	if filter.End.Before(filter.Start) {
+		s.logger.Warn("invalid report period", "reason", "end is before start")
+		return nil, apperr.Invalidf("end must not be before start")
+	}
+
+	if monthsBetween(filter.Start, filter.End) > maxReportMonths {
+		s.logger.Warn("invalid report period", "reason", "window is too long")
+		return nil, apperr.Invalidf("window must not be longer than %d months", maxReportMonths)
+	}
+
+	buckets := newSpendBuckets(filter)
+
+	err := s.subs.Stream(ctx, model.SubscriptionFilter{MemberID: filter.UserID}, func(sub *model.Subscription) error {
+		buckets.add(sub)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to calculate spend report", "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("spend report calculated", "buckets", len(buckets.buckets), "granularity", filter.Granularity)
+
+	return buckets.buckets, nil
+}
+
+// spendBuckets sums up subscriptions into the buckets of a spend report.
+type spendBuckets struct {
+	filter  model.SpendFilter
+	buckets []model.SpendBucket
+	index   map[time.Time]int // bucket of each period
+}
+
+func newSpendBuckets(filter model.SpendFilter) *spendBuckets {
+	b := &spendBuckets{filter: filter, buckets: make([]model.SpendBucket, 0), index: make(map[time.Time]int)}
+
+	for month := filter.Start; !month.After(filter.End); month = nextMonth(month) {
+		period := bucketPeriod(month, filter.Granularity)
+		if _, ok := b.index[period]; !ok {
+			b.index[period] = len(b.buckets)
+			b.buckets = append(b.buckets, model.SpendBucket{Period: period})
+		}
+	}
+
+	return b
+}
+
+// add counts the charges of sub in the window, and sub as new or ended in
+// the buckets of its first and last month if they are in the window.
+func (b *spendBuckets) add(sub *model.Subscription) {
+	var counted *model.SpendBucket
+
+	eachCharge(sub, b.filter.Start, b.filter.End, func(month time.Time, price, charge int) {
+		bucket := b.bucket(month)
+
+		amount := charge
+		if b.filter.UserID != nil {
+			amount = memberShare(sub, price, charge, *b.filter.UserID)
+		}
+		bucket.Total += amount
+
+		// Months come in order, so a subscription is counted once per bucket.
+		if bucket != counted {
+			bucket.Subscriptions++
+			counted = bucket
+		}
+	})
+
+	if b.inWindow(sub.StartDate) {
+		b.bucket(sub.StartDate).New++
+	}
+	if sub.EndDate != nil && b.inWindow(*sub.EndDate) {
+		b.bucket(*sub.EndDate).Ended++
+	}
+}
+
+func (b *spendBuckets) bucket(month time.Time) *model.SpendBucket {
+	return &b.buckets[b.index[bucketPeriod(month, b.filter.Granularity)]]
+}
+
+func (b *spendBuckets) inWindow(month time.Time) bool {
+	return !month.Before(b.filter.Start) && !month.After(b.filter.End)
+}
+
+// bucketPeriod returns the first month of the month, quarter or year that
+// month falls in.
+func bucketPeriod(month time.Time, granularity string) time.Time {
+	switch granularity {
+	case model.GranularityQuarter:
+		return time.Date(month.Year(), (month.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case model.GranularityYear:
+		return time.Date(month.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// CostByCategory returns what the subscriptions cost in the months from
+// start through end, grouped by category and most expensive first. Unless
+// userID is nil, only the user's share of the subscriptions the user owns or
+// is a member of counts. Subscriptions that are not charged in the period
+// are left out.
+func (s *ReportService) CostByCategory(
+	ctx context.Context,
+	userID *uuid.UUID,
+	start, end time.Time,
+) ([]model.CategoryCost, error) {
+
+	if end.Before(start) {
+		s.logger.Warn("invalid report period", "reason", "end is before start")
+		return nil, apperr.Invalidf("end must not be before start")
+	}
+
+	subs, err := s.subs.List(ctx, model.SubscriptionFilter{MemberID: userID})
+	if err != nil {
+		s.logger.Error("failed to list subscriptions for report", "error", err)
+		return nil, err
+	}
+
+	categories, err := s.categories.List(ctx)
+	if err != nil {
+		s.logger.Error("failed to list categories for report", "error", err)
+		return nil, err
+	}
+
+	names := make(map[uuid.UUID]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+
+	result := costByCategory(subs, names, userID, start, end)
+
+	s.logger.Info("cost by category calculated", "categories", len(result))
+
+	return result, nil
+}
+
+// costByCategory groups the cost of subs in the months from start through
+// end, or userID's share of it unless nil, by category, named after names.
+func costByCategory(
+	subs []*model.Subscription,
+	names map[uuid.UUID]string,
+	userID *uuid.UUID,
+	start, end time.Time,
+) []model.CategoryCost {
+	groups := make(map[uuid.UUID]*model.CategoryCost)
+
+	for _, sub := range subs {
+		cost := subscriptionCost(sub, start, end)
+		if userID != nil {
+			cost = memberCost(sub, *userID, start, end)
+		}
+		if cost == 0 {
+			continue
+		}
+
+		var key uuid.UUID
+		if sub.CategoryID != nil {
+			key = *sub.CategoryID
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = &model.CategoryCost{CategoryID: sub.CategoryID, CategoryName: uncategorized}
+			if sub.CategoryID != nil {
+				group.CategoryName = names[*sub.CategoryID]
+			}
+			groups[key] = group
+		}
+
+		group.Total += cost
+		group.Subscriptions++
+	}
+
+	result := make([]model.CategoryCost, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].CategoryName < result[j].CategoryName
+	})
+
+	return result
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestSpendBuckets(t *testing.T) {
+	ended := month(2025, time.April)
+	resumed := month(2025, time.April)
+	short := &model.Subscription{
+		UserId:    owner,
+		Price:     100,
+		StartDate: month(2025, time.February),
+		EndDate:   &ended,
+		Pauses:    []model.Pause{{StartDate: month(2025, time.March), ResumeDate: &resumed}},
+	}
+	shared := &model.Subscription{
+		UserId:       owner,
+		Price:        300,
+		StartDate:    month(2024, time.June),
+		Members:      []model.Member{weight(alice, 1)},
+		PricePeriods: []model.PricePeriod{{Price: 200, Until: month(2025, time.July)}},
+	}
+
+	filter := model.SpendFilter{
+		Start:       month(2025, time.January),
+		End:         month(2025, time.December),
+		Granularity: model.GranularityQuarter,
+	}
+
+	t.Run("all", func(t *testing.T) {
+		b := newSpendBuckets(filter)
+		b.add(short)
+		b.add(shared)
+
+		want := []model.SpendBucket{
+			{Period: month(2025, time.January), Total: 700, Subscriptions: 2, New: 1},
+			{Period: month(2025, time.April), Total: 700, Subscriptions: 2, Ended: 1},
+			{Period: month(2025, time.July), Total: 900, Subscriptions: 1},
+			{Period: month(2025, time.October), Total: 900, Subscriptions: 1},
+		}
+		if !reflect.DeepEqual(b.buckets, want) {
+			t.Errorf("buckets = %+v, want %+v", b.buckets, want)
+		}
+	})
+
+	t.Run("member", func(t *testing.T) {
+		filter := filter
+		filter.UserID = &alice
+
+		b := newSpendBuckets(filter)
+		b.add(shared)
+
+		want := []model.SpendBucket{
+			{Period: month(2025, time.January), Total: 300, Subscriptions: 1},
+			{Period: month(2025, time.April), Total: 300, Subscriptions: 1},
+			{Period: month(2025, time.July), Total: 450, Subscriptions: 1},
+			{Period: month(2025, time.October), Total: 450, Subscriptions: 1},
+		}
+		if !reflect.DeepEqual(b.buckets, want) {
+			t.Errorf("buckets = %+v, want %+v", b.buckets, want)
+		}
+	})
+}
+
+func TestBucketPeriod(t *testing.T) {
+	tests := []struct {
+		granularity string
+		want        time.Time
+	}{
+		{model.GranularityMonth, month(2025, time.August)},
+		{model.GranularityQuarter, month(2025, time.July)},
+		{model.GranularityYear, month(2025, time.January)},
+	}
+
+	for _, tt := range tests {
+		if got := bucketPeriod(month(2025, time.August), tt.granularity); !got.Equal(tt.want) {
+			t.Errorf("%s: period = %s, want %s", tt.granularity, got, tt.want)
+		}
+	}
+}
+
+func TestCostByCategory(t *testing.T) {
+	video := uuid.MustParse("5c1e0b7a-3f0e-4d6a-9b1a-2f7d9c0e1a01")
+	music := uuid.MustParse("5c1e0b7a-3f0e-4d6a-9b1a-2f7d9c0e1a02")
+	names := map[uuid.UUID]string{video: "Video", music: "Music"}
+
+	subs := []*model.Subscription{
+		{UserId: owner, Price: 300, StartDate: month(2025, time.January), CategoryID: &video},
+		{UserId: owner, Price: 200, StartDate: month(2025, time.January), CategoryID: &video,
+			Members: []model.Member{weight(alice, 1)}},
+		{UserId: owner, Price: 150, StartDate: month(2025, time.January), CategoryID: &music},
+		{UserId: owner, Price: 100, StartDate: month(2025, time.January)},
+		{UserId: owner, Price: 999, StartDate: month(2026, time.January), CategoryID: &music},
+	}
+
+	t.Run("all", func(t *testing.T) {
+		got := costByCategory(subs, names, nil, month(2025, time.January), month(2025, time.February))
+
+		want := []model.CategoryCost{
+			{CategoryID: &video, CategoryName: "Video", Total: 1000, Subscriptions: 2},
+			{CategoryID: &music, CategoryName: "Music", Total: 300, Subscriptions: 1},
+			{CategoryName: uncategorized, Total: 200, Subscriptions: 1},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("costs = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("member", func(t *testing.T) {
+		got := costByCategory(subs, names, &alice, month(2025, time.January), month(2025, time.February))
+
+		want := []model.CategoryCost{
+			{CategoryID: &video, CategoryName: "Video", Total: 200, Subscriptions: 1},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("costs = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := normalizeTags([]string{" Work ", "family", "WORK", "Shared  Plan"})
+
+	want := []string{"work", "family", "shared plan"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tags = %q, want %q", got, want)
+	}
+}
+
+func TestSpendBucketsPartialQuarters(t *testing.T) {
+	filter := model.SpendFilter{
+		Start:       month(2025, time.February),
+		End:         month(2025, time.April),
+		Granularity: model.GranularityQuarter,
+	}
+	sub := &model.Subscription{UserId: owner, Price: 100, StartDate: month(2025, time.January)}
+
+	b := newSpendBuckets(filter)
+	b.add(sub)
+
+	// Only the months in the window are charged, the subscription started
+	// before it.
+	want := []model.SpendBucket{
+		{Period: month(2025, time.January), Total: 200, Subscriptions: 1},
+		{Period: month(2025, time.April), Total: 100, Subscriptions: 1},
+	}
+	if !reflect.DeepEqual(b.buckets, want) {
+		t.Errorf("buckets = %+v, want %+v", b.buckets, want)
+	}
+}
+
+func TestSpendValidation(t *testing.T) {
+	s := &ReportService{logger: slog.New(slog.DiscardHandler)}
+
+	tests := []struct {
+		name   string
+		filter model.SpendFilter
+	}{
+		{
+			name:   "unknown granularity",
+			filter: model.SpendFilter{Start: month(2025, time.January), End: month(2025, time.March), Granularity: "week"},
+		},
+		{
+			name:   "end before start",
+			filter: model.SpendFilter{Start: month(2025, time.March), End: month(2025, time.January)},
+		},
+		{
+			name:   "window too long",
+			filter: model.SpendFilter{Start: month(1900, time.January), End: month(2025, time.January)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Spend(context.Background(), tt.filter); apperr.KindOf(err) != apperr.Invalid {
+				t.Errorf("spend = %v, want an invalid error", err)
+			}
+		})
+	}
+}
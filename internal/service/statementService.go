@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/statement"
+
+	"github.com/google/uuid"
+)
+
+type StatementService struct {
+	repo    *repository.CandidateRepository
+	subs    *SubscriptionService
+	catalog *repository.CatalogRepository
+	tx      *repository.Transactor
+	logger  *slog.Logger
+}
+
+func NewStatementService(
+	repo *repository.CandidateRepository,
+	subs *SubscriptionService,
+	catalog *repository.CatalogRepository,
+	tx *repository.Transactor,
+	logger *slog.Logger,
+) *StatementService {
+	return &StatementService{repo: repo, subs: subs, catalog: catalog, tx: tx, logger: logger}
+}
+
+// ImportStatement finds the recurring charges in a bank statement of the
+// user and stores them as subscription candidates. A charge of a service
+// the user already subscribes to is stored as an existing candidate.
+// Importing overlapping statements again updates the same candidates,
+// except those already accepted or rejected, which are not returned.
+func (s *StatementService) ImportStatement(
+	ctx context.Context,
+	userID uuid.UUID,
+	data []byte,
+	format string,
+	opts statement.Options,
+) ([]*model.SubscriptionCandidate, error) {
+	txs, err := statement.Parse(data, format, opts)
+	if err != nil {
+		s.logger.Warn("invalid bank statement", "error", err, "user_id", userID)
+		return nil, apperr.Invalidf("%w", err)
+	}
+
+	subs, err := s.subs.repo.GetListByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to get subscriptions of user", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	candidates := make([]*model.SubscriptionCandidate, 0)
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, r := range statement.FindRecurring(txs) {
+			c, err := s.candidate(ctx, userID, r, subs)
+			if err != nil {
+				return err
+			}
+
+			stored, err := s.repo.Upsert(ctx, c)
+			if err != nil {
+				return err
+			}
+			if stored {
+				candidates = append(candidates, c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to import bank statement", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("bank statement imported", "user_id", userID, "transactions", len(txs), "candidates", len(candidates))
+
+	return candidates, nil
+}
+
+// candidate matches a recurring charge against the catalog and the
+// subscriptions of the user.
+func (s *StatementService) candidate(
+	ctx context.Context,
+	userID uuid.UUID,
+	r statement.Recurring,
+	subs []*model.Subscription,
+) (*model.SubscriptionCandidate, error) {
+	c := &model.SubscriptionCandidate{
+		UserID:        userID,
+		MerchantKey:   r.Key,
+		Merchant:      r.Merchant,
+		ServiceName:   r.Merchant,
+		Price:         monthlyPrice(r.Amount, r.Currency, r.BillingPeriod),
+		Amount:        r.Amount,
+		Currency:      r.Currency,
+		BillingPeriod: r.BillingPeriod,
+		FirstCharge:   r.First,
+		LastCharge:    r.Last,
+		Occurrences:   r.Occurrences,
+		Status:        model.CandidatePending,
+	}
+
+	svc, err := s.catalog.Match(ctx, r.Merchant)
+	switch {
+	case err == nil:
+		c.ServiceID = &svc.ID
+		c.ServiceName = svc.Name
+	case !errors.Is(err, model.ErrServiceNotFound):
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, sub := range subs {
+		if sub.Status(now) == model.StatusEnded {
+			continue
+		}
+		if (c.ServiceID != nil && sub.ServiceID == *c.ServiceID) ||
+			statement.MerchantKey(sub.ServiceName) == r.Key {
+			c.Status = model.CandidateExisting
+			c.SubscriptionID = &sub.ID
+			break
+		}
+	}
+
+	return c, nil
+}
+
+// monthlyPrice converts a charge in minor units of currency for a billing
+// period into the whole monthly price subscriptions are stored with.
+func monthlyPrice(amount int64, currency, billingPeriod string) int {
+	months, ok := billingMonths[billingPeriod]
+	if !ok {
+		months = 1
+	}
+	units := float64(amount) / float64(statement.MinorUnits(currency))
+	return max(1, int(math.Round(units/float64(months))))
+}
+
+// ListCandidates returns the subscription candidates of the user, only
+// those with the given status unless it is empty.
+func (s *StatementService) ListCandidates(ctx context.Context, userID uuid.UUID, status string) ([]*model.SubscriptionCandidate, error) {
+	switch status {
+	case "", model.CandidatePending, model.CandidateExisting, model.CandidateAccepted, model.CandidateRejected:
+	default:
+		return nil, apperr.Invalidf("status must be pending, existing, accepted or rejected")
+	}
+
+	candidates, err := s.repo.List(ctx, userID, status)
+	if err != nil {
+		s.logger.Error("failed to list subscription candidates", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// AcceptCandidate creates a subscription from a pending candidate, starting
+// in the month of its first charge. The fields set in a replace those of
+// the candidate.
+func (s *StatementService) AcceptCandidate(
+	ctx context.Context,
+	id uuid.UUID,
+	a model.CandidateAcceptance,
+) (*model.SubscriptionCandidate, *model.Subscription, error) {
+	var (
+		c   *model.SubscriptionCandidate
+		sub *model.Subscription
+	)
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if c, err = s.repo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if c.Status != model.CandidatePending {
+			return model.ErrCandidateDecided
+		}
+
+		first := c.FirstCharge
+		sub = &model.Subscription{
+			ServiceName: c.ServiceName,
+			Price:       c.Price,
+			UserId:      c.UserID,
+			StartDate:   time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC),
+			CategoryID:  a.CategoryID,
+		}
+		if c.ServiceID != nil {
+			sub.ServiceID = *c.ServiceID
+		}
+		if a.ServiceName != nil {
+			sub.ServiceID, sub.ServiceName = uuid.Nil, *a.ServiceName
+		}
+		if a.Price != nil {
+			sub.Price = *a.Price
+		}
+		if a.StartDate != nil {
+			sub.StartDate = *a.StartDate
+		}
+
+		if err := s.subs.CreateSubscription(ctx, sub); err != nil {
+			return err
+		}
+
+		return s.repo.Decide(ctx, c, model.CandidateAccepted, &sub.ID)
+	})
+	if err != nil {
+		s.logger.Error("failed to accept subscription candidate", "error", err, "id", id)
+		return nil, nil, err
+	}
+
+	s.logger.Info("subscription candidate accepted", "id", id, "subscription_id", sub.ID)
+
+	return c, sub, nil
+}
+
+// RejectCandidate marks a pending or existing candidate as rejected, so
+// that importing the same charges again does not bring it back.
+func (s *StatementService) RejectCandidate(ctx context.Context, id uuid.UUID) (*model.SubscriptionCandidate, error) {
+	var c *model.SubscriptionCandidate
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if c, err = s.repo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if c.Status != model.CandidatePending && c.Status != model.CandidateExisting {
+			return model.ErrCandidateDecided
+		}
+
+		return s.repo.Decide(ctx, c, model.CandidateRejected, c.SubscriptionID)
+	})
+	if err != nil {
+		s.logger.Error("failed to reject subscription candidate", "error", err, "id", id)
+		return nil, err
+	}
+
+	s.logger.Info("subscription candidate rejected", "id", id)
+
+	return c, nil
+}
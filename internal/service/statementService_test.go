@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+func TestMonthlyPrice(t *testing.T) {
+	tests := []struct {
+		name          string
+		amount        int64
+		currency      string
+		billingPeriod string
+		want          int
+	}{
+		{"cents", 1599, "EUR", model.BillingMonthly, 16},
+		{"no minor units", 1500, "JPY", model.BillingMonthly, 1500},
+		{"yearly", 3600000, "RUB", model.BillingYearly, 3000},
+		{"quarterly", 89700, "USD", model.BillingQuarterly, 299},
+		{"three decimals", 12345, "KWD", model.BillingMonthly, 12},
+		{"less than one unit", 49, "EUR", model.BillingMonthly, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := monthlyPrice(tt.amount, tt.currency, tt.billingPeriod); got != tt.want {
+				t.Errorf("monthly price = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,53 +1,87 @@
 package service
 
 import (
+	"github.com/Lirohop/App/internal/apperr"
 	"github.com/Lirohop/App/internal/model"
 	"github.com/Lirohop/App/internal/repository"
+	"github.com/Lirohop/App/internal/requestctx"
 	"github.com/Lirohop/App/internal/utils"
 	"context"
-	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const maxTagLength = 50
+
 type SubscriptionService struct {
-	repo   *repository.SubscriptionRepository
-	logger *slog.Logger
+	repo        *repository.SubscriptionRepository
+	catalog     *repository.CatalogRepository
+	categories  *repository.CategoryRepository
+	outbox      *repository.OutboxRepository
+	tx          *repository.Transactor
+	overlapMode string // one of the model.Overlap modes
+	admins      []string // actors that can read deleted subscriptions
+	logger      *slog.Logger
 }
 
-func NewSubscriptionService(repo *repository.SubscriptionRepository, logger *slog.Logger) *SubscriptionService {
-	return &SubscriptionService{repo: repo, logger: logger}
+func NewSubscriptionService(
+	repo *repository.SubscriptionRepository,
+	catalog *repository.CatalogRepository,
+	categories *repository.CategoryRepository,
+	outbox *repository.OutboxRepository,
+	tx *repository.Transactor,
+	overlapMode string,
+	admins []string,
+	logger *slog.Logger,
+) *SubscriptionService {
+	return &SubscriptionService{
+		repo:        repo,
+		catalog:     catalog,
+		categories:  categories,
+		outbox:      outbox,
+		tx:          tx,
+		overlapMode: overlapMode,
+		admins:      admins,
+		logger:      logger,
+	}
 }
 
+// CreateSubscription stores a new subscription of the catalog service given
+// by sub.ServiceID, or else by sub.ServiceName, which is added to the
+// catalog if it matches no service or alias. Without a category the
+// subscription takes the category of the service.
 func (s *SubscriptionService) CreateSubscription(ctx context.Context, sub *model.Subscription) error {
 
-	if sub.ServiceName == "" {
-		s.logger.Warn("invalid subscription data", "reason", "service name is empty")
-		return errors.New("service name is required")
+	if sub.ID == uuid.Nil {
+		sub.ID = uuid.New()
 	}
 
-	if sub.Price <= 0 {
-		s.logger.Warn("invalid subscription data", "reason", "price is not valid")
-		return errors.New("price must be greater than zero")
-	}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		svc, err := s.prepare(ctx, sub, nil)
+		if err != nil {
+			return err
+		}
 
-	if sub.StartDate.IsZero() {
-		s.logger.Warn("invalid subscription data", "reason", "start date is zero")
-		return errors.New("start date is required")
-	}
+		if sub.CategoryID == nil && svc != nil {
+			sub.CategoryID = svc.CategoryID
+		}
 
-	if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
-		s.logger.Warn("invalid subscription data", "reason", "end date is not valid")
-		return errors.New("end date must be after start date")
-	}
+		if err := s.repo.Create(ctx, sub); err != nil {
+			return err
+		}
 
-	if sub.ID == uuid.Nil {
-		sub.ID = uuid.New()
-	}
+		event, err := newEvent(model.EventSubscriptionCreated, sub, sub)
+		if err != nil {
+			return err
+		}
 
-	err := s.repo.Create(ctx, sub)
+		return s.outbox.Add(ctx, event)
+	})
 	if err != nil {
 		s.logger.Error("failed to create subscription", "error", err)
 		return err
@@ -61,49 +95,101 @@ func (s *SubscriptionService) UpdateSubscription(ctx context.Context, sub *model
 
 	if sub.ID == uuid.Nil {
 		s.logger.Warn("invalid subscription data", "reason", "id is nil")
-		return errors.New("id is nil")
+		return apperr.Invalidf("id is nil")
 	}
 
-	if sub.ServiceName == "" {
-		s.logger.Warn("invalid subscription data", "reason", "service name is empty")
-		return errors.New("service name is required")
-	}
+	err := s.update(ctx, sub, s.repo.Update)
 
-	if sub.Price <= 0 {
-		s.logger.Warn("invalid subscription data", "reason", "price is not valid")
-		return errors.New("price must be greater than zero")
+	if err != nil {
+		s.logger.Error("failed to update subscription", "error", err, "id", sub.ID)
+		return err
 	}
 
-	if sub.StartDate.IsZero() {
-		s.logger.Warn("invalid subscription data", "reason", "start date is zero")
-		return errors.New("start date is required")
-	}
+	s.logger.Info("subscription updated", "id", sub.ID)
 
-	if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
-		s.logger.Warn("invalid subscription data", "reason", "end date is not valid")
-		return errors.New("end date must be after start date")
+	return nil
+}
+
+// UpdateSubscriptionIfVersion is UpdateSubscription guarded by the versions
+// the client last saw; see repository.UpdateIfVersion.
+func (s *SubscriptionService) UpdateSubscriptionIfVersion(ctx context.Context, sub *model.Subscription, versions []int) error {
+
+	if sub.ID == uuid.Nil {
+		s.logger.Warn("invalid subscription data", "reason", "id is nil")
+		return apperr.Invalidf("id is nil")
 	}
 
-	err := s.repo.Update(ctx, sub)
+	err := s.update(ctx, sub, func(ctx context.Context, sub *model.Subscription) error {
+		return s.repo.UpdateIfVersion(ctx, sub, versions)
+	})
 
 	if err != nil {
 		s.logger.Error("failed to update subscription", "error", err, "id", sub.ID)
 		return err
 	}
 
-	s.logger.Info("subscription updated", "id", sub.ID)
+	s.logger.Info("subscription updated", "id", sub.ID, "version", sub.Version)
 
 	return nil
 }
 
+// ChangePrice sets the price of the subscription like UpdateSubscription and
+// returns the price it replaced.
+func (s *SubscriptionService) ChangePrice(ctx context.Context, id uuid.UUID, price int) (int, error) {
+
+	var previous int
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		sub, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		previous = sub.Price
+		if sub.Price == price {
+			return nil
+		}
+
+		sub.Price = price
+		return s.update(ctx, sub, s.repo.Update)
+	})
+	if err != nil {
+		s.logger.Error("failed to change subscription price", "error", err, "id", id)
+		return 0, err
+	}
+
+	s.logger.Info("subscription price changed", "id", id, "price", price, "previous_price", previous)
+
+	return previous, nil
+}
+
 func (s *SubscriptionService) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
 
 	if id == uuid.Nil {
 		s.logger.Error("id is nil")
-		return errors.New("id is valid")
+		return apperr.Invalidf("id is valid")
+	}
+
+	err := s.delete(ctx, id, s.repo.Delete)
+	if err != nil {
+		s.logger.Error("failed to delete subscription", "error", err)
+		return err
+	}
+
+	s.logger.Info("subscription successfully deleted", "id", id)
+	return nil
+}
+
+func (s *SubscriptionService) DeleteSubscriptionIfVersion(ctx context.Context, id uuid.UUID, versions []int) error {
+
+	if id == uuid.Nil {
+		s.logger.Error("id is nil")
+		return apperr.Invalidf("id is nil")
 	}
 
-	err := s.repo.Delete(ctx, id)
+	err := s.delete(ctx, id, func(ctx context.Context, id uuid.UUID) error {
+		return s.repo.DeleteIfVersion(ctx, id, versions)
+	})
 	if err != nil {
 		s.logger.Error("failed to delete subscription", "error", err)
 		return err
@@ -113,14 +199,75 @@ func (s *SubscriptionService) DeleteSubscription(ctx context.Context, id uuid.UU
 	return nil
 }
 
-func (s *SubscriptionService) GetSubscriptionById(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+// update runs write together with recording the events it causes.
+func (s *SubscriptionService) update(
+	ctx context.Context,
+	sub *model.Subscription,
+	write func(context.Context, *model.Subscription) error,
+) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetForUpdate(ctx, sub.ID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.prepare(ctx, sub, before); err != nil {
+			return err
+		}
+
+		if err := write(ctx, sub); err != nil {
+			return err
+		}
+
+		events, err := changeEvents(before, sub)
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, events...)
+	})
+}
+
+func (s *SubscriptionService) delete(
+	ctx context.Context,
+	id uuid.UUID,
+	write func(context.Context, uuid.UUID) error,
+) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		sub, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := write(ctx, id); err != nil {
+			return err
+		}
+
+		event, err := newEvent(model.EventSubscriptionDeleted, sub, sub)
+		if err != nil {
+			return err
+		}
+
+		return s.outbox.Add(ctx, event)
+	})
+}
+
+// GetSubscriptionById returns the subscription; soft-deleted ones are
+// reported as model.ErrNotFound unless includeDeleted is set, which only
+// admins can do.
+func (s *SubscriptionService) GetSubscriptionById(ctx context.Context, id uuid.UUID, includeDeleted bool) (*model.Subscription, error) {
 
 	if id == uuid.Nil {
 		s.logger.Error("id is nil")
-		return nil, errors.New("id is valid")
+		return nil, apperr.Invalidf("id is valid")
 	}
 
-	sub, err := s.repo.GetByID(ctx, id)
+	if includeDeleted && !s.isAdmin(ctx) {
+		s.logger.Warn("trash denied", "id", id, "actor", requestctx.Actor(ctx))
+		return nil, model.ErrTrashForbidden
+	}
+
+	sub, err := s.repo.GetByID(ctx, id, includeDeleted)
 	if err != nil {
 		s.logger.Error("failed to get subscription by id", "error", err, "id", id)
 		return nil, err
@@ -131,9 +278,28 @@ func (s *SubscriptionService) GetSubscriptionById(ctx context.Context, id uuid.U
 	return sub, err
 }
 
-func (s *SubscriptionService) List(ctx context.Context) ([]*model.Subscription, error) {
+// LockSubscription returns the live subscription, locked until the
+// transaction in ctx ends.
+func (s *SubscriptionService) LockSubscription(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+
+	sub, err := s.repo.GetForUpdate(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to lock subscription", "error", err, "id", id)
+		return nil, err
+	}
+
+	return sub, nil
+}
 
-	subs, err := s.repo.List(ctx)
+func (s *SubscriptionService) List(ctx context.Context, filter model.SubscriptionFilter) ([]*model.Subscription, error) {
+
+	if err := s.CheckFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	filter.Tags = normalizeTags(filter.Tags)
+
+	subs, err := s.repo.List(ctx, filter)
 
 	if err != nil {
 		s.logger.Error("failed to list subscriptions", "error", err)
@@ -145,6 +311,471 @@ func (s *SubscriptionService) List(ctx context.Context) ([]*model.Subscription,
 	return subs, nil
 }
 
+// isAdmin reports whether the actor is one of the admins. Requests without
+// an actor run as requestctx.SystemActor, which is never an admin.
+func (s *SubscriptionService) isAdmin(ctx context.Context) bool {
+	actor := requestctx.Actor(ctx)
+	return actor != requestctx.SystemActor && slices.Contains(s.admins, actor)
+}
+
+// CheckFilter returns model.ErrTrashForbidden if filter reads deleted
+// subscriptions and the actor is not an admin. List and
+// ExportSubscriptions check their filter themselves.
+func (s *SubscriptionService) CheckFilter(ctx context.Context, filter model.SubscriptionFilter) error {
+	if (filter.IncludeDeleted || filter.OnlyDeleted) && !s.isAdmin(ctx) {
+		s.logger.Warn("trash denied", "actor", requestctx.Actor(ctx))
+		return model.ErrTrashForbidden
+	}
+	return nil
+}
+
+// ExportSubscriptions calls fn with every subscription matching filter,
+// without loading them all into memory.
+func (s *SubscriptionService) ExportSubscriptions(
+	ctx context.Context,
+	filter model.SubscriptionFilter,
+	fn func(*model.Subscription) error,
+) error {
+
+	if err := s.CheckFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	filter.Tags = normalizeTags(filter.Tags)
+
+	if err := s.repo.Stream(ctx, filter, fn); err != nil {
+		s.logger.Error("failed to export subscriptions", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *SubscriptionService) RestoreSubscription(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+
+	if id == uuid.Nil {
+		s.logger.Error("id is nil")
+		return nil, apperr.Invalidf("id is nil")
+	}
+
+	sub, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to restore subscription", "error", err, "id", id)
+		return nil, err
+	}
+
+	s.logger.Info("subscription restored", "id", id)
+
+	return sub, nil
+}
+
+// RunPurge hard-deletes subscriptions that have been in the trash for longer
+// than retention, checking every interval until ctx is done.
+func (s *SubscriptionService) RunPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.repo.PurgeDeleted(ctx, time.Now().Add(-retention)); err != nil {
+				s.logger.Error("failed to purge deleted subscriptions", "error", err)
+			}
+		}
+	}
+}
+
+// PauseSubscription stops charging the subscription from the start month
+// until the resume month; either defaults to an open pause from next month.
+func (s *SubscriptionService) PauseSubscription(
+	ctx context.Context,
+	id uuid.UUID,
+	start, resume *time.Time,
+) (*model.Subscription, error) {
+
+	pause := model.Pause{StartDate: nextMonth(time.Now().UTC()), ResumeDate: resume}
+	if start != nil {
+		pause.StartDate = *start
+	}
+
+	var sub *model.Subscription
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.validatePause(before, pause); err != nil {
+			return err
+		}
+
+		sub, err = s.repo.AddPause(ctx, id, &pause)
+		return err
+	})
+	if err != nil {
+		s.logger.Error("failed to pause subscription", "error", err, "id", id)
+		return nil, err
+	}
+
+	s.logger.Info("subscription paused", "id", id, "pause_id", pause.ID, "start_date", pause.StartDate)
+
+	return sub, nil
+}
+
+// ResumeSubscription ends the current or next pause so that the
+// subscription is charged again from the resume month, by default next
+// month. A pause that would not start before then is removed.
+func (s *SubscriptionService) ResumeSubscription(
+	ctx context.Context,
+	id uuid.UUID,
+	resume *time.Time,
+) (*model.Subscription, error) {
+
+	resumeDate := nextMonth(time.Now().UTC())
+	if resume != nil {
+		resumeDate = *resume
+	}
+
+	var sub *model.Subscription
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		pause, started, ok := pauseToResume(before.Pauses, resumeDate)
+		if !ok {
+			return model.ErrNotPaused
+		}
+
+		if started {
+			sub, err = s.repo.ResumePause(ctx, id, pause.ID, resumeDate)
+		} else {
+			sub, err = s.repo.DeletePause(ctx, id, pause.ID)
+		}
+		return err
+	})
+	if err != nil {
+		s.logger.Error("failed to resume subscription", "error", err, "id", id)
+		return nil, err
+	}
+
+	s.logger.Info("subscription resumed", "id", id, "resume_date", resumeDate)
+
+	return sub, nil
+}
+
+// pauseToResume returns the first pause that still runs at resumeDate, and
+// whether it started before then: a started pause is shortened to end at
+// resumeDate, one that has not started yet is deleted.
+func pauseToResume(pauses []model.Pause, resumeDate time.Time) (pause model.Pause, started, ok bool) {
+	for _, p := range pauses {
+		if p.ResumeDate != nil && !p.ResumeDate.After(resumeDate) {
+			continue
+		}
+		return p, p.StartDate.Before(resumeDate), true
+	}
+	return model.Pause{}, false, false
+}
+
+func (s *SubscriptionService) validatePause(sub *model.Subscription, pause model.Pause) error {
+
+	if pause.StartDate.Before(sub.StartDate) {
+		s.logger.Warn("invalid pause", "reason", "pause starts before subscription")
+		return apperr.Invalidf("pause must not start before the subscription")
+	}
+
+	if sub.EndDate != nil && pause.StartDate.After(*sub.EndDate) {
+		s.logger.Warn("invalid pause", "reason", "pause starts after subscription end")
+		return apperr.Invalidf("pause must start before the subscription ends")
+	}
+
+	if pause.ResumeDate != nil && !pause.ResumeDate.After(pause.StartDate) {
+		s.logger.Warn("invalid pause", "reason", "resume date is not after start date")
+		return apperr.Invalidf("resume month must be after start month")
+	}
+
+	for _, p := range sub.Pauses {
+		if p.Overlaps(pause) {
+			s.logger.Warn("invalid pause", "reason", "pause overlaps", "pause_id", p.ID)
+			return model.ErrPauseConflict
+		}
+	}
+
+	return nil
+}
+
+// TrialsEnding returns live subscriptions whose trial ends between now and
+// now+within.
+func (s *SubscriptionService) TrialsEnding(ctx context.Context, within time.Duration) ([]*model.Subscription, error) {
+
+	if within < 0 {
+		s.logger.Warn("invalid trials query", "reason", "within is negative")
+		return nil, apperr.Invalidf("within must not be negative")
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	subs, err := s.repo.ListTrialsEnding(ctx, today, now.Add(within))
+	if err != nil {
+		s.logger.Error("failed to list ending trials", "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("ending trials fetched", "count", len(subs))
+
+	return subs, nil
+}
+
+// RunTrialConversions raises a trial conversion event for every subscription
+// whose trial has ended, checking every interval until ctx is done. Trials of
+// subscriptions that end before they would be charged the full price are
+// marked handled without an event.
+func (s *SubscriptionService) RunTrialConversions(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.convertTrials(ctx, batchSize); err != nil {
+				s.logger.Error("failed to convert trials", "error", err)
+			}
+		}
+	}
+}
+
+func (s *SubscriptionService) convertTrials(ctx context.Context, batchSize int) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		subs, err := s.repo.LockEndedTrials(ctx, time.Now().UTC(), batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range subs {
+			if trialConverts(sub) {
+				event, err := newEvent(model.EventTrialConverted, sub, model.TrialConversion{
+					TrialEnd:   *sub.TrialEnd,
+					TrialPrice: sub.TrialPrice,
+					Price:      sub.Price,
+				})
+				if err != nil {
+					return err
+				}
+
+				if err := s.outbox.Add(ctx, event); err != nil {
+					return err
+				}
+
+				s.logger.Info("trial converted", "id", sub.ID, "user_id", sub.UserId)
+			}
+
+			if err := s.repo.MarkTrialHandled(ctx, sub.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// trialConverts reports whether the subscription, whose trial has ended,
+// is still in service after the trial, so that it is charged the full price.
+func trialConverts(sub *model.Subscription) bool {
+	return sub.EndDate == nil || endOfService(sub).After(*sub.TrialEnd)
+}
+
+// prepare resolves the catalog service of sub, filling in its canonical
+// name and, for a zero price, its default price, and validates the result
+// against before, the stored subscription it replaces or nil for a new one.
+// It returns the service, or nil if sub names none.
+func (s *SubscriptionService) prepare(
+	ctx context.Context,
+	sub *model.Subscription,
+	before *model.Subscription,
+) (*model.Service, error) {
+	var (
+		svc *model.Service
+		err error
+	)
+
+	switch {
+	case sub.ServiceID != uuid.Nil:
+		svc, err = s.catalog.GetByID(ctx, sub.ServiceID)
+	case normalizeName(sub.ServiceName) != "":
+		svc, err = s.catalog.Ensure(ctx, normalizeName(sub.ServiceName))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if svc != nil {
+		applyService(sub, svc)
+	}
+
+	sub.Tags = normalizeTags(sub.Tags)
+
+	if err := s.validate(sub); err != nil {
+		return nil, err
+	}
+
+	if err := checkCategory(ctx, s.categories, sub.CategoryID); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkOverlaps(ctx, sub, before); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+// applyService links sub to its catalog service svc, renaming it to the
+// canonical name and pricing it at the default price if it has none.
+func applyService(sub *model.Subscription, svc *model.Service) {
+	sub.ServiceID = svc.ID
+	sub.ServiceName = svc.Name
+	if sub.Price == 0 && svc.DefaultPrice != nil {
+		sub.Price = *svc.DefaultPrice
+	}
+}
+
+// checkOverlaps applies the overlap mode to a valid sub: in reject mode an
+// overlap with another live subscription of the user to the same service
+// is an error, in warn mode it is recorded in sub.Overlaps. An update that
+// keeps the user, service and months of before is not checked again and
+// keeps its flag, so that a subscription written in another mode can still
+// have its price or tags changed.
+func (s *SubscriptionService) checkOverlaps(ctx context.Context, sub, before *model.Subscription) error {
+	sub.Overlaps = nil
+
+	if before != nil && samePeriod(before, sub) {
+		sub.OverlapAllowed = before.OverlapAllowed
+		return nil
+	}
+
+	sub.OverlapAllowed = s.overlapMode != model.OverlapReject
+
+	if s.overlapMode == model.OverlapAllow {
+		return nil
+	}
+
+	ids, err := s.repo.ListOverlapping(ctx, sub)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if s.overlapMode == model.OverlapReject {
+		s.logger.Warn("invalid subscription data", "reason", "overlaps another subscription", "overlaps", ids)
+		return fmt.Errorf("%w: %s", model.ErrSubscriptionOverlap, ids[0])
+	}
+
+	s.logger.Warn("subscription overlaps another subscription", "id", sub.ID, "user_id", sub.UserId, "overlaps", ids)
+	sub.Overlaps = ids
+
+	return nil
+}
+
+// samePeriod reports whether a and b are of the same user and service over
+// the same months.
+func samePeriod(a, b *model.Subscription) bool {
+	return a.UserId == b.UserId &&
+		a.ServiceID == b.ServiceID &&
+		a.StartDate.Equal(b.StartDate) &&
+		(a.EndDate == nil) == (b.EndDate == nil) &&
+		(a.EndDate == nil || a.EndDate.Equal(*b.EndDate))
+}
+
+// overlapping reports whether a and b are of the same user and service and
+// share a month, like the overlaps that ListOverlapping finds.
+func overlapping(a, b *model.Subscription) bool {
+	if a.UserId != b.UserId || a.ServiceID != b.ServiceID {
+		return false
+	}
+
+	endsBefore := func(sub *model.Subscription, month time.Time) bool {
+		return sub.EndDate != nil && utils.MaxTime(*sub.EndDate, sub.StartDate).Before(month)
+	}
+
+	return !endsBefore(a, b.StartDate) && !endsBefore(b, a.StartDate)
+}
+
+// ListOverlaps returns the pairs of live subscriptions of the user to the
+// same service that share a month, whatever the overlap mode was when
+// they were written.
+func (s *SubscriptionService) ListOverlaps(ctx context.Context, userID uuid.UUID) ([]*model.Overlap, error) {
+	overlaps, err := s.repo.ListOverlaps(ctx, userID)
+	if err != nil {
+		s.logger.Error("failed to list overlaps", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	s.logger.Info("overlaps fetched", "user_id", userID, "count", len(overlaps))
+
+	return overlaps, nil
+}
+
+func (s *SubscriptionService) validate(sub *model.Subscription) error {
+
+	if sub.ServiceName == "" {
+		s.logger.Warn("invalid subscription data", "reason", "service name is empty")
+		return apperr.Invalidf("service name is required")
+	}
+
+	if sub.Price <= 0 {
+		s.logger.Warn("invalid subscription data", "reason", "price is not valid")
+		return apperr.Invalidf("price must be greater than zero")
+	}
+
+	if sub.StartDate.IsZero() {
+		s.logger.Warn("invalid subscription data", "reason", "start date is zero")
+		return apperr.Invalidf("start date is required")
+	}
+
+	if sub.EndDate != nil && sub.EndDate.Before(sub.StartDate) {
+		s.logger.Warn("invalid subscription data", "reason", "end date is not valid")
+		return apperr.Invalidf("end date must be after start date")
+	}
+
+	if sub.TrialPrice < 0 {
+		s.logger.Warn("invalid subscription data", "reason", "trial price is negative")
+		return apperr.Invalidf("trial price must not be negative")
+	}
+
+	if sub.TrialEnd != nil && !sub.TrialEnd.After(sub.StartDate) {
+		s.logger.Warn("invalid subscription data", "reason", "trial end is not valid")
+		return apperr.Invalidf("trial end must be after start date")
+	}
+
+	for _, tag := range sub.Tags {
+		if tag == "" || len(tag) > maxTagLength {
+			s.logger.Warn("invalid subscription data", "reason", "tag is not valid", "tag", tag)
+			return apperr.Invalidf("tags must be 1 to %d characters long", maxTagLength)
+		}
+	}
+
+	if sub.TrialEnd == nil && sub.TrialPrice != 0 {
+		s.logger.Warn("invalid subscription data", "reason", "trial price without trial end")
+		return apperr.Invalidf("trial price requires trial end")
+	}
+
+	return nil
+}
+
+// CalculateSubscriptionsTotalCost returns the user's share of what the
+// subscriptions the user owns or is a member of cost in the months from
+// dateStart through dateEnd, only those of the service serviceName
+// resolves to unless it is empty.
 func (s *SubscriptionService) CalculateSubscriptionsTotalCost(
 	ctx context.Context,
 	userId uuid.UUID,
@@ -153,41 +784,149 @@ func (s *SubscriptionService) CalculateSubscriptionsTotalCost(
 	dateEnd time.Time,
 ) (int, error) {
 
-	sub, err := s.repo.GetByUserAndService(ctx, userId, serviceName)
+	counts, err := s.costFilter(ctx, serviceName, dateStart, dateEnd)
 	if err != nil {
-		s.logger.Error("failed to get subscription", "error", err)
 		return 0, err
 	}
 
-	periodStart := utils.MaxTime(dateStart, sub.StartDate)
-
-	var periodEnd time.Time
-	if sub.EndDate != nil {
-		periodEnd = utils.MinTime(dateEnd, *sub.EndDate)
-	} else {
-		periodEnd = dateEnd
+	subs, err := s.repo.List(ctx, model.SubscriptionFilter{MemberID: &userId})
+	if err != nil {
+		s.logger.Error("failed to get subscriptions", "error", err)
+		return 0, err
 	}
 
-	if periodStart.After(periodEnd) {
-		s.logger.Info("no overlapping period for subscription",
-			"user_id", userId,
-			"service", serviceName,
-		)
-		return 0, nil
+	totalPrice := 0
+	for _, sub := range subs {
+		if counts(sub) {
+			totalPrice += memberCost(sub, userId, dateStart, dateEnd)
+		}
 	}
 
-	months := (periodEnd.Year()-periodStart.Year())*12 +
-		int(periodEnd.Month()-periodStart.Month()) + 1
-
-	totalPrice := months * sub.Price
-
 	s.logger.Debug(
 		"calculated subscription cost",
 		"user_id", userId,
 		"service", serviceName,
-		"months", months,
 		"total_price", totalPrice,
 	)
 
 	return totalPrice, nil
 }
+
+// ListByMembers returns, by user, the live subscriptions each of the users
+// owns or is a member of, like List with MemberID, in one query.
+func (s *SubscriptionService) ListByMembers(
+	ctx context.Context,
+	userIDs []uuid.UUID,
+) (map[uuid.UUID][]*model.Subscription, error) {
+
+	subs, err := s.repo.ListByMembers(ctx, userIDs)
+	if err != nil {
+		s.logger.Error("failed to list subscriptions of members", "error", err)
+		return nil, err
+	}
+
+	requested := make(map[uuid.UUID]bool, len(userIDs))
+	for _, id := range userIDs {
+		requested[id] = true
+	}
+
+	byUser := make(map[uuid.UUID][]*model.Subscription, len(userIDs))
+	for _, sub := range subs {
+		if requested[sub.UserId] {
+			byUser[sub.UserId] = append(byUser[sub.UserId], sub)
+		}
+		for _, m := range sub.Members {
+			if requested[m.UserID] && m.UserID != sub.UserId {
+				byUser[m.UserID] = append(byUser[m.UserID], sub)
+			}
+		}
+	}
+
+	return byUser, nil
+}
+
+// MonthlyCosts returns, by user, the share of each of the users of what
+// the subscriptions they own or are members of cost in every month from
+// dateStart through dateEnd, calculated like CalculateSubscriptionsTotalCost.
+func (s *SubscriptionService) MonthlyCosts(
+	ctx context.Context,
+	userIDs []uuid.UUID,
+	serviceName string,
+	dateStart time.Time,
+	dateEnd time.Time,
+) (map[uuid.UUID][]model.MonthlyCost, error) {
+
+	counts, err := s.costFilter(ctx, serviceName, dateStart, dateEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser, err := s.ListByMembers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make(map[uuid.UUID][]model.MonthlyCost, len(userIDs))
+	for _, userID := range userIDs {
+		months := make([]model.MonthlyCost, 0, monthsBetween(dateStart, dateEnd))
+		for month := dateStart; !month.After(dateEnd); month = month.AddDate(0, 1, 0) {
+			cost := model.MonthlyCost{Month: month}
+			for _, sub := range byUser[userID] {
+				if counts(sub) {
+					cost.Total += memberCost(sub, userID, month, month)
+				}
+			}
+			months = append(months, cost)
+		}
+		costs[userID] = months
+	}
+
+	return costs, nil
+}
+
+// costFilter validates the period of a cost calculation from dateStart
+// through dateEnd and returns whether a subscription counts towards it:
+// every one when serviceName is empty, else those of the service it
+// resolves to.
+func (s *SubscriptionService) costFilter(
+	ctx context.Context,
+	serviceName string,
+	dateStart time.Time,
+	dateEnd time.Time,
+) (func(*model.Subscription) bool, error) {
+
+	if dateEnd.Before(dateStart) {
+		s.logger.Warn("invalid cost period", "reason", "end is before start")
+		return nil, apperr.Invalidf("end must not be before start")
+	}
+
+	if monthsBetween(dateStart, dateEnd) > maxReportMonths {
+		s.logger.Warn("invalid cost period", "reason", "window is too long")
+		return nil, apperr.Invalidf("window must not be longer than %d months", maxReportMonths)
+	}
+
+	if serviceName == "" {
+		return func(*model.Subscription) bool { return true }, nil
+	}
+
+	svc, err := s.catalog.Resolve(ctx, serviceName)
+	if err != nil {
+		s.logger.Error("failed to resolve service", "error", err, "service", serviceName)
+		return nil, err
+	}
+
+	return func(sub *model.Subscription) bool { return sub.ServiceID == svc.ID }, nil
+}
+
+// normalizeTags lowercases tags and collapses their whitespace, dropping
+// duplicates.
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(normalizeName(tag))
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
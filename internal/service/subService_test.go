@@ -0,0 +1,140 @@
+package service
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestPauseToResume(t *testing.T) {
+	ended := model.Pause{ID: uuid.New(), StartDate: month(2025, time.January), ResumeDate: monthPtr(2025, time.March)}
+	open := model.Pause{ID: uuid.New(), StartDate: month(2025, time.April)}
+	closed := model.Pause{ID: uuid.New(), StartDate: month(2025, time.April), ResumeDate: monthPtr(2025, time.August)}
+
+	tests := []struct {
+		name    string
+		pauses  []model.Pause
+		resume  time.Time
+		want    model.Pause
+		started bool
+		ok      bool
+	}{
+		{
+			name:    "shortens an open pause",
+			pauses:  []model.Pause{ended, open},
+			resume:  month(2025, time.June),
+			want:    open,
+			started: true,
+			ok:      true,
+		},
+		{
+			name:    "shortens a pause",
+			pauses:  []model.Pause{ended, closed},
+			resume:  month(2025, time.June),
+			want:    closed,
+			started: true,
+			ok:      true,
+		},
+		{
+			name:   "deletes a pause starting at the resume month",
+			pauses: []model.Pause{ended, closed},
+			resume: month(2025, time.April),
+			want:   closed,
+			ok:     true,
+		},
+		{
+			name:   "deletes a pause starting after the resume month",
+			pauses: []model.Pause{open},
+			resume: month(2025, time.February),
+			want:   open,
+			ok:     true,
+		},
+		{
+			name:   "pause ending at the resume month",
+			pauses: []model.Pause{ended},
+			resume: month(2025, time.March),
+		},
+		{
+			name:   "not paused",
+			resume: month(2025, time.March),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, started, ok := pauseToResume(tt.pauses, tt.resume)
+			if ok != tt.ok || started != tt.started || got.ID != tt.want.ID {
+				t.Errorf("pauseToResume = %s, %t, %t, want %s, %t, %t",
+					got.ID, started, ok, tt.want.ID, tt.started, tt.ok)
+			}
+		})
+	}
+}
+
+func TestTrialConverts(t *testing.T) {
+	trialEnd := time.Date(2025, time.April, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		endDate  *time.Time
+		trialEnd time.Time
+		want     bool
+	}{
+		{name: "no end", trialEnd: trialEnd, want: true},
+		{name: "ends after the trial", endDate: monthPtr(2025, time.April), trialEnd: trialEnd, want: true},
+		{name: "ends before the trial ends", endDate: monthPtr(2025, time.March), trialEnd: trialEnd},
+		{name: "ends as the trial ends", endDate: monthPtr(2025, time.April), trialEnd: month(2025, time.May)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{StartDate: month(2025, time.January), EndDate: tt.endDate, TrialEnd: &tt.trialEnd}
+			if got := trialConverts(sub); got != tt.want {
+				t.Errorf("converts = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTrial(t *testing.T) {
+	s := &SubscriptionService{logger: slog.New(slog.DiscardHandler)}
+
+	tests := []struct {
+		name       string
+		trialEnd   *time.Time
+		trialPrice int
+		valid      bool
+	}{
+		{name: "no trial", valid: true},
+		{name: "free trial", trialEnd: monthPtr(2025, time.February), valid: true},
+		{name: "paid trial", trialEnd: monthPtr(2025, time.February), trialPrice: 10, valid: true},
+		{name: "negative trial price", trialEnd: monthPtr(2025, time.February), trialPrice: -1},
+		{name: "trial ending at the start", trialEnd: monthPtr(2025, time.January)},
+		{name: "trial price without trial", trialPrice: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &model.Subscription{
+				ServiceName: "Netflix",
+				Price:       100,
+				StartDate:   month(2025, time.January),
+				TrialEnd:    tt.trialEnd,
+				TrialPrice:  tt.trialPrice,
+			}
+
+			err := s.validate(sub)
+			if (err == nil) != tt.valid {
+				t.Errorf("validate = %v, want valid %t", err, tt.valid)
+			}
+			if err != nil && apperr.KindOf(err) != apperr.Invalid {
+				t.Errorf("validate = %v, want an invalid error", err)
+			}
+		})
+	}
+}
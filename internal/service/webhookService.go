@@ -0,0 +1,336 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Lirohop/App/internal/apperr"
+	"github.com/Lirohop/App/internal/model"
+	"github.com/Lirohop/App/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	webhookBaseBackoff   = 30 * time.Second
+	webhookMaxBackoff    = 6 * time.Hour
+	maxDeliveriesListed  = 200
+	maxWebhookErrorBytes = 1024
+)
+
+var eventTypes = []string{
+	model.EventSubscriptionCreated,
+	model.EventPriceChanged,
+	model.EventSubscriptionEnded,
+	model.EventSubscriptionDeleted,
+	model.EventTrialConverted,
+	model.EventServiceRenamed,
+}
+
+// WebhookService sends events to registered webhooks. Deliveries are sent
+// outside of any transaction, so a slow endpoint holds no database
+// connection or lock.
+type WebhookService struct {
+	repo        *repository.WebhookRepository
+	client      *http.Client
+	batchSize   int
+	maxAttempts int
+	logger      *slog.Logger
+}
+
+func NewWebhookService(
+	repo *repository.WebhookRepository,
+	timeout time.Duration,
+	batchSize int,
+	maxAttempts int,
+	logger *slog.Logger,
+) *WebhookService {
+	return &WebhookService{
+		repo:        repo,
+		client:      &http.Client{Timeout: timeout},
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// CreateWebhook registers a webhook, generating a secret if none is given.
+func (s *WebhookService) CreateWebhook(ctx context.Context, w *model.Webhook) error {
+
+	if w.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return err
+		}
+		w.Secret = secret
+	}
+
+	if err := s.validate(w); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, w); err != nil {
+		s.logger.Error("failed to create webhook", "error", err)
+		return err
+	}
+
+	s.logger.Info("webhook created", "id", w.ID, "url", w.URL)
+	return nil
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, w *model.Webhook) error {
+
+	if err := s.validate(w); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, w); err != nil {
+		s.logger.Error("failed to update webhook", "error", err, "id", w.ID)
+		return err
+	}
+
+	s.logger.Info("webhook updated", "id", w.ID)
+	return nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		s.logger.Error("failed to delete webhook", "error", err, "id", id)
+		return err
+	}
+
+	s.logger.Info("webhook deleted", "id", id)
+	return nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	return s.repo.List(ctx)
+}
+
+// ListDeliveries returns the latest deliveries of the webhook, optionally
+// only those with the given status.
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string) ([]*model.WebhookDelivery, error) {
+
+	if _, err := s.repo.GetByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListDeliveries(ctx, webhookID, status, maxDeliveriesListed)
+}
+
+// Redeliver queues a delivery again, including dead and succeeded ones.
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	return s.repo.Redeliver(ctx, webhookID, deliveryID)
+}
+
+// Publish implements sink.Sink by queueing a delivery for every matching
+// webhook. Publishing an event again queues no further deliveries.
+func (s *WebhookService) Publish(ctx context.Context, event model.Event) error {
+	queued, err := s.repo.Enqueue(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if queued > 0 {
+		s.logger.Debug("webhook deliveries queued", "event_id", event.ID, "count", queued)
+	}
+	return nil
+}
+
+func (s *WebhookService) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// RunDelivery sends due deliveries every interval until ctx is done.
+func (s *WebhookService) RunDelivery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.deliverBatch(ctx); err != nil {
+				s.logger.Error("failed to deliver webhooks", "error", err)
+			}
+		}
+	}
+}
+
+func (s *WebhookService) deliverBatch(ctx context.Context) error {
+	// Every delivery of the batch may take up to the client timeout; those
+	// not recorded by then are claimed again.
+	claimedUntil := time.Now().Add(max(s.client.Timeout, time.Second) * time.Duration(s.batchSize+1))
+
+	deliveries, err := s.repo.ClaimDue(ctx, s.batchSize, claimedUntil)
+	if err != nil {
+		return err
+	}
+
+	hooks := make(map[uuid.UUID]*model.Webhook)
+
+	for _, d := range deliveries {
+		hook, ok := hooks[d.WebhookID]
+		if !ok {
+			hook, err = s.repo.GetByID(ctx, d.WebhookID)
+			if errors.Is(err, model.ErrWebhookNotFound) {
+				// Deleted since it was claimed, with its deliveries.
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			hooks[d.WebhookID] = hook
+		}
+
+		s.attempt(ctx, hook, d)
+
+		if err := s.repo.RecordAttempt(ctx, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attempt sends d to the webhook and updates d with the outcome.
+func (s *WebhookService) attempt(ctx context.Context, hook *model.Webhook, d *model.WebhookDelivery) {
+	d.Attempts++
+
+	statusCode, err := s.send(ctx, hook, d)
+	d.LastStatusCode = statusCode
+
+	if err == nil {
+		now := time.Now()
+		d.Status = model.DeliverySucceeded
+		d.DeliveredAt = &now
+		d.LastError = nil
+		s.logger.Debug("webhook delivered", "delivery_id", d.ID, "webhook_id", hook.ID)
+		return
+	}
+
+	msg := err.Error()
+	d.LastError = &msg
+
+	if d.Attempts >= s.maxAttempts {
+		d.Status = model.DeliveryDead
+		s.logger.Warn("webhook delivery is dead",
+			"delivery_id", d.ID,
+			"webhook_id", hook.ID,
+			"attempts", d.Attempts,
+			"error", err,
+		)
+		return
+	}
+
+	d.NextAttemptAt = time.Now().Add(webhookBackoff(d.Attempts))
+	s.logger.Warn("webhook delivery failed",
+		"delivery_id", d.ID,
+		"webhook_id", hook.ID,
+		"attempts", d.Attempts,
+		"next_attempt_at", d.NextAttemptAt,
+		"error", err,
+	)
+}
+
+func (s *WebhookService) send(ctx context.Context, hook *model.Webhook, d *model.WebhookDelivery) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", hook.ID.String())
+	req.Header.Set("X-Delivery-ID", d.ID.String())
+	req.Header.Set("X-Event-Type", d.EventType)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(hook.Secret, time.Now(), d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	statusCode := resp.StatusCode
+	if statusCode < 200 || statusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookErrorBytes))
+		return &statusCode, fmt.Errorf("webhook responded with status %d: %s", statusCode, body)
+	}
+
+	return &statusCode, nil
+}
+
+func (s *WebhookService) validate(w *model.Webhook) error {
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		s.logger.Warn("invalid webhook data", "reason", "url is not valid")
+		return apperr.Invalidf("url must be an absolute http or https URL")
+	}
+
+	if w.Secret == "" {
+		s.logger.Warn("invalid webhook data", "reason", "secret is empty")
+		return apperr.Invalidf("secret is required")
+	}
+
+	if w.EventTypes == nil {
+		w.EventTypes = []string{}
+	}
+	for _, t := range w.EventTypes {
+		if !slices.Contains(eventTypes, t) {
+			s.logger.Warn("invalid webhook data", "reason", "unknown event type", "event_type", t)
+			return apperr.Invalidf("unknown event type %q", t)
+		}
+	}
+
+	return nil
+}
+
+// SignWebhookPayload returns the signature header value for body:
+// "t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">". Receivers
+// recompute it with the shared secret and should reject old timestamps.
+func SignWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff << min(attempts-1, 16)
+	return min(backoff, webhookMaxBackoff)
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
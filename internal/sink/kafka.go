@@ -0,0 +1,54 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink writes events keyed by subscription id, so all events of one
+// subscription land in the same partition and keep their order.
+type KafkaSink struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaSink(brokers []string, topic string) (*KafkaSink, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("kafka sink requires kafka.brokers")
+	}
+
+	return &KafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// The relay writes one message at a time and waits for it, so
+		// there is no batch worth waiting a second for.
+		BatchTimeout: 10 * time.Millisecond,
+	}}, nil
+}
+
+func (s *KafkaSink) Publish(ctx context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.SubscriptionID.String()),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(event.ID.String())},
+			{Key: "event_type", Value: []byte(event.Type)},
+		},
+	})
+}
+
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
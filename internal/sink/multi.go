@@ -0,0 +1,34 @@
+package sink
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+type multiSink []Sink
+
+// Multi publishes every event to all sinks in order and stops at the first
+// error. Sinks must tolerate receiving an event again when a later sink
+// failed and the event is retried.
+func Multi(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Publish(ctx context.Context, event model.Event) error {
+	for _, s := range m {
+		if err := s.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiSink) Close() error {
+	var errs []error
+	for _, s := range m {
+		errs = append(errs, s.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Lirohop/App/internal/model"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSSink publishes events to JetStream under "<subject>.<event type>".
+// The stream must already exist. The event id is sent as Nats-Msg-Id, so
+// redeliveries within the stream's duplicate window are dropped.
+type NATSSink struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+}
+
+func NewNATSSink(url, subject string) (*NATSSink, error) {
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &NATSSink{conn: conn, js: js, subject: subject}, nil
+}
+
+func (s *NATSSink) Publish(ctx context.Context, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.js.Publish(ctx, s.subject+"."+event.Type, data, jetstream.WithMsgID(event.ID.String()))
+	return err
+}
+
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
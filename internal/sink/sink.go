@@ -0,0 +1,44 @@
+// Package sink publishes domain events to external systems.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/Lirohop/App/internal/config"
+	"github.com/Lirohop/App/internal/model"
+)
+
+const (
+	TypeStdout  = "stdout"
+	TypeFile    = "file"
+	TypeWebhook = "webhook"
+	TypeNATS    = "nats"
+	TypeKafka   = "kafka"
+)
+
+// Sink delivers events. Publish must return nil only once the event is
+// accepted by the destination; events may be delivered more than once.
+type Sink interface {
+	Publish(ctx context.Context, event model.Event) error
+	Close() error
+}
+
+// New creates the sink selected by cfg.Sink.
+func New(cfg config.EventsConfig) (Sink, error) {
+	switch cfg.Sink {
+	case TypeStdout, "":
+		return NewWriterSink(os.Stdout), nil
+	case TypeFile:
+		return NewFileSink(cfg.FilePath)
+	case TypeWebhook:
+		return NewWebhookSink(cfg.Webhook.URL, cfg.Webhook.Timeout)
+	case TypeNATS:
+		return NewNATSSink(cfg.NATS.URL, cfg.NATS.Subject)
+	case TypeKafka:
+		return NewKafkaSink(cfg.Kafka.Brokers, cfg.Kafka.Topic)
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", cfg.Sink)
+	}
+}
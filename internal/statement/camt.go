@@ -0,0 +1,123 @@
+package statement
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// camtDocument is the part of an ISO 20022 camt.053 bank-to-customer
+// statement that is read. Namespaces are ignored so that all versions of
+// the message are accepted.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	CreditDebit string `xml:"CdtDbtInd"`
+	Status      struct {
+		Value string `xml:",chardata"`
+		Code  string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate camtDate `xml:"BookgDt"`
+	ValueDate   camtDate `xml:"ValDt"`
+	Details     []struct {
+		Creditor     string `xml:"RltdPties>Cdtr>Nm"`
+		CreditorPty  string `xml:"RltdPties>Cdtr>Pty>Nm"`
+		Unstructured string `xml:"RmtInf>Ustrd"`
+	} `xml:"NtryDtls>TxDtls"`
+	Info string `xml:"AddtlNtryInf"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+func (d camtDate) time() (time.Time, bool) {
+	switch {
+	case d.Date != "":
+		t, err := time.Parse(time.DateOnly, d.Date)
+		return t, err == nil
+	case len(d.DateTime) >= 10:
+		t, err := time.Parse(time.DateOnly, d.DateTime[:10])
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// parseCAMT053 reads the booked entries of a camt.053 statement.
+func parseCAMT053(data []byte) ([]Transaction, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 statement: %w", err)
+	}
+
+	var txs []Transaction
+
+	for _, stmt := range doc.Statements {
+		for _, e := range stmt.Entries {
+			status := e.Status.Code
+			if status == "" {
+				status = strings.TrimSpace(e.Status.Value)
+			}
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			date, ok := e.BookingDate.time()
+			if !ok {
+				if date, ok = e.ValueDate.time(); !ok {
+					return nil, errors.New("camt.053 entry has no booking date")
+				}
+			}
+
+			amount, err := parseAmount(e.Amount.Value, e.Amount.Currency)
+			if err != nil {
+				return nil, err
+			}
+			if e.CreditDebit == "DBIT" {
+				amount = -amount
+			}
+
+			txs = append(txs, Transaction{
+				Date:     date,
+				Amount:   amount,
+				Currency: e.Amount.Currency,
+				Merchant: camtMerchant(e),
+			})
+		}
+	}
+
+	if len(txs) == 0 {
+		return nil, errors.New("no booked entries found in camt.053 statement")
+	}
+
+	return txs, nil
+}
+
+// camtMerchant returns the creditor of the entry, falling back to the
+// remittance information.
+func camtMerchant(e camtEntry) string {
+	for _, d := range e.Details {
+		for _, name := range []string{d.Creditor, d.CreditorPty} {
+			if name = strings.TrimSpace(name); name != "" {
+				return name
+			}
+		}
+	}
+	for _, d := range e.Details {
+		if u := strings.TrimSpace(d.Unstructured); u != "" {
+			return u
+		}
+	}
+	return strings.TrimSpace(e.Info)
+}
@@ -0,0 +1,111 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+var csvFields = []string{"date", "amount", "description", "currency"}
+
+// csvDateLayouts are tried in order; day-first dates are preferred over
+// month-first ones.
+var csvDateLayouts = []string{
+	time.DateOnly,
+	"02.01.2006",
+	"02/01/2006",
+	"01/02/2006",
+	"2006/01/02",
+}
+
+// parseCSV reads a statement with a header row. Amounts are negative for
+// debits. The delimiter is a comma or, as exported by many banks, a
+// semicolon.
+func parseCSV(data []byte, mapping map[string]string) ([]Transaction, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for _, field := range csvFields {
+		name, ok := mapping[field]
+		if !ok {
+			name = field
+		}
+		i := slices.IndexFunc(header, func(h string) bool {
+			return strings.EqualFold(strings.TrimSpace(h), name)
+		})
+		if i >= 0 {
+			columns[field] = i
+		} else if field != "currency" {
+			return nil, fmt.Errorf("column %q of field %s is missing", name, field)
+		}
+	}
+
+	value := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var txs []Transaction
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		date, err := parseCSVDate(value(record, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		currency := strings.ToUpper(value(record, "currency"))
+
+		amount, err := parseAmount(value(record, "amount"), currency)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		txs = append(txs, Transaction{
+			Date:     date,
+			Amount:   amount,
+			Currency: currency,
+			Merchant: value(record, "description"),
+		})
+	}
+
+	return txs, nil
+}
+
+func parseCSVDate(s string) (time.Time, error) {
+	for _, layout := range csvDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
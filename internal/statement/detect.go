@@ -0,0 +1,133 @@
+package statement
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+// amountTolerance is how much, in percent, the charges of one subscription
+// may differ, to allow for exchange rates and small price changes.
+const amountTolerance = 10
+
+// periods are the billing periods recognized, with the days allowed
+// between two charges and the charges needed to call them recurring.
+var periods = []struct {
+	billingPeriod  string
+	minDays        int
+	maxDays        int
+	minOccurrences int
+}{
+	{model.BillingMonthly, 26, 35, 3},
+	{model.BillingQuarterly, 85, 97, 2},
+	{model.BillingYearly, 355, 376, 2},
+}
+
+// Recurring is a series of charges of a merchant in one currency. Amount is
+// the latest charge, in minor units of Currency.
+type Recurring struct {
+	Key           string
+	Merchant      string
+	Amount        int64
+	Currency      string
+	BillingPeriod string
+	First         time.Time
+	Last          time.Time
+	Occurrences   int
+}
+
+// FindRecurring returns the series of debits of the same merchant, in the
+// same currency and of about the same amount that are charged every month,
+// quarter or year.
+func FindRecurring(txs []Transaction) []Recurring {
+	type merchant struct {
+		key      string
+		currency string
+	}
+
+	byMerchant := make(map[merchant][]Transaction)
+	for _, tx := range txs {
+		key := MerchantKey(tx.Merchant)
+		if tx.Amount >= 0 || key == "" {
+			continue
+		}
+		m := merchant{key: key, currency: tx.Currency}
+		byMerchant[m] = append(byMerchant[m], tx)
+	}
+
+	var result []Recurring
+
+	for m, charges := range byMerchant {
+		sort.SliceStable(charges, func(i, j int) bool { return charges[i].Date.Before(charges[j].Date) })
+
+		for _, series := range clusterByAmount(charges) {
+			if r, ok := recurring(m.key, series); ok {
+				result = append(result, r)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Key != result[j].Key {
+			return result[i].Key < result[j].Key
+		}
+		if result[i].Currency != result[j].Currency {
+			return result[i].Currency < result[j].Currency
+		}
+		return result[i].First.Before(result[j].First)
+	})
+
+	return result
+}
+
+// clusterByAmount splits charges of one currency, sorted by date, into
+// series whose amounts stay within amountTolerance of the previous charge.
+func clusterByAmount(charges []Transaction) [][]Transaction {
+	var clusters [][]Transaction
+
+next:
+	for _, tx := range charges {
+		for i, c := range clusters {
+			last := -c[len(c)-1].Amount
+			if diff := -tx.Amount - last; diff*100 <= last*amountTolerance && -diff*100 <= last*amountTolerance {
+				clusters[i] = append(c, tx)
+				continue next
+			}
+		}
+		clusters = append(clusters, []Transaction{tx})
+	}
+
+	return clusters
+}
+
+func recurring(key string, series []Transaction) (Recurring, bool) {
+	for _, p := range periods {
+		if len(series) < p.minOccurrences {
+			continue
+		}
+
+		regular := true
+		for i := 1; i < len(series) && regular; i++ {
+			days := int(series[i].Date.Sub(series[i-1].Date).Hours() / 24)
+			regular = days >= p.minDays && days <= p.maxDays
+		}
+		if !regular {
+			continue
+		}
+
+		last := series[len(series)-1]
+		return Recurring{
+			Key:           key,
+			Merchant:      MerchantName(last.Merchant),
+			Amount:        -last.Amount,
+			Currency:      last.Currency,
+			BillingPeriod: p.billingPeriod,
+			First:         series[0].Date,
+			Last:          last.Date,
+			Occurrences:   len(series),
+		}, true
+	}
+
+	return Recurring{}, false
+}
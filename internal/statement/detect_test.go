@@ -0,0 +1,113 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Lirohop/App/internal/model"
+)
+
+// charges returns debits of amount in currency on the given dates.
+func charges(merchant string, amount int64, currency string, dates ...time.Time) []Transaction {
+	txs := make([]Transaction, len(dates))
+	for i, d := range dates {
+		txs[i] = Transaction{Date: d, Amount: -amount, Currency: currency, Merchant: merchant}
+	}
+	return txs
+}
+
+func TestFindRecurring(t *testing.T) {
+	var txs []Transaction
+	txs = append(txs, charges("NETFLIX.COM 4829", 1599, "EUR",
+		day(2025, time.January, 5), day(2025, time.February, 5), day(2025, time.March, 5))...)
+	// The same merchant charged in another currency is another series.
+	txs = append(txs, charges("Netflix.com 5120", 1799, "USD",
+		day(2025, time.January, 20), day(2025, time.February, 19), day(2025, time.March, 21))...)
+	txs = append(txs, charges("Spotify", 1099, "EUR",
+		day(2025, time.January, 12), day(2025, time.February, 12))...)
+	txs = append(txs, Transaction{Date: day(2025, time.January, 31), Amount: 200000, Currency: "EUR", Merchant: "Payroll"})
+
+	want := []Recurring{
+		{
+			Key: "netflix com", Merchant: "NETFLIX.COM", Amount: 1599, Currency: "EUR",
+			BillingPeriod: model.BillingMonthly,
+			First:         day(2025, time.January, 5), Last: day(2025, time.March, 5), Occurrences: 3,
+		},
+		{
+			Key: "netflix com", Merchant: "Netflix.com", Amount: 1799, Currency: "USD",
+			BillingPeriod: model.BillingMonthly,
+			First:         day(2025, time.January, 20), Last: day(2025, time.March, 21), Occurrences: 3,
+		},
+	}
+
+	if got := FindRecurring(txs); !reflect.DeepEqual(got, want) {
+		t.Errorf("recurring = %+v, want %+v", got, want)
+	}
+}
+
+func TestClusterByAmount(t *testing.T) {
+	txs := []Transaction{
+		{Date: day(2025, time.January, 1), Amount: -1000},
+		{Date: day(2025, time.January, 2), Amount: -1500},
+		{Date: day(2025, time.February, 1), Amount: -1100}, // 10% more than 1000
+		{Date: day(2025, time.March, 1), Amount: -1000},    // within 10% of the previous 1100
+		{Date: day(2025, time.March, 2), Amount: -1700},    // more than 10% over 1500
+	}
+
+	got := clusterByAmount(txs)
+
+	want := [][]Transaction{
+		{txs[0], txs[2], txs[3]},
+		{txs[1]},
+		{txs[4]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clusters = %+v, want %+v", got, want)
+	}
+}
+
+func TestRecurring(t *testing.T) {
+	tests := []struct {
+		name   string
+		dates  []time.Time
+		period string // empty if not recurring
+	}{
+		{
+			name:   "monthly",
+			dates:  []time.Time{day(2025, time.January, 31), day(2025, time.February, 28), day(2025, time.March, 31)},
+			period: model.BillingMonthly,
+		},
+		{
+			name:  "two monthly charges",
+			dates: []time.Time{day(2025, time.January, 5), day(2025, time.February, 5)},
+		},
+		{
+			name:  "irregular",
+			dates: []time.Time{day(2025, time.January, 5), day(2025, time.February, 5), day(2025, time.March, 25)},
+		},
+		{
+			name:   "quarterly",
+			dates:  []time.Time{day(2025, time.January, 5), day(2025, time.April, 5)},
+			period: model.BillingQuarterly,
+		},
+		{
+			name:   "yearly",
+			dates:  []time.Time{day(2024, time.February, 29), day(2025, time.February, 28)},
+			period: model.BillingYearly,
+		},
+		{
+			name:  "two months apart",
+			dates: []time.Time{day(2025, time.January, 5), day(2025, time.March, 5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := recurring("service", charges("Service", 500, "EUR", tt.dates...))
+			if ok != (tt.period != "") || r.BillingPeriod != tt.period {
+				t.Errorf("recurring = %q, %t, want %q", r.BillingPeriod, ok, tt.period)
+			}
+		})
+	}
+}
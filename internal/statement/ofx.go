@@ -0,0 +1,92 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseOFX reads the STMTTRN records of an OFX or QFX statement. Both the
+// SGML form of OFX 1.x, whose elements need not be closed, and the XML
+// form of OFX 2.x are read by taking the text after a start tag up to the
+// next tag as its value.
+func parseOFX(data []byte) ([]Transaction, error) {
+	text := string(data)
+	upper := strings.ToUpper(text)
+
+	currency := ofxValue(text, upper, "CURDEF")
+
+	var txs []Transaction
+
+	for {
+		start := strings.Index(upper, "<STMTTRN>")
+		if start < 0 {
+			break
+		}
+		// In SGML the record may not be closed; it then ends at the next
+		// record or at the end of the list.
+		end := len(upper) - start
+		for _, tag := range []string{"</STMTTRN>", "<STMTTRN>", "</BANKTRANLIST>"} {
+			if i := strings.Index(upper[start+1:], tag); i >= 0 && i+1 < end {
+				end = i + 1
+			}
+		}
+
+		block, blockUpper := text[start:start+end], upper[start:start+end]
+		text, upper = text[start+end:], upper[start+end:]
+		if strings.HasPrefix(upper, "</STMTTRN>") {
+			text, upper = text[len("</STMTTRN>"):], upper[len("</STMTTRN>"):]
+		}
+
+		tx, err := ofxTransaction(block, blockUpper, currency)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+
+	if len(txs) == 0 {
+		return nil, errors.New("no transactions found in OFX statement")
+	}
+
+	return txs, nil
+}
+
+func ofxTransaction(block, upper, currency string) (Transaction, error) {
+	posted := ofxValue(block, upper, "DTPOSTED")
+	if len(posted) < 8 {
+		return Transaction{}, fmt.Errorf("invalid DTPOSTED %q", posted)
+	}
+	date, err := time.Parse("20060102", posted[:8])
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid DTPOSTED %q", posted)
+	}
+
+	amount, err := parseAmount(ofxValue(block, upper, "TRNAMT"), currency)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	merchant := ofxValue(block, upper, "NAME")
+	if merchant == "" {
+		merchant = ofxValue(block, upper, "MEMO")
+	}
+
+	return Transaction{Date: date, Amount: amount, Currency: currency, Merchant: merchant}, nil
+}
+
+// ofxValue returns the text following the first <tag> in text, up to the
+// next tag or line end.
+func ofxValue(text, upper, tag string) string {
+	i := strings.Index(upper, "<"+tag+">")
+	if i < 0 {
+		return ""
+	}
+
+	value := text[i+len(tag)+2:]
+	if end := strings.IndexAny(value, "<\r\n"); end >= 0 {
+		value = value[:end]
+	}
+	return strings.TrimSpace(value)
+}
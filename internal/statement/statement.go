@@ -0,0 +1,177 @@
+// Package statement reads bank statements and finds recurring charges in
+// them.
+package statement
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	FormatOFX     = "ofx" // also QFX, which is OFX with extra tags
+	FormatCAMT053 = "camt053"
+	FormatCSV     = "csv"
+)
+
+// Transaction is a booked statement entry. Amount is in minor units of
+// Currency, see MinorUnits, and negative for debits.
+type Transaction struct {
+	Date     time.Time
+	Amount   int64
+	Currency string
+	Merchant string
+}
+
+// Options control parsing. Mapping maps the CSV fields date, amount,
+// description and currency to column names; unmapped fields are read from
+// the column of the same name.
+type Options struct {
+	Mapping map[string]string
+}
+
+// Parse reads the transactions of a statement in format, detected from
+// the content if empty.
+func Parse(data []byte, format string, opts Options) ([]Transaction, error) {
+	if format == "" {
+		format = Detect(data)
+	}
+
+	switch strings.ToLower(format) {
+	case FormatOFX, "qfx":
+		return parseOFX(data)
+	case FormatCAMT053:
+		return parseCAMT053(data)
+	case FormatCSV:
+		return parseCSV(data, opts.Mapping)
+	default:
+		return nil, fmt.Errorf("unknown statement format %q", format)
+	}
+}
+
+// Detect guesses the format of a statement from its content.
+func Detect(data []byte) string {
+	head := data[:min(len(data), 4096)]
+	switch {
+	case bytes.Contains(head, []byte("OFXHEADER")), bytes.Contains(bytes.ToUpper(head), []byte("<OFX>")):
+		return FormatOFX
+	case bytes.Contains(head, []byte("BkToCstmrStmt")), bytes.Contains(head, []byte("camt.053")):
+		return FormatCAMT053
+	default:
+		return FormatCSV
+	}
+}
+
+// MerchantKey normalizes a merchant name so that charges of the same
+// merchant with changing references, such as "NETFLIX.COM 4829" and
+// "Netflix.com 5120", get the same key.
+func MerchantKey(merchant string) string {
+	fields := strings.FieldsFunc(strings.ToLower(merchant), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// MerchantName tidies a merchant name for display by dropping the words
+// with digits, which are usually references or card numbers.
+func MerchantName(merchant string) string {
+	words := strings.Fields(merchant)
+	kept := words[:0]
+	for _, w := range words {
+		if !strings.ContainsFunc(w, unicode.IsDigit) {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == 0 {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(kept, " ")
+}
+
+// minorDigits are the decimals of the ISO 4217 currencies whose minor unit
+// is not a hundredth.
+var minorDigits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorUnits returns how many minor units of currency make one unit: 100
+// for most currencies, 1 for those without, such as JPY, and 1000 for those
+// with three decimals, such as KWD. Amounts without a currency are read
+// with two decimals.
+func MinorUnits(currency string) int64 {
+	units := int64(1)
+	for range decimals(currency) {
+		units *= 10
+	}
+	return units
+}
+
+func decimals(currency string) int {
+	if digits, ok := minorDigits[strings.ToUpper(currency)]; ok {
+		return digits
+	}
+	return 2
+}
+
+// parseAmount parses a decimal amount into minor units of currency,
+// accepting either "." or "," as the decimal separator and the other,
+// spaces or apostrophes as thousands separators.
+func parseAmount(s, currency string) (int64, error) {
+	s = strings.NewReplacer(" ", "", " ", "", "'", "").Replace(strings.TrimSpace(s))
+	if s == "" {
+		return 0, errors.New("amount is empty")
+	}
+
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0 && comma > dot:
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	case dot >= 0 && comma >= 0:
+		s = strings.ReplaceAll(s, ",", "")
+	case comma >= 0 && len(s)-comma <= 3:
+		s = strings.Replace(s, ",", ".", 1)
+	case comma >= 0:
+		s = strings.ReplaceAll(s, ",", "")
+	}
+
+	digits := decimals(currency)
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > digits {
+		// Banks may write zero decimals for currencies without them.
+		if strings.Trim(frac[digits:], "0") != "" {
+			return 0, fmt.Errorf("amount %q has more than %d decimals", s, digits)
+		}
+		frac = frac[:digits]
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+
+	negative := strings.HasPrefix(whole, "-")
+	whole = strings.TrimLeft(whole, "+-")
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	var minor int64
+	if frac != "" {
+		if minor, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	amount := units*MinorUnits(currency) + minor
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
@@ -0,0 +1,113 @@
+package statement
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func parseFile(t *testing.T, name string, opts Options) []Transaction {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txs, err := Parse(data, "", opts)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return txs
+}
+
+func TestParseOFX(t *testing.T) {
+	got := parseFile(t, "statement.ofx", Options{})
+
+	want := []Transaction{
+		{Date: day(2025, time.January, 5), Amount: -1599, Currency: "EUR", Merchant: "NETFLIX.COM 4829"},
+		{Date: day(2025, time.January, 31), Amount: 200000, Currency: "EUR", Merchant: "ACME PAYROLL"},
+		{Date: day(2025, time.February, 5), Amount: -1599, Currency: "EUR", Merchant: "Netflix.com 5120"},
+		{Date: day(2025, time.March, 5), Amount: -123450, Currency: "EUR", Merchant: "Furniture Store"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transactions = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCAMT053(t *testing.T) {
+	got := parseFile(t, "statement.camt053.xml", Options{})
+
+	want := []Transaction{
+		{Date: day(2025, time.January, 12), Amount: -1099, Currency: "EUR", Merchant: "Spotify AB"},
+		{Date: day(2025, time.February, 3), Amount: -1500, Currency: "JPY", Merchant: "Nintendo eShop"},
+		{Date: day(2025, time.February, 15), Amount: 25000, Currency: "EUR", Merchant: "Refund"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transactions = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	got := parseFile(t, "statement.csv", Options{Mapping: map[string]string{
+		"date":        "Datum",
+		"amount":      "Betrag",
+		"description": "Verwendungszweck",
+		"currency":    "Waehrung",
+	}})
+
+	want := []Transaction{
+		{Date: day(2025, time.January, 5), Amount: -123456, Currency: "EUR", Merchant: "Miete Januar"},
+		{Date: day(2025, time.January, 10), Amount: -500, Currency: "JPY", Merchant: "Nintendo eShop"},
+		{Date: day(2025, time.January, 12), Amount: -999, Currency: "EUR", Merchant: "Apple; iCloud"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transactions = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	_, err := Parse([]byte("date,amount\n2025-01-05,-9.99\n"), FormatCSV, Options{})
+	if err == nil {
+		t.Error("err = nil, want a missing description column")
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		invalid  bool
+	}{
+		{in: "15.99", currency: "EUR", want: 1599},
+		{in: "-15,9", currency: "EUR", want: -1590},
+		{in: "-1.234,56", currency: "EUR", want: -123456},
+		{in: "1,234.56", currency: "USD", want: 123456},
+		{in: "1'234.5", currency: "CHF", want: 123450},
+		{in: "12", want: 1200},
+		{in: "1500", currency: "JPY", want: 1500},
+		{in: "1500.00", currency: "JPY", want: 1500},
+		{in: "1.5", currency: "JPY", invalid: true},
+		{in: "1.234", currency: "KWD", want: 1234},
+		{in: "1.2345", currency: "EUR", invalid: true},
+		{in: "abc", currency: "EUR", invalid: true},
+		{in: "", currency: "EUR", invalid: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in, tt.currency)
+		if (err != nil) != tt.invalid {
+			t.Errorf("parseAmount(%q, %s) error = %v, want invalid %t", tt.in, tt.currency, err, tt.invalid)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAmount(%q, %s) = %d, want %d", tt.in, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+CREATE TABLE subscription_audit (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX idx_subscription_audit_subscription
+ON subscription_audit(subscription_id, created_at);
+
+CREATE INDEX idx_subscription_audit_created_at
+ON subscription_audit(created_at);
+
+CREATE FUNCTION subscription_audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'subscription_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscription_audit_append_only
+BEFORE UPDATE OR DELETE ON subscription_audit
+FOR EACH ROW EXECUTE FUNCTION subscription_audit_append_only()
@@ -0,0 +1,18 @@
+CREATE TABLE notification_settings (
+    user_id UUID PRIMARY KEY,
+    email TEXT,
+    renewal_lead_days INTEGER,
+    end_lead_days INTEGER,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One row per notification that was (or is being) sent. The key identifies
+-- the occurrence, e.g. "renewal:<subscription id>:2025-08-01", so that every
+-- replica sends it only once.
+CREATE TABLE sent_notifications (
+    key TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    sent_at TIMESTAMPTZ
+)
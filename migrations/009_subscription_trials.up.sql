@@ -0,0 +1,8 @@
+ALTER TABLE subscriptions
+ADD COLUMN trial_end DATE,
+ADD COLUMN trial_price INTEGER NOT NULL DEFAULT 0 CHECK (trial_price >= 0),
+ADD COLUMN trial_converted_at TIMESTAMPTZ;
+
+CREATE INDEX idx_subscriptions_trial_end
+ON subscriptions(trial_end)
+WHERE trial_end IS NOT NULL AND trial_converted_at IS NULL
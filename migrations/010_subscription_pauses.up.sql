@@ -0,0 +1,11 @@
+CREATE TABLE subscription_pauses (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    resume_date DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (resume_date IS NULL OR resume_date > start_date)
+);
+
+CREATE INDEX idx_subscription_pauses_subscription
+ON subscription_pauses(subscription_id, start_date)
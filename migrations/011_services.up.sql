@@ -0,0 +1,63 @@
+CREATE FUNCTION service_key(name TEXT) RETURNS TEXT AS $$
+    SELECT lower(regexp_replace(btrim(name), '\s+', ' ', 'g'))
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE TABLE services (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category TEXT,
+    default_price INTEGER CHECK (default_price > 0),
+    currency TEXT,
+    billing_period TEXT NOT NULL DEFAULT 'monthly'
+        CHECK (billing_period IN ('monthly', 'quarterly', 'yearly')),
+    website TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- service_name_keys maps the normalized canonical name and aliases of every
+-- service to it, so that each name resolves to at most one service.
+CREATE TABLE service_name_keys (
+    key TEXT PRIMARY KEY,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_service_name_keys_service
+ON service_name_keys(service_id);
+
+-- One service per normalized name, named after its most used spelling.
+WITH spellings AS (
+    SELECT service_key(service_name) AS key,
+           regexp_replace(btrim(service_name), '\s+', ' ', 'g') AS name,
+           count(*) AS uses
+    FROM subscriptions
+    GROUP BY 1, 2
+), canonical AS (
+    SELECT DISTINCT ON (key) key, name
+    FROM spellings
+    ORDER BY key, uses DESC, name
+), inserted AS (
+    INSERT INTO services(name)
+    SELECT name FROM canonical
+    RETURNING id, name
+)
+INSERT INTO service_name_keys(key, service_id)
+SELECT service_key(name), id FROM inserted;
+
+ALTER TABLE subscriptions
+ADD COLUMN service_id UUID REFERENCES services(id);
+
+UPDATE subscriptions s
+SET service_id = k.service_id,
+    service_name = sv.name,
+    version = s.version + 1
+FROM service_name_keys k
+JOIN services sv ON sv.id = k.service_id
+WHERE k.key = service_key(s.service_name);
+
+ALTER TABLE subscriptions
+ALTER COLUMN service_id SET NOT NULL;
+
+CREATE INDEX idx_subscriptions_user_service_id
+ON subscriptions(user_id, service_id)
@@ -0,0 +1,51 @@
+CREATE TABLE categories (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_categories_name
+ON categories(lower(name));
+
+INSERT INTO categories(name)
+SELECT DISTINCT ON (lower(btrim(category))) btrim(category)
+FROM services
+WHERE btrim(coalesce(category, '')) <> ''
+ORDER BY lower(btrim(category)), btrim(category);
+
+ALTER TABLE services
+ADD COLUMN category_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+
+UPDATE services s
+SET category_id = c.id
+FROM categories c
+WHERE lower(c.name) = lower(btrim(s.category));
+
+ALTER TABLE services
+DROP COLUMN category;
+
+ALTER TABLE subscriptions
+ADD COLUMN category_id UUID REFERENCES categories(id) ON DELETE SET NULL;
+
+UPDATE subscriptions sub
+SET category_id = s.category_id,
+    version = sub.version + 1
+FROM services s
+WHERE s.id = sub.service_id AND s.category_id IS NOT NULL;
+
+CREATE INDEX idx_subscriptions_category
+ON subscriptions(category_id);
+
+CREATE TABLE tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE subscription_tags (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (subscription_id, tag_id)
+);
+
+CREATE INDEX idx_subscription_tags_tag
+ON subscription_tags(tag_id)
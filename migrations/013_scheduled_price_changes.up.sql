@@ -0,0 +1,13 @@
+CREATE TABLE scheduled_price_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_date DATE NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    applied_at TIMESTAMPTZ,
+    UNIQUE (subscription_id, effective_date)
+);
+
+CREATE INDEX idx_scheduled_price_changes_pending
+ON scheduled_price_changes(effective_date)
+WHERE applied_at IS NULL
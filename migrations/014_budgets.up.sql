@@ -0,0 +1,15 @@
+CREATE TABLE budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    scope TEXT NOT NULL CHECK (scope IN ('user', 'category', 'service')),
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    period TEXT NOT NULL CHECK (period IN ('month', 'quarter', 'year')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((scope = 'category') = (category_id IS NOT NULL)),
+    CHECK ((scope = 'service') = (service_id IS NOT NULL))
+);
+
+CREATE INDEX idx_budgets_user_id ON budgets(user_id)
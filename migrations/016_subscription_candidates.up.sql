@@ -0,0 +1,24 @@
+CREATE TABLE subscription_candidates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    merchant_key TEXT NOT NULL,
+    merchant TEXT NOT NULL,
+    service_id UUID REFERENCES services(id) ON DELETE SET NULL,
+    service_name TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL DEFAULT '',
+    billing_period TEXT NOT NULL,
+    first_charge DATE NOT NULL,
+    last_charge DATE NOT NULL,
+    occurrences INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'existing', 'accepted', 'rejected')),
+    subscription_id UUID REFERENCES subscriptions(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_at TIMESTAMPTZ,
+    UNIQUE (user_id, merchant_key, billing_period)
+);
+
+CREATE INDEX idx_subscription_candidates_user_status ON subscription_candidates(user_id, status)
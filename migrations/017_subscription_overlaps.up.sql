@@ -0,0 +1,34 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- subscription_months is the range of months a subscription runs, both
+-- ends included; months are stored as their first day.
+CREATE FUNCTION subscription_months(start_date DATE, end_date DATE) RETURNS daterange AS $$
+    SELECT daterange(start_date, CASE WHEN end_date < start_date THEN start_date ELSE end_date END, '[]')
+$$ LANGUAGE sql IMMUTABLE;
+
+-- overlap_allowed marks subscriptions written while overlaps were allowed
+-- or only warned about. The exclusion constraint leaves them out.
+ALTER TABLE subscriptions
+ADD COLUMN overlap_allowed BOOLEAN NOT NULL DEFAULT false;
+
+-- Overlaps from before the constraint are kept and left to the overlaps
+-- report.
+UPDATE subscriptions s
+SET overlap_allowed = true
+WHERE s.deleted_at IS NULL
+  AND EXISTS (
+      SELECT 1
+      FROM subscriptions o
+      WHERE o.user_id = s.user_id
+        AND o.service_id = s.service_id
+        AND o.id <> s.id
+        AND o.deleted_at IS NULL
+        AND subscription_months(o.start_date, o.end_date) && subscription_months(s.start_date, s.end_date));
+
+ALTER TABLE subscriptions
+ADD CONSTRAINT subscriptions_no_overlap
+EXCLUDE USING gist (
+    user_id WITH =,
+    service_id WITH =,
+    subscription_months(start_date, end_date) WITH &&
+) WHERE (deleted_at IS NULL AND NOT overlap_allowed)
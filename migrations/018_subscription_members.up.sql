@@ -0,0 +1,47 @@
+-- Members share the cost of a subscription with its owner, either by
+-- weight or for a fixed monthly amount out of the full price.
+CREATE TABLE subscription_members (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    weight INTEGER CHECK (weight > 0),
+    amount INTEGER CHECK (amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, user_id),
+    CHECK ((weight IS NULL) <> (amount IS NULL))
+);
+
+CREATE INDEX idx_subscription_members_user
+ON subscription_members(user_id);
+
+-- member_share returns the part of a month's charge of a subscription that
+-- member_id pays. It mirrors memberShare in the service: fixed amounts are
+-- paid in full unless the charge is lower than the price, as in a trial,
+-- or than their sum, when they are scaled down. The rest is split by
+-- weight among the other members and the owner, who has weight 1 unless
+-- listed, and the owner pays what is left after rounding.
+CREATE FUNCTION member_share(sub_id UUID, owner_id UUID, full_price INTEGER, member_id UUID, charge INTEGER)
+RETURNS INTEGER AS $$
+    WITH members AS (
+        SELECT m.user_id, m.weight, m.amount
+        FROM subscription_members m
+        WHERE m.subscription_id = sub_id
+    ), totals AS (
+        SELECT coalesce(sum(amount), 0) AS fixed,
+               coalesce(sum(weight), 0) + CASE WHEN bool_or(user_id = owner_id) THEN 0 ELSE 1 END AS weights
+        FROM members
+    ), fixed AS (
+        SELECT m.user_id, m.weight, m.amount * charge / greatest(full_price, t.fixed, charge, 1) AS share
+        FROM members m, totals t
+    ), remaining AS (
+        SELECT charge - coalesce(sum(share), 0) AS rest
+        FROM fixed
+    ), shares AS (
+        SELECT f.user_id, coalesce(f.share, r.rest * f.weight / t.weights) AS share
+        FROM fixed f, remaining r, totals t
+    )
+    SELECT (CASE
+        WHEN member_id = owner_id
+            THEN charge - coalesce((SELECT sum(share) FROM shares WHERE user_id <> owner_id), 0)
+        ELSE coalesce((SELECT share FROM shares WHERE user_id = member_id), 0)
+    END)::integer
+$$ LANGUAGE sql STABLE
@@ -0,0 +1,5 @@
+-- previous_price is the price an applied change replaced, so that the
+-- months before it are still charged the old price. Changes applied before
+-- the column was added have none and do not count.
+ALTER TABLE scheduled_price_changes
+ADD COLUMN previous_price INTEGER
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- The spend report computes member shares in the service like every other
+-- cost, so the SQL copy of the rules is no longer used.
+DROP FUNCTION member_share(UUID, UUID, INTEGER, UUID, INTEGER)
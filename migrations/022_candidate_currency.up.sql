@@ -0,0 +1,4 @@
+-- Charges of one merchant in different currencies are separate candidates.
+ALTER TABLE subscription_candidates
+DROP CONSTRAINT subscription_candidates_user_id_merchant_key_billing_period_key,
+ADD UNIQUE (user_id, merchant_key, currency, billing_period)